@@ -2,8 +2,11 @@ package audit
 
 import (
 	"database/sql"
+	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/pivotal-cf/scantron/kernel"
 	"github.com/pivotal-cf/scantron/manifest"
 )
 
@@ -27,14 +30,29 @@ type HostResult struct {
 	MismatchedProcesses []MismatchedProcess
 	MissingProcesses    []string
 	UnexpectedPorts     []Port
-	MissingPorts        []Port
+
+	// FirewalledPorts are the unexpected ports which the host firewall
+	// blocks, so are not exposed to other machines.
+	FirewalledPorts []Port
+
+	MissingPorts          []Port
+	MismatchedSysctls     []MismatchedSysctl
+	MissingBootParameters []string
+
+	// KernelNotCollected is set when the manifest has kernel settings but
+	// the scan has no kernel for the host, as for Windows hosts, so the
+	// settings could not be checked.
+	KernelNotCollected bool
 }
 
 func (hr HostResult) OK() bool {
 	return len(hr.MismatchedProcesses) == 0 &&
 		len(hr.MissingProcesses) == 0 &&
 		len(hr.UnexpectedPorts) == 0 &&
-		len(hr.MissingPorts) == 0
+		len(hr.MissingPorts) == 0 &&
+		len(hr.MismatchedSysctls) == 0 &&
+		len(hr.MissingBootParameters) == 0 &&
+		!hr.KernelNotCollected
 }
 
 type MismatchedProcess struct {
@@ -45,67 +63,176 @@ type MismatchedProcess struct {
 	Expected string
 }
 
+// MismatchedSysctl is a kernel parameter with a value other than the
+// manifest's. Actual is empty when the host does not have the parameter.
+type MismatchedSysctl struct {
+	Name     string
+	Actual   string
+	Expected string
+}
+
 type Port int
 
-type AuditInput map[string]manifest.Spec
+// AuditInput is the spec of each host to audit, by the host's id.
+type AuditInput map[int]manifest.Spec
 
-func Audit(db *sql.DB, m manifest.Manifest) (AuditResult, error) {
+type Options struct {
+	// HostOnly leaves out ports opened inside containers, which are
+	// audited along with the host's by default.
+	HostOnly bool
+}
+
+func Audit(db *sql.DB, m manifest.Manifest, options Options) (AuditResult, error) {
 	result := AuditResult{
 		Hosts: make(map[string]HostResult),
 	}
 
-	input, err := mapHostnameToSpec(db, m)
+	hosts, err := scannedHosts(db)
 	if err != nil {
 		return AuditResult{}, err
 	}
 
-	missing, extras, err := lookForMissingAndExtraHosts(db, m.Specs)
+	input, err := mapHostnameToSpec(db, m)
 	if err != nil {
 		return AuditResult{}, err
 	}
 
-	result.ExtraHosts = extras
-	result.MissingHostType = missing
+	result.MissingHostType, result.ExtraHosts = lookForMissingAndExtraHosts(hosts, m.Specs)
 
-	for host, spec := range input {
-		hostResult, err := auditHost(db, host, spec)
+	for _, host := range hosts {
+		spec, ok := input[host.id]
+		if !ok {
+			continue
+		}
+
+		hostResult, err := auditHost(db, host.id, spec, options)
 		if err != nil {
 			return AuditResult{}, err
 		}
 
-		result.Hosts[host] = hostResult
+		result.Hosts[host.label] = hostResult
 	}
 
 	return result, nil
 }
 
-func auditHost(db *sql.DB, host string, spec manifest.Spec) (HostResult, error) {
-	missingProcs, err := lookForMissingProcesses(db, host, spec)
+// scannedHost is a host in the scan. Hosts are told apart by their id since
+// deployments on different directors can have hosts with the same name.
+type scannedHost struct {
+	id    int
+	name  string
+	label string
+}
+
+// scannedHosts returns every host in the scan, labelled with its name. Hosts
+// which share their name with another are labelled with their address and
+// deployment too.
+func scannedHosts(db *sql.DB) ([]scannedHost, error) {
+	rows, err := db.Query(`
+		SELECT hosts.id, hosts.name, hosts.ip, coalesce(deployments.name, ''), coalesce(deployments.director, '')
+		FROM hosts
+			LEFT JOIN deployments
+				ON hosts.deployment_id = deployments.id
+		ORDER BY hosts.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type row struct {
+		scannedHost
+		ip, deployment, director string
+	}
+
+	scanned := []row{}
+	names := map[string]int{}
+	for rows.Next() {
+		var r row
+		err := rows.Scan(&r.id, &r.name, &r.ip, &r.deployment, &r.director)
+		if err != nil {
+			return nil, err
+		}
+
+		scanned = append(scanned, r)
+		names[r.name]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hosts := []scannedHost{}
+	for _, r := range scanned {
+		r.label = r.name
+		if names[r.name] > 1 {
+			deployment := r.deployment
+			if r.director != "" {
+				deployment += " on " + r.director
+			}
+			r.label = fmt.Sprintf("%s (%s, %s)", r.name, r.ip, deployment)
+		}
+
+		hosts = append(hosts, r.scannedHost)
+	}
+
+	return hosts, nil
+}
+
+func auditHost(db *sql.DB, hostID int, spec manifest.Spec, options Options) (HostResult, error) {
+	missingProcs, err := lookForMissingProcesses(db, hostID, spec)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	missingPorts, err := lookForMissingPorts(db, host, spec)
+	missingPorts, err := lookForMissingPorts(db, hostID, spec)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	unexpectedPorts, err := findUnexpectedPorts(db, host, spec)
+	unexpectedPorts, firewalledPorts, err := findUnexpectedPorts(db, hostID, spec, options.HostOnly)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	mismatchedProcesses, err := verifyProcessUsers(db, host, spec)
+	mismatchedProcesses, err := verifyProcessUsers(db, hostID, spec)
 	if err != nil {
 		return HostResult{}, err
 	}
 
-	return HostResult{
-		MissingProcesses:    missingProcs,
-		MissingPorts:        missingPorts,
-		UnexpectedPorts:     unexpectedPorts,
-		MismatchedProcesses: mismatchedProcesses,
-	}, nil
+	hostResult := HostResult{
+		MissingProcesses:      missingProcs,
+		MissingPorts:          missingPorts,
+		UnexpectedPorts:       unexpectedPorts,
+		FirewalledPorts:       firewalledPorts,
+		MismatchedProcesses:   mismatchedProcesses,
+		MismatchedSysctls:     []MismatchedSysctl{},
+		MissingBootParameters: []string{},
+	}
+
+	if spec.Kernel == nil {
+		return hostResult, nil
+	}
+
+	collected, err := kernelCollected(db, hostID)
+	if err != nil {
+		return HostResult{}, err
+	}
+	if !collected {
+		hostResult.KernelNotCollected = true
+		return hostResult, nil
+	}
+
+	hostResult.MismatchedSysctls, err = verifySysctls(db, hostID, spec)
+	if err != nil {
+		return HostResult{}, err
+	}
+
+	hostResult.MissingBootParameters, err = lookForMissingBootParameters(db, hostID, spec)
+	if err != nil {
+		return HostResult{}, err
+	}
+
+	return hostResult, nil
 }
 
 func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
@@ -113,7 +240,7 @@ func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
 
 	for _, spec := range m.Specs {
 		rows, err := db.Query(`
-			SELECT hosts.name
+			SELECT hosts.id
 			FROM hosts
 			WHERE hosts.name LIKE ? || '%'
 		`, spec.Prefix)
@@ -124,32 +251,32 @@ func mapHostnameToSpec(db *sql.DB, m manifest.Manifest) (AuditInput, error) {
 
 		defer rows.Close()
 
-		var hostName string
+		var hostID int
 
 		for rows.Next() {
-			err := rows.Scan(&hostName)
+			err := rows.Scan(&hostID)
 			if err != nil {
 				return AuditInput{}, err
 			}
 
-			input[hostName] = spec
+			input[hostID] = spec
 		}
 	}
 
 	return input, nil
 }
 
-func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, error) {
+func findUnexpectedPorts(db *sql.DB, hostID int, spec manifest.Spec, hostOnly bool) ([]Port, []Port, error) {
 	expectedPorts := spec.ExpectedPorts()
 
 	args := []interface{}{}
 	for _, port := range expectedPorts {
 		args = append(args, port)
 	}
-	args = append(args, host)
+	args = append(args, hostOnly, hostID)
 
 	rows, err := db.Query(`
-		SELECT ports.number, processes.name
+		SELECT ports.number, processes.name, ports.firewall_blocked
 		FROM ports
 			INNER JOIN processes
 				ON ports.process_id = processes.id
@@ -158,11 +285,12 @@ func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 		WHERE ports.number NOT IN (`+inPlaceholder(len(expectedPorts))+`)
 			AND ports.state = "LISTEN"
 			AND ports.address != "127.0.0.1"
-			AND hosts.name = ?
+			AND (NOT ? OR ports.container_id = '')
+			AND hosts.id = ?
 	`, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer rows.Close()
@@ -170,11 +298,15 @@ func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 	var unexpectedPorts []Port
 	var unexpectedPort int
 	var processName string
+	var blocked sql.NullBool
+
+	// Ports are only firewalled if they are blocked on every address
+	exposed := map[Port]bool{}
 
 	for rows.Next() {
-		err := rows.Scan(&unexpectedPort, &processName)
+		err := rows.Scan(&unexpectedPort, &processName, &blocked)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if spec.ShouldIgnorePortsForCommand(processName) {
@@ -182,59 +314,50 @@ func findUnexpectedPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 		}
 
 		unexpectedPorts = append(unexpectedPorts, Port(unexpectedPort))
+		exposed[Port(unexpectedPort)] = exposed[Port(unexpectedPort)] || !blocked.Bool
 	}
 
-	return unexpectedPorts, nil
+	var firewalledPorts []Port
+	for port, isExposed := range exposed {
+		if !isExposed {
+			firewalledPorts = append(firewalledPorts, port)
+		}
+	}
+	sort.Slice(firewalledPorts, func(i, j int) bool {
+		return firewalledPorts[i] < firewalledPorts[j]
+	})
+
+	return unexpectedPorts, firewalledPorts, nil
 }
 
 func inPlaceholder(count int) string {
 	return strings.Join(strings.Split(strings.Repeat("?", count), ""), ", ")
 }
 
-func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec) ([]string, []string, error) {
-	rows, err := db.Query(`SELECT hosts.name FROM hosts`)
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	defer rows.Close()
-
-	reportHosts := []string{}
-
-	for rows.Next() {
-		var reportHost string
-		err := rows.Scan(&reportHost)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		reportHosts = append(reportHosts, reportHost)
-	}
-
+func lookForMissingAndExtraHosts(hosts []scannedHost, manifestHosts []manifest.Spec) ([]string, []string) {
 	extras := []string{}
 	missings := []string{}
 
-	for _, reportHost := range reportHosts {
+	for _, reportHost := range hosts {
 		found := false
 
 		for _, manifestHost := range manifestHosts {
-			if strings.HasPrefix(reportHost, manifestHost.Prefix) {
+			if strings.HasPrefix(reportHost.name, manifestHost.Prefix) {
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			extras = append(extras, reportHost)
+			extras = append(extras, reportHost.label)
 		}
 	}
 
 	for _, manifestHost := range manifestHosts {
 		found := false
 
-		for _, reportHost := range reportHosts {
-			if strings.HasPrefix(reportHost, manifestHost.Prefix) {
+		for _, reportHost := range hosts {
+			if strings.HasPrefix(reportHost.name, manifestHost.Prefix) {
 				found = true
 				break
 			}
@@ -245,10 +368,10 @@ func lookForMissingAndExtraHosts(db *sql.DB, manifestHosts []manifest.Spec) ([]s
 		}
 	}
 
-	return missings, extras, nil
+	return missings, extras
 }
 
-func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]MismatchedProcess, error) {
+func verifyProcessUsers(db *sql.DB, hostID int, spec manifest.Spec) ([]MismatchedProcess, error) {
 	mismatched := []MismatchedProcess{}
 
 	for _, proc := range spec.Processes {
@@ -261,8 +384,8 @@ func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]Mismatch
 					ON processes.id = ports.process_id
 			WHERE processes.user != ?
 				AND processes.name = ?
-				AND hosts.name = ?
-		`, proc.User, proc.Command, host)
+				AND hosts.id = ?
+		`, proc.User, proc.Command, hostID)
 
 		if err != nil {
 			return nil, err
@@ -290,7 +413,7 @@ func verifyProcessUsers(db *sql.DB, host string, spec manifest.Spec) ([]Mismatch
 	return mismatched, nil
 }
 
-func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]string, error) {
+func lookForMissingProcesses(db *sql.DB, hostID int, spec manifest.Spec) ([]string, error) {
 	missingCommands := []string{}
 
 	for _, command := range spec.ExpectedCommands() {
@@ -302,8 +425,8 @@ func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]str
 				JOIN hosts
 					ON processes.host_id = hosts.id
 			WHERE processes.name = ?
-				AND hosts.name = ?
-		`, command, host).Scan(&count)
+				AND hosts.id = ?
+		`, command, hostID).Scan(&count)
 
 		if err != nil {
 			return nil, err
@@ -317,7 +440,7 @@ func lookForMissingProcesses(db *sql.DB, host string, spec manifest.Spec) ([]str
 	return missingCommands, nil
 }
 
-func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, error) {
+func lookForMissingPorts(db *sql.DB, hostID int, spec manifest.Spec) ([]Port, error) {
 	missingPorts := []Port{}
 
 	for _, port := range spec.ExpectedPorts() {
@@ -331,8 +454,8 @@ func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 				JOIN hosts
 					ON processes.host_id = hosts.id
 			WHERE ports.number = ?
-				AND hosts.name = ?
-		`, port, host).Scan(&count)
+				AND hosts.id = ?
+		`, port, hostID).Scan(&count)
 
 		if err != nil {
 			return nil, err
@@ -345,3 +468,92 @@ func lookForMissingPorts(db *sql.DB, host string, spec manifest.Spec) ([]Port, e
 
 	return missingPorts, nil
 }
+
+func kernelCollected(db *sql.DB, hostID int) (bool, error) {
+	var count int
+
+	err := db.QueryRow(`
+		SELECT COUNT(kernels.id)
+		FROM kernels
+			JOIN hosts
+				ON kernels.host_id = hosts.id
+		WHERE hosts.id = ?
+	`, hostID).Scan(&count)
+
+	return count > 0, err
+}
+
+func verifySysctls(db *sql.DB, hostID int, spec manifest.Spec) ([]MismatchedSysctl, error) {
+	mismatched := []MismatchedSysctl{}
+	if spec.Kernel == nil {
+		return mismatched, nil
+	}
+
+	names := []string{}
+	for name := range spec.Kernel.Sysctls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var value string
+
+		err := db.QueryRow(`
+			SELECT sysctls.value
+			FROM sysctls
+				JOIN hosts
+					ON sysctls.host_id = hosts.id
+			WHERE sysctls.name = ?
+				AND hosts.id = ?
+		`, name, hostID).Scan(&value)
+
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		expected := kernel.NormalizeValue(spec.Kernel.Sysctls[name])
+		if value != expected {
+			mismatched = append(mismatched, MismatchedSysctl{
+				Name:     name,
+				Actual:   value,
+				Expected: expected,
+			})
+		}
+	}
+
+	return mismatched, nil
+}
+
+func lookForMissingBootParameters(db *sql.DB, hostID int, spec manifest.Spec) ([]string, error) {
+	missing := []string{}
+	if spec.Kernel == nil || len(spec.Kernel.BootParameters) == 0 {
+		return missing, nil
+	}
+
+	var bootParameters string
+
+	err := db.QueryRow(`
+		SELECT kernels.boot_parameters
+		FROM kernels
+			JOIN hosts
+				ON kernels.host_id = hosts.id
+		WHERE hosts.id = ?
+	`, hostID).Scan(&bootParameters)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	actual := map[string]bool{}
+	for _, parameter := range strings.Fields(bootParameters) {
+		actual[parameter] = true
+	}
+
+	for _, parameter := range spec.Kernel.BootParameters {
+		if !actual[parameter] {
+			missing = append(missing, parameter)
+		}
+	}
+
+	return missing, nil
+}
@@ -112,7 +112,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a results that says everything is ok", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeTrue())
@@ -183,7 +183,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the extra or missing host", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -236,7 +236,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the missing process", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -312,7 +312,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing the unexpected port", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -320,14 +320,65 @@ var _ = Describe("Audit", func() {
 				Expect(result.Hosts["host1"].UnexpectedPorts).To(ConsistOf(audit.Port(2345)))
 			})
 
+			Context("when a port is opened inside a container", func() {
+				BeforeEach(func() {
+					hosts.JobResults[0].Services = append(hosts.JobResults[0].Services, scantron.Process{
+						CommandName: "nginx",
+						User:        "root",
+						ContainerID: "3f4c2b1a9e8d",
+						Ports: []scantron.Port{
+							{
+								Number:      8080,
+								State:       "LISTEN",
+								ContainerID: "3f4c2b1a9e8d",
+							},
+						},
+					})
+				})
+
+				It("returns a result showing the unexpected port", func() {
+					result, err := audit.Audit(database.DB(), mani, audit.Options{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.Hosts["host1"].UnexpectedPorts).To(ConsistOf(audit.Port(2345), audit.Port(8080)))
+				})
+
+				It("ignores the port when only the host is audited", func() {
+					result, err := audit.Audit(database.DB(), mani, audit.Options{HostOnly: true})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.Hosts["host1"].UnexpectedPorts).To(ConsistOf(audit.Port(2345)))
+				})
+			})
+
 			It("returns a result showing the missing port", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
 				Expect(result.Hosts).To(HaveKey("host1"))
 				Expect(result.Hosts["host1"].MissingPorts).To(ConsistOf(audit.Port(80)))
 			})
+
+			Context("when the host firewall blocks an unexpected port", func() {
+				BeforeEach(func() {
+					process := &hosts.JobResults[0].Services[0]
+					process.Ports = append(process.Ports,
+						scantron.Port{Number: 4567, State: "LISTEN", Address: "10.0.0.1", FirewallBlocked: true},
+						scantron.Port{Number: 5678, State: "LISTEN", Address: "10.0.0.1", FirewallBlocked: true},
+						scantron.Port{Number: 5678, State: "LISTEN", Address: "10.0.1.1"},
+					)
+				})
+
+				It("returns a result showing the unexpected port is firewalled", func() {
+					result, err := audit.Audit(database.DB(), mani, audit.Options{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.OK()).To(BeFalse())
+					Expect(result.Hosts["host1"].UnexpectedPorts).To(ConsistOf(audit.Port(2345), audit.Port(4567), audit.Port(5678), audit.Port(5678)))
+					Expect(result.Hosts["host1"].FirewalledPorts).To(Equal([]audit.Port{4567}))
+				})
+			})
 		})
 
 		Context("when one of processes is running with incorrect user", func() {
@@ -369,7 +420,7 @@ var _ = Describe("Audit", func() {
 			})
 
 			It("returns a result showing incorrect values", func() {
-				result, err := audit.Audit(database.DB(), mani)
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(result.OK()).To(BeFalse())
@@ -382,5 +433,135 @@ var _ = Describe("Audit", func() {
 				}))
 			})
 		})
+
+		Context("when the kernel settings differ from the manifest", func() {
+			BeforeEach(func() {
+				mani = manifest.Manifest{
+					Specs: []manifest.Spec{
+						{
+							Prefix: "host1",
+							Kernel: &manifest.Kernel{
+								Sysctls: map[string]string{
+									"net.ipv4.ip_forward":       "0",
+									"kernel.kptr_restrict":      "1",
+									"kernel.yama.ptrace_scope":  "1",
+									"kernel.randomize_va_space": "2",
+								},
+								BootParameters: []string{"apparmor=1", "audit=1"},
+							},
+						},
+					},
+				}
+
+				hosts = scanner.ScanResult{
+					JobResults: []scanner.JobResult{
+						{
+							Job: "host1",
+							Kernel: &scantron.Kernel{
+								Release:        "5.15.0-91-generic",
+								BootParameters: "BOOT_IMAGE=/vmlinuz root=/dev/sda1 ro apparmor=1",
+								Sysctls: map[string]string{
+									"net.ipv4.ip_forward":       "1",
+									"kernel.kptr_restrict":      "1",
+									"kernel.randomize_va_space": "2",
+								},
+							},
+						},
+					},
+				}
+			})
+
+			It("returns a result showing the mismatched sysctls and missing boot parameters", func() {
+				result, err := audit.Audit(database.DB(), mani, audit.Options{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.OK()).To(BeFalse())
+				Expect(result.Hosts).To(HaveKey("host1"))
+				Expect(result.Hosts["host1"].MismatchedSysctls).To(Equal([]audit.MismatchedSysctl{
+					{Name: "kernel.yama.ptrace_scope", Actual: "", Expected: "1"},
+					{Name: "net.ipv4.ip_forward", Actual: "1", Expected: "0"},
+				}))
+				Expect(result.Hosts["host1"].MissingBootParameters).To(Equal([]string{"audit=1"}))
+				Expect(result.Hosts["host1"].KernelNotCollected).To(BeFalse())
+			})
+
+			Context("when the kernel of the host was not collected", func() {
+				BeforeEach(func() {
+					hosts.JobResults[0].Kernel = nil
+				})
+
+				It("returns a result showing the kernel settings could not be checked", func() {
+					result, err := audit.Audit(database.DB(), mani, audit.Options{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.OK()).To(BeFalse())
+					Expect(result.Hosts["host1"].KernelNotCollected).To(BeTrue())
+					Expect(result.Hosts["host1"].MismatchedSysctls).To(BeEmpty())
+					Expect(result.Hosts["host1"].MissingBootParameters).To(BeEmpty())
+				})
+			})
+		})
+	})
+
+	Context("when deployments with the same name on different directors have hosts with the same name", func() {
+		BeforeEach(func() {
+			mani = manifest.Manifest{
+				Specs: []manifest.Spec{
+					{
+						Prefix: "host1",
+						Processes: []manifest.Process{
+							{Command: "command1", User: "root", Ports: []manifest.Port{1234}},
+						},
+					},
+				},
+			}
+
+			hosts = scanner.ScanResult{
+				Director: "https://10.0.0.6:25555",
+				JobResults: []scanner.JobResult{
+					{
+						Job: "host1",
+						IP:  "10.0.0.1",
+						Services: []scantron.Process{
+							{CommandName: "command1", User: "root", Ports: []scantron.Port{{Number: 1234, State: "LISTEN"}}},
+						},
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			err := database.SaveReport("cf1", scanner.ScanResult{
+				Director: "https://10.1.0.6:25555",
+				JobResults: []scanner.JobResult{
+					{
+						Job: "host1",
+						IP:  "10.1.0.1",
+						Services: []scantron.Process{
+							{CommandName: "command1", User: "vcap", Ports: []scantron.Port{{Number: 2345, State: "LISTEN"}}},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("audits each of the hosts on its own", func() {
+			result, err := audit.Audit(database.DB(), mani, audit.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.OK()).To(BeFalse())
+			Expect(result.Hosts).To(HaveLen(2))
+
+			first := result.Hosts["host1 (10.0.0.1, cf1 on https://10.0.0.6:25555)"]
+			Expect(first.OK()).To(BeTrue())
+
+			second := result.Hosts["host1 (10.1.0.1, cf1 on https://10.1.0.6:25555)"]
+			Expect(second.UnexpectedPorts).To(ConsistOf(audit.Port(2345)))
+			Expect(second.MissingPorts).To(ConsistOf(audit.Port(1234)))
+			Expect(second.MismatchedProcesses).To(ConsistOf(audit.MismatchedProcess{
+				Command: "command1", Field: "user", Actual: "vcap", Expected: "root",
+			}))
+		})
 	})
 })
@@ -9,10 +9,10 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-func GenerateManifest(writer io.Writer, db *sql.DB) error {
+func GenerateManifest(writer io.Writer, db *sql.DB, options Options) error {
 	m := manifest.Manifest{}
 
-	specs, err := getSpecsFor(db)
+	specs, err := getSpecsFor(db, options.HostOnly)
 	if err != nil {
 		return err
 	}
@@ -27,7 +27,7 @@ func GenerateManifest(writer io.Writer, db *sql.DB) error {
 	return err
 }
 
-func getSpecsFor(db *sql.DB) ([]manifest.Spec, error) {
+func getSpecsFor(db *sql.DB, hostOnly bool) ([]manifest.Spec, error) {
 	rows, err := db.Query(`SELECT hosts.id, hosts.name FROM hosts`)
 
 	if err != nil {
@@ -50,7 +50,7 @@ func getSpecsFor(db *sql.DB) ([]manifest.Spec, error) {
 			Prefix: hostName,
 		}
 
-		processes, err := getProcessesFor(db, hostId)
+		processes, err := getProcessesFor(db, hostId, hostOnly)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +62,7 @@ func getSpecsFor(db *sql.DB) ([]manifest.Spec, error) {
 	return specs, nil
 }
 
-func getProcessesFor(db *sql.DB, hostId int) ([]manifest.Process, error) {
+func getProcessesFor(db *sql.DB, hostId int, hostOnly bool) ([]manifest.Process, error) {
 	processes := []manifest.Process{}
 
 	processRows, err := db.Query(`
@@ -71,9 +71,10 @@ func getProcessesFor(db *sql.DB, hostId int) ([]manifest.Process, error) {
 				JOIN ports
 					ON processes.id = ports.process_id
 			WHERE processes.host_id = ?
+				AND (NOT ? OR processes.container_id = '')
 				AND ports.address != "127.0.0.1"
 				AND ports.state = "LISTEN"
-		`, hostId)
+		`, hostId, hostOnly)
 	if err != nil {
 		return nil, err
 	}
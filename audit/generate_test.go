@@ -22,12 +22,14 @@ var _ = Describe("Generate", func() {
 		database *db.Database
 		tmpdir   string
 
-		writer *bytes.Buffer
-		hosts  scanner.ScanResult
+		writer  *bytes.Buffer
+		hosts   scanner.ScanResult
+		options audit.Options
 	)
 
 	BeforeEach(func() {
 		writer = &bytes.Buffer{}
+		options = audit.Options{}
 		var err error
 		tmpdir, err = ioutil.TempDir("", "audit")
 		Expect(err).NotTo(HaveOccurred())
@@ -44,7 +46,7 @@ var _ = Describe("Generate", func() {
 	JustBeforeEach(func() {
 		err := database.SaveReport("cf1", hosts)
 		Expect(err).NotTo(HaveOccurred())
-		err = audit.GenerateManifest(writer, database.DB())
+		err = audit.GenerateManifest(writer, database.DB(), options)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -149,6 +151,41 @@ var _ = Describe("Generate", func() {
 		It("does not show the ignore_ports field", func() {
 			Expect(writer.String()).NotTo(ContainSubstring("ignore_ports"))
 		})
+
+		Context("when a process is inside a container", func() {
+			BeforeEach(func() {
+				hosts.JobResults[0].Services = append(hosts.JobResults[0].Services, scantron.Process{
+					CommandName: "nginx",
+					User:        "root",
+					ContainerID: "3f4c2b1a9e8d",
+					Ports:       []scantron.Port{port(8081)},
+				})
+			})
+
+			It("shows the process", func() {
+				var m manifest.Manifest
+				err := yaml.Unmarshal(writer.Bytes(), &m)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(m.Specs[0].Processes).To(ContainElement(manifest.Process{
+					Command: "nginx",
+					User:    "root",
+					Ports:   []manifest.Port{8081},
+				}))
+			})
+
+			Context("when only the host is wanted", func() {
+				BeforeEach(func() {
+					options.HostOnly = true
+				})
+
+				It("leaves the process out", func() {
+					var m manifest.Manifest
+					err := yaml.Unmarshal(writer.Bytes(), &m)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(m.Specs[0].Processes).To(HaveLen(2))
+				})
+			})
+		})
 	})
 
 	Context("when multiple hosts exists", func() {
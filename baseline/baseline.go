@@ -0,0 +1,316 @@
+package baseline
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	DiffersFromInstances  = "differs from other instances"
+	InstancesDisagree     = "instances disagree"
+	ModifiedSinceBaseline = "modified since baseline"
+	MissingSinceBaseline  = "missing since baseline"
+)
+
+// Baseline is the expected SHA-256 of the hashed files of each instance
+// group.
+type Baseline struct {
+	InstanceGroups []InstanceGroup `yaml:"instance_groups"`
+}
+
+type InstanceGroup struct {
+	// Director is empty for deployments which were not scanned through BOSH.
+	Director   string            `yaml:"director,omitempty"`
+	Deployment string            `yaml:"deployment"`
+	Name       string            `yaml:"name"`
+	Files      map[string]string `yaml:"files"`
+}
+
+// FileHash is the hash of a file recorded on one host in a scan.
+type FileHash struct {
+	Director      string
+	Deployment    string
+	InstanceGroup string
+	Host          string
+	Path          string
+	SHA256        string
+}
+
+type Difference struct {
+	FileHash
+
+	Expected string
+	Reason   string
+}
+
+// Load reads a baseline written by Save. A baseline that does not exist yet
+// is empty.
+func Load(path string) (Baseline, error) {
+	bs, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Baseline{}, nil
+	}
+	if err != nil {
+		return Baseline{}, err
+	}
+
+	var b Baseline
+	err = yaml.Unmarshal(bs, &b)
+	if err != nil {
+		return Baseline{}, err
+	}
+
+	return b, nil
+}
+
+func (b Baseline) Save(path string) error {
+	bs, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bs, 0644)
+}
+
+// Hashes returns the hashes of files recorded in a scan. Hosts which were
+// not found through BOSH are grouped by the part of their name before the
+// slash. Deployments with the same name on different directors are kept
+// apart.
+func Hashes(db *sql.DB) ([]FileHash, error) {
+	rows, err := db.Query(`
+		SELECT coalesce(d.director, ''), d.name, bi.instance_group, h.name, h.ip, f.path, f.sha256
+		FROM files f
+			JOIN hosts h ON f.host_id = h.id
+			JOIN deployments d ON h.deployment_id = d.id
+			LEFT JOIN bosh_instances bi ON bi.host_id = h.id
+		WHERE f.sha256 IS NOT NULL AND f.sha256 != ''
+		ORDER BY d.director, d.name, h.name, f.path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := []FileHash{}
+	for rows.Next() {
+		var (
+			hash          FileHash
+			instanceGroup sql.NullString
+			name, ip      string
+		)
+
+		err := rows.Scan(&hash.Director, &hash.Deployment, &instanceGroup, &name, &ip, &hash.Path, &hash.SHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.InstanceGroup = instanceGroup.String
+		if !instanceGroup.Valid {
+			hash.InstanceGroup = strings.SplitN(name, "/", 2)[0]
+		}
+		hash.Host = name + " (" + ip + ")"
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+type groupKey struct {
+	director   string
+	deployment string
+	name       string
+}
+
+func hashGroup(hash FileHash) groupKey {
+	return groupKey{hash.Director, hash.Deployment, hash.InstanceGroup}
+}
+
+func baselineGroup(group InstanceGroup) groupKey {
+	return groupKey{group.Director, group.Deployment, group.Name}
+}
+
+// byGroup is the hashes of each file on each host of each instance group.
+type byGroup map[groupKey]map[string]map[string]string
+
+func groupHashes(hashes []FileHash) byGroup {
+	groups := byGroup{}
+	for _, hash := range hashes {
+		key := hashGroup(hash)
+		if groups[key] == nil {
+			groups[key] = map[string]map[string]string{}
+		}
+		if groups[key][hash.Path] == nil {
+			groups[key][hash.Path] = map[string]string{}
+		}
+
+		groups[key][hash.Path][hash.Host] = hash.SHA256
+	}
+
+	return groups
+}
+
+// majority is the hash held by more than half of the hosts, or "" if there
+// is none.
+func majority(hosts map[string]string) string {
+	counts := map[string]int{}
+	for _, sha := range hosts {
+		counts[sha]++
+	}
+
+	for sha, count := range counts {
+		if count*2 > len(hosts) {
+			return sha
+		}
+	}
+
+	return ""
+}
+
+// agreed is the hash held by all of the hosts, if they agree.
+func agreed(hosts map[string]string) (string, bool) {
+	sha := majority(hosts)
+	for _, other := range hosts {
+		if other != sha {
+			return "", false
+		}
+	}
+
+	return sha, sha != ""
+}
+
+// Compare finds files which do not match the baseline or, when the baseline
+// does not have them, differ from the same file on most other instances of
+// the instance group. When most instances do not agree there is no hash to
+// expect, so all of them are found. Files in the baseline which a scanned
+// instance of the instance group does not have are found too.
+func Compare(hashes []FileHash, b Baseline) []Difference {
+	expected := map[groupKey]map[string]string{}
+	for _, group := range b.InstanceGroups {
+		expected[baselineGroup(group)] = group.Files
+	}
+
+	groups := groupHashes(hashes)
+	differences := []Difference{}
+	for _, hash := range hashes {
+		key := hashGroup(hash)
+
+		reason := ModifiedSinceBaseline
+		sha, ok := expected[key][hash.Path]
+		if !ok {
+			reason = DiffersFromInstances
+			sha = majority(groups[key][hash.Path])
+			if sha == "" {
+				reason = InstancesDisagree
+			}
+		}
+
+		if hash.SHA256 != sha {
+			differences = append(differences, Difference{
+				FileHash: hash,
+				Expected: sha,
+				Reason:   reason,
+			})
+		}
+	}
+
+	groupHosts := map[groupKey][]string{}
+	type hostKey struct {
+		group groupKey
+		host  string
+	}
+	seen := map[hostKey]bool{}
+	for _, hash := range hashes {
+		key := hashGroup(hash)
+		host := hostKey{key, hash.Host}
+		if !seen[host] {
+			seen[host] = true
+			groupHosts[key] = append(groupHosts[key], hash.Host)
+		}
+	}
+
+	for _, group := range b.InstanceGroups {
+		key := baselineGroup(group)
+
+		paths := make([]string, 0, len(group.Files))
+		for path := range group.Files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			for _, host := range groupHosts[key] {
+				if _, ok := groups[key][path][host]; ok {
+					continue
+				}
+
+				differences = append(differences, Difference{
+					FileHash: FileHash{
+						Director:      group.Director,
+						Deployment:    group.Deployment,
+						InstanceGroup: group.Name,
+						Host:          host,
+						Path:          path,
+					},
+					Expected: group.Files[path],
+					Reason:   MissingSinceBaseline,
+				})
+			}
+		}
+	}
+
+	return differences
+}
+
+// Update records the hashes from a scan in the baseline. Files whose hashes
+// differ between instances keep the hash they had before, if any, since it
+// is not clear which instance is right.
+func Update(hashes []FileHash, b Baseline) Baseline {
+	files := map[groupKey]map[string]string{}
+	for _, group := range b.InstanceGroups {
+		key := baselineGroup(group)
+		files[key] = map[string]string{}
+		for path, sha := range group.Files {
+			files[key][path] = sha
+		}
+	}
+
+	for key, paths := range groupHashes(hashes) {
+		if files[key] == nil {
+			files[key] = map[string]string{}
+		}
+
+		for path, hosts := range paths {
+			if sha, ok := agreed(hosts); ok {
+				files[key][path] = sha
+			}
+		}
+	}
+
+	updated := Baseline{}
+	for key, paths := range files {
+		updated.InstanceGroups = append(updated.InstanceGroups, InstanceGroup{
+			Director:   key.director,
+			Deployment: key.deployment,
+			Name:       key.name,
+			Files:      paths,
+		})
+	}
+
+	sort.Slice(updated.InstanceGroups, func(i, j int) bool {
+		a, b := updated.InstanceGroups[i], updated.InstanceGroups[j]
+		if a.Director != b.Director {
+			return a.Director < b.Director
+		}
+		if a.Deployment != b.Deployment {
+			return a.Deployment < b.Deployment
+		}
+		return a.Name < b.Name
+	})
+
+	return updated
+}
@@ -0,0 +1,252 @@
+package baseline_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/baseline"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/scanner"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Baseline", func() {
+	const (
+		goodSHA = "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+		badSHA  = "4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358"
+	)
+
+	var (
+		tmpdir string
+		hashes []baseline.FileHash
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "baseline")
+		Expect(err).NotTo(HaveOccurred())
+
+		hashes = []baseline.FileHash{
+			{Deployment: "cf", InstanceGroup: "router", Host: "router/0", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+			{Deployment: "cf", InstanceGroup: "router", Host: "router/1", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+			{Deployment: "cf", InstanceGroup: "router", Host: "router/2", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: badSHA},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Describe("Hashes", func() {
+		It("returns the hashed files of each instance group", func() {
+			database, err := db.CreateDatabase(filepath.Join(tmpdir, "database.db"))
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			err = database.SaveReport("cf", scanner.ScanResult{
+				JobResults: []scanner.JobResult{
+					{
+						IP:           "10.0.0.1",
+						Job:          "router/a1b2",
+						BoshInstance: &scanner.BoshInstance{InstanceGroup: "router", ID: "a1b2"},
+						Files: []scantron.File{
+							{Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+							{Path: "/etc/passwd"},
+						},
+					},
+					{
+						IP:  "10.0.0.2",
+						Job: "uaa/c3d4",
+						Files: []scantron.File{
+							{Path: "/var/vcap/jobs/uaa/bin/pre-start", SHA256: badSHA},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			hashes, err := baseline.Hashes(database.DB())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hashes).To(Equal([]baseline.FileHash{
+				{Deployment: "cf", InstanceGroup: "router", Host: "router/a1b2 (10.0.0.1)", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+				{Deployment: "cf", InstanceGroup: "uaa", Host: "uaa/c3d4 (10.0.0.2)", Path: "/var/vcap/jobs/uaa/bin/pre-start", SHA256: badSHA},
+			}))
+		})
+
+		It("records the director of each deployment", func() {
+			database, err := db.CreateDatabase(filepath.Join(tmpdir, "database.db"))
+			Expect(err).NotTo(HaveOccurred())
+			defer database.Close()
+
+			for _, director := range []string{"https://10.0.0.6:25555", "https://10.1.0.6:25555"} {
+				err = database.SaveReport("cf", scanner.ScanResult{
+					Director: director,
+					JobResults: []scanner.JobResult{
+						{
+							IP:           "10.0.0.1",
+							Job:          "router/a1b2",
+							BoshInstance: &scanner.BoshInstance{InstanceGroup: "router", ID: "a1b2"},
+							Files:        []scantron.File{{Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA}},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			hashes, err := baseline.Hashes(database.DB())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hashes).To(Equal([]baseline.FileHash{
+				{Director: "https://10.0.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/a1b2 (10.0.0.1)", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+				{Director: "https://10.1.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/a1b2 (10.0.0.1)", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+			}))
+		})
+	})
+
+	Describe("Compare", func() {
+		It("finds files that differ from the other instances", func() {
+			differences := baseline.Compare(hashes, baseline.Baseline{})
+			Expect(differences).To(Equal([]baseline.Difference{
+				{FileHash: hashes[2], Expected: goodSHA, Reason: baseline.DiffersFromInstances},
+			}))
+		})
+
+		It("finds every instance when most of them do not agree", func() {
+			differences := baseline.Compare(hashes[1:], baseline.Baseline{})
+			Expect(differences).To(Equal([]baseline.Difference{
+				{FileHash: hashes[1], Reason: baseline.InstancesDisagree},
+				{FileHash: hashes[2], Reason: baseline.InstancesDisagree},
+			}))
+		})
+
+		It("finds files modified since the baseline", func() {
+			differences := baseline.Compare(hashes, baseline.Baseline{
+				InstanceGroups: []baseline.InstanceGroup{
+					{Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": badSHA}},
+				},
+			})
+			Expect(differences).To(Equal([]baseline.Difference{
+				{FileHash: hashes[0], Expected: badSHA, Reason: baseline.ModifiedSinceBaseline},
+				{FileHash: hashes[1], Expected: badSHA, Reason: baseline.ModifiedSinceBaseline},
+			}))
+		})
+
+		It("finds files in the baseline that instances do not have", func() {
+			preStart := "/var/vcap/jobs/gorouter/bin/pre-start"
+			hashes = append(hashes, baseline.FileHash{
+				Deployment: "cf", InstanceGroup: "router", Host: "router/1", Path: preStart, SHA256: goodSHA,
+			})
+
+			differences := baseline.Compare(hashes, baseline.Baseline{
+				InstanceGroups: []baseline.InstanceGroup{
+					{Deployment: "cf", Name: "router", Files: map[string]string{preStart: goodSHA}},
+					{Deployment: "cf", Name: "uaa", Files: map[string]string{"/var/vcap/jobs/uaa/bin/pre-start": goodSHA}},
+				},
+			})
+
+			Expect(differences).To(Equal([]baseline.Difference{
+				{FileHash: hashes[2], Expected: goodSHA, Reason: baseline.DiffersFromInstances},
+				{
+					FileHash: baseline.FileHash{Deployment: "cf", InstanceGroup: "router", Host: "router/0", Path: preStart},
+					Expected: goodSHA,
+					Reason:   baseline.MissingSinceBaseline,
+				},
+				{
+					FileHash: baseline.FileHash{Deployment: "cf", InstanceGroup: "router", Host: "router/2", Path: preStart},
+					Expected: goodSHA,
+					Reason:   baseline.MissingSinceBaseline,
+				},
+			}))
+		})
+		Context("when deployments with the same name are on different directors", func() {
+			BeforeEach(func() {
+				hashes = []baseline.FileHash{
+					{Director: "https://10.0.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/0", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+					{Director: "https://10.0.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/1", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: goodSHA},
+					{Director: "https://10.1.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/0", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: badSHA},
+					{Director: "https://10.1.0.6:25555", Deployment: "cf", InstanceGroup: "router", Host: "router/1", Path: "/var/vcap/packages/gorouter/bin/gorouter", SHA256: badSHA},
+				}
+			})
+
+			It("compares the instances of each deployment separately", func() {
+				Expect(baseline.Compare(hashes, baseline.Baseline{})).To(BeEmpty())
+			})
+
+			It("compares each deployment with its own baseline", func() {
+				differences := baseline.Compare(hashes, baseline.Baseline{
+					InstanceGroups: []baseline.InstanceGroup{
+						{Director: "https://10.0.0.6:25555", Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": goodSHA}},
+						{Director: "https://10.1.0.6:25555", Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": goodSHA}},
+					},
+				})
+				Expect(differences).To(Equal([]baseline.Difference{
+					{FileHash: hashes[2], Expected: goodSHA, Reason: baseline.ModifiedSinceBaseline},
+					{FileHash: hashes[3], Expected: goodSHA, Reason: baseline.ModifiedSinceBaseline},
+				}))
+			})
+
+			It("records a baseline for each of them", func() {
+				Expect(baseline.Update(hashes, baseline.Baseline{})).To(Equal(baseline.Baseline{
+					InstanceGroups: []baseline.InstanceGroup{
+						{Director: "https://10.0.0.6:25555", Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": goodSHA}},
+						{Director: "https://10.1.0.6:25555", Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": badSHA}},
+					},
+				}))
+			})
+		})
+	})
+
+	Describe("Update", func() {
+		It("records the hashes all instances agree on", func() {
+			hashes = append(hashes, baseline.FileHash{
+				Deployment: "cf", InstanceGroup: "router", Host: "router/0", Path: "/var/vcap/jobs/gorouter/bin/pre-start", SHA256: goodSHA,
+			})
+
+			updated := baseline.Update(hashes, baseline.Baseline{
+				InstanceGroups: []baseline.InstanceGroup{
+					{Deployment: "cf", Name: "router", Files: map[string]string{"/var/vcap/packages/gorouter/bin/gorouter": badSHA}},
+					{Deployment: "cf", Name: "uaa", Files: map[string]string{"/var/vcap/jobs/uaa/bin/pre-start": goodSHA}},
+				},
+			})
+
+			Expect(updated).To(Equal(baseline.Baseline{
+				InstanceGroups: []baseline.InstanceGroup{
+					{Deployment: "cf", Name: "router", Files: map[string]string{
+						"/var/vcap/packages/gorouter/bin/gorouter": badSHA,
+						"/var/vcap/jobs/gorouter/bin/pre-start":    goodSHA,
+					}},
+					{Deployment: "cf", Name: "uaa", Files: map[string]string{"/var/vcap/jobs/uaa/bin/pre-start": goodSHA}},
+				},
+			}))
+		})
+	})
+
+	Describe("Load", func() {
+		It("reads a saved baseline", func() {
+			path := filepath.Join(tmpdir, "baseline.yml")
+			saved := baseline.Update(hashes[:2], baseline.Baseline{})
+			Expect(saved.Save(path)).To(Succeed())
+
+			loaded, err := baseline.Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded).To(Equal(saved))
+		})
+
+		It("returns an empty baseline when there is none yet", func() {
+			loaded, err := baseline.Load(filepath.Join(tmpdir, "missing.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded).To(Equal(baseline.Baseline{}))
+		})
+
+		It("returns an error when the baseline is malformed", func() {
+			path := filepath.Join(tmpdir, "baseline.yml")
+			Expect(ioutil.WriteFile(path, []byte("instance_groups: {"), 0644)).To(Succeed())
+
+			_, err := baseline.Load(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -2,9 +2,11 @@ package bosh
 
 import (
 	"bufio"
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"net"
 	"os"
+	"strings"
 
 	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
@@ -20,45 +22,43 @@ import (
 
 type TargetDeployment interface {
 	Name() string
+	Director() string
 	VMs() []boshdir.VMInfo
 	Releases() []boshdir.Release
+	Stemcells() []boshdir.Stemcell
+	InstanceStemcell(boshdir.VMInfo) boshdir.Stemcell
 
 	Setup() error
-	ConnectTo(boshdir.VMInfo) remotemachine.RemoteMachine
+	ConnectTo(boshdir.VMInfo) (remotemachine.RemoteMachine, error)
 	Cleanup() error
 }
 
+// SSHUserPrefix starts the name of every SSH user scantron creates so that the
+// users left behind by interrupted scans can be cleaned up. The agent only
+// manages users starting with bosh_ and Windows limits names to 20 characters.
+const SSHUserPrefix = "bosh_scantron"
+
+const maxSSHUsernameLength = 20
+
 type TargetDeploymentImpl struct {
 	sshOpts    boshdir.SSHOpts
 	signer     ssh.Signer
 	deployment boshdir.Deployment
+	director   string
+	filter     InstanceFilter
+	slugs      []boshdir.AllOrInstanceGroupOrInstanceSlug
+	stemcells  map[string]boshdir.Stemcell
 	logger     scanlog.Logger
 }
 
 func GetDeployments(
-	creds boshconfig.Creds,
-	caCertPath string,
-	deploymentNames []string,
-	boshURL string,
+	env Environment,
+	selection DeploymentSelection,
+	filter InstanceFilter,
 	logger scanlog.Logger) ([]TargetDeployment, error) {
 
-	var caCert string
-
-	if caCertPath != "" {
-		caCertBytes, err := ioutil.ReadFile(caCertPath)
-		if err != nil {
-			logger.Errorf("Failed to load CA certificate (%s): %s", caCertPath, err)
-			return nil, err
-		}
-
-		caCert = string(caCertBytes)
-	}
-
-	out := bufio.NewWriter(os.Stdout)
-	boshLogger := boshlog.NewWriterLogger(boshlog.LevelNone, out)
-	director, err := getDirector(boshURL, creds, caCert, boshLogger)
+	director, deploymentNames, err := selectDeployments(env, selection, logger)
 	if err != nil {
-		logger.Errorf("Could not reach BOSH Director (%s): %s", boshURL, err)
 		return nil, err
 	}
 
@@ -71,6 +71,7 @@ func GetDeployments(
 			logger.Errorf("Could not create SSH options: %s", err)
 			return nil, err
 		}
+		sshOpts.Username = scantronUsername(sshOpts.Username)
 		logger.Debugf("Generated user %s for deployment %s", sshOpts.Username, depName)
 
 		signer, err := ssh.ParsePrivateKey([]byte(privKey))
@@ -89,6 +90,8 @@ func GetDeployments(
 			sshOpts:    sshOpts,
 			signer:     signer,
 			deployment: deployment,
+			director:   env.URL,
+			filter:     filter,
 			logger:     logger,
 		})
 	}
@@ -100,13 +103,96 @@ func GetDeployments(
 	return deps, nil
 }
 
+// scantronUsername swaps the bosh_ prefix of a generated username for the
+// scantron one, keeping as much of the random suffix as still fits.
+func scantronUsername(username string) string {
+	suffix := strings.TrimPrefix(username, "bosh_")
+	return SSHUserPrefix + suffix[:maxSSHUsernameLength-len(SSHUserPrefix)]
+}
+
+// CleanupSSHUsers removes every scantron SSH user from the selected
+// deployments, including those left behind by scans that were interrupted.
+// The agent removes the home directory along with the user so this also
+// removes any scanners left on the VMs.
+func CleanupSSHUsers(env Environment, selection DeploymentSelection, logger scanlog.Logger) error {
+	director, deploymentNames, err := selectDeployments(env, selection, logger)
+	if err != nil {
+		return err
+	}
+
+	all := boshdir.NewAllOrInstanceGroupOrInstanceSlug("", "")
+	for _, depName := range deploymentNames {
+		deployment, err := director.FindDeployment(depName)
+		if err != nil {
+			logger.Errorf("Failed to find deployment (%s): %s", depName, err)
+			return err
+		}
+
+		logger.Infof("Cleaning up scantron SSH users in deployment %s", depName)
+		err = deployment.CleanUpSSH(all, boshdir.SSHOpts{Username: SSHUserPrefix})
+		if err != nil {
+			logger.Errorf("Failed to clean up SSH users in deployment %s: %s", depName, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func selectDeployments(env Environment, selection DeploymentSelection, logger scanlog.Logger) (boshdir.Director, []string, error) {
+	out := bufio.NewWriter(os.Stdout)
+	boshLogger := boshlog.NewWriterLogger(boshlog.LevelNone, out)
+	director, err := getDirector(env.URL, env.Creds, env.CACert, boshLogger)
+	if err != nil {
+		logger.Errorf("Could not reach BOSH Director (%s): %s", env.URL, err)
+		return nil, nil, err
+	}
+
+	if !selection.All {
+		return director, selection.Names, nil
+	}
+
+	deploymentNames, err := listDeploymentNames(director, selection, logger)
+	if err != nil {
+		logger.Errorf("Failed to list deployments on BOSH Director (%s): %s", env.URL, err)
+		return nil, nil, err
+	}
+
+	return director, deploymentNames, nil
+}
+
+func listDeploymentNames(director boshdir.Director, selection DeploymentSelection, logger scanlog.Logger) ([]string, error) {
+	deployments, err := director.ListDeployments()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, deployment := range deployments {
+		names = append(names, deployment.Name)
+	}
+
+	selected := selection.Select(names)
+	if len(selected) == 0 {
+		return nil, errors.New("no deployments matched the given patterns")
+	}
+
+	logger.Infof("Found %d deployments to scan: %v", len(selected), selected)
+
+	return selected, nil
+}
+
 func (d *TargetDeploymentImpl) Name() string {
 	return d.deployment.Name()
 }
 
+func (d *TargetDeploymentImpl) Director() string {
+	return d.director
+}
+
 func (d *TargetDeploymentImpl) VMs() []boshdir.VMInfo {
 	vms, _ := d.deployment.VMInfos()
-	return vms
+	return d.filter.Select(vms)
 }
 
 func (d *TargetDeploymentImpl) Releases() []boshdir.Release {
@@ -114,33 +200,107 @@ func (d *TargetDeploymentImpl) Releases() []boshdir.Release {
 	return releases
 }
 
+func (d *TargetDeploymentImpl) Stemcells() []boshdir.Stemcell {
+	stemcells, _ := d.deployment.Stemcells()
+	return stemcells
+}
+
+func (d *TargetDeploymentImpl) InstanceStemcell(vm boshdir.VMInfo) boshdir.Stemcell {
+	return d.stemcells[vm.JobName]
+}
+
 func (d *TargetDeploymentImpl) Setup() error {
 	d.logger.Debugf("About to setup SSH for deployment %s", d.Name())
-	slug := boshdir.NewAllOrInstanceGroupOrInstanceSlug("", "")
 
-	_, err := d.deployment.SetUpSSH(slug, d.sshOpts)
+	vms, err := d.deployment.VMInfos()
 	if err != nil {
 		return err
 	}
 
+	d.stemcells, err = d.instanceGroupStemcells()
+	if err != nil {
+		d.logger.Warnf("Could not work out the stemcell of each instance group in deployment %s: %s", d.Name(), err)
+	}
+
+	selected := d.filter.Select(vms)
+	if len(selected) == 0 {
+		d.logger.Warnf("No instances in deployment %s matched the instance filter", d.Name())
+		return nil
+	}
+
+	for _, slug := range SSHSlugs(vms, selected) {
+		d.logger.Debugf("Setting up SSH for '%s' in deployment %s", slug, d.Name())
+
+		_, err := d.deployment.SetUpSSH(slug, d.sshOpts)
+		if err != nil {
+			d.Cleanup()
+			return err
+		}
+
+		d.slugs = append(d.slugs, slug)
+	}
+
 	return nil
 }
 
-func (d *TargetDeploymentImpl) ConnectTo(vm boshdir.VMInfo) remotemachine.RemoteMachine {
-	stemcells, _ := d.deployment.Stemcells()
-	return remotemachine.NewRemoteMachine(scantron.Machine{
-		Address:  BestAddress(vm.IPs),
-		Username: d.sshOpts.Username,
-		Key:      d.signer,
-		OSName:   stemcells[0].Name(),
-	})
+func (d *TargetDeploymentImpl) instanceGroupStemcells() (map[string]boshdir.Stemcell, error) {
+	stemcells, err := d.deployment.Stemcells()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := d.deployment.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	return InstanceGroupStemcells(manifest, stemcells)
+}
+
+func (d *TargetDeploymentImpl) ConnectTo(vm boshdir.VMInfo) (remotemachine.RemoteMachine, error) {
+	machine := scantron.Machine{
+		Address:    BestAddress(vm.IPs),
+		Username:   d.sshOpts.Username,
+		Key:        d.signer,
+		Escalation: remotemachine.EscalatePasswordlessSudo,
+	}
+
+	if stemcell := d.InstanceStemcell(vm); stemcell != nil {
+		machine.OSName = stemcell.OSName()
+	} else {
+		d.logger.Debugf("Stemcell of %s/%s is unknown, probing its OS over SSH", vm.JobName, vm.ID)
+
+		probe := remotemachine.NewRemoteMachine(machine, d.logger)
+		osName, err := remotemachine.DetectOSName(probe)
+		probe.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		machine.OSName = osName
+	}
+
+	if _, err := remotemachine.OSFamily(machine.OSName); err != nil {
+		return nil, fmt.Errorf("cannot scan %s/%s: %s", vm.JobName, vm.ID, err)
+	}
+
+	return remotemachine.NewRemoteMachine(machine, d.logger), nil
 }
 
 func (d *TargetDeploymentImpl) Cleanup() error {
 	d.logger.Debugf("About to cleanup SSH for deployment %s", d.Name())
-	slug := boshdir.NewAllOrInstanceGroupOrInstanceSlug("", "")
-	err := d.deployment.CleanUpSSH(slug, d.sshOpts)
-	return err
+
+	var cleanupErr error
+	for _, slug := range d.slugs {
+		err := d.deployment.CleanUpSSH(slug, d.sshOpts)
+		if err != nil {
+			d.logger.Errorf("Failed to clean up SSH for '%s' in deployment %s: %s", slug, d.Name(), err)
+			cleanupErr = err
+		}
+	}
+	d.slugs = nil
+
+	return cleanupErr
 }
 
 func getDirector(
@@ -214,6 +374,11 @@ func getUAA(dirConfig boshdir.FactoryConfig, creds boshconfig.Creds, caCert stri
 	uaaConfig.Client = creds.Client
 	uaaConfig.ClientSecret = creds.ClientSecret
 
+	// Refresh tokens from the bosh CLI config were issued to the bosh CLI
+	if uaaConfig.Client == "" {
+		uaaConfig.Client = "bosh_cli"
+	}
+
 	return boshuaa.NewFactory(logger).New(uaaConfig)
 }
 
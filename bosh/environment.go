@@ -0,0 +1,55 @@
+package bosh
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type Environment struct {
+	URL    string
+	CACert string
+	Creds  boshconfig.Creds
+}
+
+// ResolveEnvironment works out which director to talk to and how in the same
+// way as the bosh CLI. The environment can be a URL or an alias from the bosh
+// CLI config, and the CA certificate and credentials stored for it are used
+// unless they are given explicitly. The CA certificate can be a path or the
+// certificate itself.
+func ResolveEnvironment(urlOrAlias, caCert string, creds boshconfig.Creds, configPath string) (Environment, error) {
+	if urlOrAlias == "" {
+		return Environment{}, errors.New("a BOSH director must be given with --director-url or BOSH_ENVIRONMENT")
+	}
+
+	fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+	config, err := boshconfig.NewFSConfigFromPath(configPath, fs)
+	if err != nil {
+		return Environment{}, err
+	}
+
+	if caCert == "" {
+		caCert = config.CACert(urlOrAlias)
+	} else if !strings.Contains(caCert, "BEGIN CERTIFICATE") {
+		caCertBytes, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return Environment{}, err
+		}
+
+		caCert = string(caCertBytes)
+	}
+
+	if !creds.IsUAA() {
+		creds = config.Credentials(urlOrAlias)
+	}
+
+	return Environment{
+		URL:    config.ResolveEnvironment(urlOrAlias),
+		CACert: caCert,
+		Creds:  creds,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package bosh_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
+
+	"github.com/pivotal-cf/scantron/bosh"
+)
+
+var _ = Describe("ResolveEnvironment", func() {
+	const caCert = "-----BEGIN CERTIFICATE-----\nconfig\n-----END CERTIFICATE-----\n"
+
+	var (
+		tmpdir     string
+		configPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "bosh-config")
+		Expect(err).NotTo(HaveOccurred())
+
+		configPath = filepath.Join(tmpdir, "config")
+		err = ioutil.WriteFile(configPath, []byte(`
+environments:
+- url: https://10.0.0.6:25555
+  alias: prod
+  ca_cert: |
+    -----BEGIN CERTIFICATE-----
+    config
+    -----END CERTIFICATE-----
+  refresh_token: refresh-token
+`), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpdir)).To(Succeed())
+	})
+
+	It("uses the URL, CA certificate and credentials stored for an alias", func() {
+		env, err := bosh.ResolveEnvironment("prod", "", boshconfig.Creds{}, configPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(env).To(Equal(bosh.Environment{
+			URL:    "https://10.0.0.6:25555",
+			CACert: caCert,
+			Creds:  boshconfig.Creds{RefreshToken: "refresh-token"},
+		}))
+	})
+
+	It("prefers the credentials and CA certificate it was given", func() {
+		env, err := bosh.ResolveEnvironment(
+			"https://10.0.0.6:25555",
+			"-----BEGIN CERTIFICATE-----\ngiven\n-----END CERTIFICATE-----",
+			boshconfig.Creds{Client: "scantron", ClientSecret: "secret"},
+			configPath,
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(env.CACert).To(ContainSubstring("given"))
+		Expect(env.Creds).To(Equal(boshconfig.Creds{Client: "scantron", ClientSecret: "secret"}))
+	})
+
+	It("reads the CA certificate from a path", func() {
+		caCertPath := filepath.Join(tmpdir, "ca.pem")
+		Expect(ioutil.WriteFile(caCertPath, []byte("-----BEGIN CERTIFICATE-----\nfile\n"), 0600)).To(Succeed())
+
+		env, err := bosh.ResolveEnvironment("https://10.0.0.7:25555", caCertPath, boshconfig.Creds{}, configPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(env.URL).To(Equal("https://10.0.0.7:25555"))
+		Expect(env.CACert).To(ContainSubstring("file"))
+		Expect(env.Creds).To(Equal(boshconfig.Creds{}))
+	})
+
+	It("works without a bosh CLI config", func() {
+		env, err := bosh.ResolveEnvironment("https://10.0.0.6:25555", "", boshconfig.Creds{}, filepath.Join(tmpdir, "missing"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env.URL).To(Equal("https://10.0.0.6:25555"))
+	})
+
+	It("returns an error when the CA certificate cannot be read", func() {
+		_, err := bosh.ResolveEnvironment("prod", filepath.Join(tmpdir, "missing.pem"), boshconfig.Creds{}, configPath)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error without a director", func() {
+		_, err := bosh.ResolveEnvironment("", "", boshconfig.Creds{}, configPath)
+		Expect(err).To(MatchError(ContainSubstring("BOSH_ENVIRONMENT")))
+	})
+})
@@ -0,0 +1,179 @@
+package bosh
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+)
+
+type InstanceFilter struct {
+	InstanceGroups         []string `long:"instance-group" description:"Only scan instances in this instance group" value-name:"INSTANCE_GROUP"`
+	Instances              []string `long:"instance" description:"Only scan this instance" value-name:"INSTANCE_GROUP/ID"`
+	ExcludedInstanceGroups []string `long:"exclude-instance-group" description:"Do not scan instances in this instance group" value-name:"INSTANCE_GROUP"`
+	ExcludedInstances      []string `long:"exclude-instance" description:"Do not scan this instance" value-name:"INSTANCE_GROUP/ID"`
+}
+
+func (f InstanceFilter) Validate() error {
+	for _, instance := range append(f.Instances, f.ExcludedInstances...) {
+		if _, _, err := splitInstance(instance); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f InstanceFilter) Select(vms []boshdir.VMInfo) []boshdir.VMInfo {
+	selected := []boshdir.VMInfo{}
+
+	for _, vm := range vms {
+		if f.matches(vm) {
+			selected = append(selected, vm)
+		}
+	}
+
+	return selected
+}
+
+func (f InstanceFilter) matches(vm boshdir.VMInfo) bool {
+	included := len(f.InstanceGroups) == 0 && len(f.Instances) == 0
+	if !included {
+		included = containsString(f.InstanceGroups, vm.JobName) || matchesInstance(f.Instances, vm)
+	}
+
+	if !included {
+		return false
+	}
+
+	return !containsString(f.ExcludedInstanceGroups, vm.JobName) && !matchesInstance(f.ExcludedInstances, vm)
+}
+
+// SSHSlugs returns the narrowest set of slugs which covers the selected VMs.
+// Instance groups are used where every VM in the group was selected so that
+// the director does not have to set up SSH access one instance at a time.
+func SSHSlugs(all, selected []boshdir.VMInfo) []boshdir.AllOrInstanceGroupOrInstanceSlug {
+	if len(all) == len(selected) {
+		return []boshdir.AllOrInstanceGroupOrInstanceSlug{
+			boshdir.NewAllOrInstanceGroupOrInstanceSlug("", ""),
+		}
+	}
+
+	groupSizes := map[string]int{}
+	for _, vm := range all {
+		groupSizes[vm.JobName]++
+	}
+
+	groups := []string{}
+	selectedByGroup := map[string][]boshdir.VMInfo{}
+	for _, vm := range selected {
+		if _, found := selectedByGroup[vm.JobName]; !found {
+			groups = append(groups, vm.JobName)
+		}
+		selectedByGroup[vm.JobName] = append(selectedByGroup[vm.JobName], vm)
+	}
+
+	slugs := []boshdir.AllOrInstanceGroupOrInstanceSlug{}
+	for _, group := range groups {
+		vms := selectedByGroup[group]
+		if len(vms) == groupSizes[group] {
+			slugs = append(slugs, boshdir.NewAllOrInstanceGroupOrInstanceSlug(group, ""))
+			continue
+		}
+
+		for _, vm := range vms {
+			slugs = append(slugs, boshdir.NewAllOrInstanceGroupOrInstanceSlug(group, vm.ID))
+		}
+	}
+
+	return slugs
+}
+
+func matchesInstance(instances []string, vm boshdir.VMInfo) bool {
+	for _, instance := range instances {
+		group, id, err := splitInstance(instance)
+		if err != nil || group != vm.JobName {
+			continue
+		}
+
+		if id == vm.ID || (vm.Index != nil && id == strconv.Itoa(*vm.Index)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitInstance(instance string) (string, string, error) {
+	pieces := strings.Split(instance, "/")
+	if len(pieces) != 2 || pieces[0] == "" || pieces[1] == "" {
+		return "", "", fmt.Errorf("instance '%s' must be in the format 'instance-group/id-or-index'", instance)
+	}
+
+	return pieces[0], pieces[1], nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+type DeploymentSelection struct {
+	Names   []string
+	All     bool
+	Include []string
+	Exclude []string
+}
+
+func (s DeploymentSelection) Validate() error {
+	if s.All && len(s.Names) > 0 {
+		return errors.New("deployment names cannot be given when scanning all deployments")
+	}
+
+	if !s.All && len(s.Names) == 0 {
+		return errors.New("at least one deployment must be given unless scanning all deployments")
+	}
+
+	if !s.All && (len(s.Include) > 0 || len(s.Exclude) > 0) {
+		return errors.New("deployment patterns can only be used when scanning all deployments")
+	}
+
+	for _, pattern := range append(s.Include, s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid deployment pattern '%s': %s", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (s DeploymentSelection) Select(names []string) []string {
+	selected := []string{}
+
+	for _, name := range names {
+		included := len(s.Include) == 0 || matchesPattern(s.Include, name)
+		if included && !matchesPattern(s.Exclude, name) {
+			selected = append(selected, name)
+		}
+	}
+
+	return selected
+}
+
+func matchesPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
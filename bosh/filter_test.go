@@ -0,0 +1,155 @@
+package bosh_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+
+	"github.com/pivotal-cf/scantron/bosh"
+)
+
+var _ = Describe("InstanceFilter", func() {
+	var (
+		vms    []boshdir.VMInfo
+		filter bosh.InstanceFilter
+	)
+
+	index := func(i int) *int {
+		return &i
+	}
+
+	names := func(vms []boshdir.VMInfo) []string {
+		result := []string{}
+		for _, vm := range vms {
+			result = append(result, vm.JobName+"/"+vm.ID)
+		}
+		return result
+	}
+
+	slugs := func(slugs []boshdir.AllOrInstanceGroupOrInstanceSlug) []string {
+		result := []string{}
+		for _, slug := range slugs {
+			result = append(result, slug.String())
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		vms = []boshdir.VMInfo{
+			{JobName: "router", ID: "router-0-id", Index: index(0)},
+			{JobName: "router", ID: "router-1-id", Index: index(1)},
+			{JobName: "diego_cell", ID: "cell-0-id", Index: index(0)},
+			{JobName: "diego_cell", ID: "cell-1-id", Index: index(1)},
+		}
+		filter = bosh.InstanceFilter{}
+	})
+
+	It("selects every VM when it is empty", func() {
+		selected := filter.Select(vms)
+		Expect(selected).To(Equal(vms))
+		Expect(slugs(bosh.SSHSlugs(vms, selected))).To(Equal([]string{""}))
+	})
+
+	It("selects the instances in an instance group", func() {
+		filter.InstanceGroups = []string{"router"}
+
+		selected := filter.Select(vms)
+		Expect(names(selected)).To(Equal([]string{"router/router-0-id", "router/router-1-id"}))
+		Expect(slugs(bosh.SSHSlugs(vms, selected))).To(Equal([]string{"router"}))
+	})
+
+	It("selects instances by id or index", func() {
+		filter.Instances = []string{"router/router-1-id", "diego_cell/0"}
+
+		selected := filter.Select(vms)
+		Expect(names(selected)).To(Equal([]string{"router/router-1-id", "diego_cell/cell-0-id"}))
+		Expect(slugs(bosh.SSHSlugs(vms, selected))).To(Equal([]string{"router/router-1-id", "diego_cell/cell-0-id"}))
+	})
+
+	It("drops excluded instance groups and instances", func() {
+		filter.ExcludedInstanceGroups = []string{"router"}
+		filter.ExcludedInstances = []string{"diego_cell/cell-1-id"}
+
+		selected := filter.Select(vms)
+		Expect(names(selected)).To(Equal([]string{"diego_cell/cell-0-id"}))
+		Expect(slugs(bosh.SSHSlugs(vms, selected))).To(Equal([]string{"diego_cell/cell-0-id"}))
+	})
+
+	It("lets exclusions win over inclusions", func() {
+		filter.InstanceGroups = []string{"router", "diego_cell"}
+		filter.ExcludedInstances = []string{"router/0"}
+
+		selected := filter.Select(vms)
+		Expect(names(selected)).To(Equal([]string{"router/router-1-id", "diego_cell/cell-0-id", "diego_cell/cell-1-id"}))
+		Expect(slugs(bosh.SSHSlugs(vms, selected))).To(Equal([]string{"router/router-1-id", "diego_cell"}))
+	})
+
+	Describe("Validate", func() {
+		It("accepts instances in the group/id format", func() {
+			filter.Instances = []string{"router/0"}
+			filter.ExcludedInstances = []string{"router/router-1-id"}
+			Expect(filter.Validate()).To(Succeed())
+		})
+
+		It("rejects instances without an instance group", func() {
+			filter.Instances = []string{"router"}
+			Expect(filter.Validate()).To(MatchError(ContainSubstring("'router' must be in the format")))
+		})
+
+		It("rejects malformed excluded instances", func() {
+			filter.ExcludedInstances = []string{"router/0/1"}
+			Expect(filter.Validate()).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("DeploymentSelection", func() {
+	var selection bosh.DeploymentSelection
+
+	BeforeEach(func() {
+		selection = bosh.DeploymentSelection{All: true}
+	})
+
+	It("selects every deployment without patterns", func() {
+		Expect(selection.Select([]string{"cf-abc", "mysql-def"})).To(Equal([]string{"cf-abc", "mysql-def"}))
+	})
+
+	It("selects deployments matching the include patterns", func() {
+		selection.Include = []string{"cf-*", "redis"}
+		Expect(selection.Select([]string{"cf-abc", "mysql-def", "redis"})).To(Equal([]string{"cf-abc", "redis"}))
+	})
+
+	It("drops deployments matching the exclude patterns", func() {
+		selection.Include = []string{"*"}
+		selection.Exclude = []string{"mysql-*"}
+		Expect(selection.Select([]string{"cf-abc", "mysql-def"})).To(Equal([]string{"cf-abc"}))
+	})
+
+	Describe("Validate", func() {
+		It("requires deployment names unless all deployments are scanned", func() {
+			selection.All = false
+			Expect(selection.Validate()).To(HaveOccurred())
+
+			selection.Names = []string{"cf"}
+			Expect(selection.Validate()).To(Succeed())
+		})
+
+		It("does not allow deployment names with all deployments", func() {
+			selection.Names = []string{"cf"}
+			Expect(selection.Validate()).To(HaveOccurred())
+		})
+
+		It("only allows patterns with all deployments", func() {
+			selection.All = false
+			selection.Names = []string{"cf"}
+			selection.Exclude = []string{"mysql-*"}
+			Expect(selection.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects malformed patterns", func() {
+			selection.Include = []string{"cf-["}
+			Expect(selection.Validate()).To(MatchError(ContainSubstring("invalid deployment pattern 'cf-['")))
+		})
+	})
+})
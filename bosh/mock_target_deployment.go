@@ -46,6 +46,18 @@ func (mr *MockTargetDeploymentMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockTargetDeployment)(nil).Name))
 }
 
+// Director mocks base method
+func (m *MockTargetDeployment) Director() string {
+	ret := m.ctrl.Call(m, "Director")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Director indicates an expected call of Director
+func (mr *MockTargetDeploymentMockRecorder) Director() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Director", reflect.TypeOf((*MockTargetDeployment)(nil).Director))
+}
+
 // VMs mocks base method
 func (m *MockTargetDeployment) VMs() []director.VMInfo {
 	ret := m.ctrl.Call(m, "VMs")
@@ -70,6 +82,30 @@ func (mr *MockTargetDeploymentMockRecorder) Releases() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Releases", reflect.TypeOf((*MockTargetDeployment)(nil).Releases))
 }
 
+// Stemcells mocks base method
+func (m *MockTargetDeployment) Stemcells() []director.Stemcell {
+	ret := m.ctrl.Call(m, "Stemcells")
+	ret0, _ := ret[0].([]director.Stemcell)
+	return ret0
+}
+
+// Stemcells indicates an expected call of Stemcells
+func (mr *MockTargetDeploymentMockRecorder) Stemcells() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stemcells", reflect.TypeOf((*MockTargetDeployment)(nil).Stemcells))
+}
+
+// InstanceStemcell mocks base method
+func (m *MockTargetDeployment) InstanceStemcell(arg0 director.VMInfo) director.Stemcell {
+	ret := m.ctrl.Call(m, "InstanceStemcell", arg0)
+	ret0, _ := ret[0].(director.Stemcell)
+	return ret0
+}
+
+// InstanceStemcell indicates an expected call of InstanceStemcell
+func (mr *MockTargetDeploymentMockRecorder) InstanceStemcell(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceStemcell", reflect.TypeOf((*MockTargetDeployment)(nil).InstanceStemcell), arg0)
+}
+
 // Setup mocks base method
 func (m *MockTargetDeployment) Setup() error {
 	ret := m.ctrl.Call(m, "Setup")
@@ -83,10 +119,11 @@ func (mr *MockTargetDeploymentMockRecorder) Setup() *gomock.Call {
 }
 
 // ConnectTo mocks base method
-func (m *MockTargetDeployment) ConnectTo(arg0 director.VMInfo) remotemachine.RemoteMachine {
+func (m *MockTargetDeployment) ConnectTo(arg0 director.VMInfo) (remotemachine.RemoteMachine, error) {
 	ret := m.ctrl.Call(m, "ConnectTo", arg0)
 	ret0, _ := ret[0].(remotemachine.RemoteMachine)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ConnectTo indicates an expected call of ConnectTo
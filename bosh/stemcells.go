@@ -0,0 +1,78 @@
+package bosh
+
+import (
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type deploymentManifest struct {
+	Stemcells []struct {
+		Alias   string `yaml:"alias"`
+		OS      string `yaml:"os"`
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+	} `yaml:"stemcells"`
+
+	InstanceGroups []struct {
+		Name     string `yaml:"name"`
+		Stemcell string `yaml:"stemcell"`
+	} `yaml:"instance_groups"`
+}
+
+// InstanceGroupStemcells works out which of the deployment's stemcells each
+// instance group runs on. The director does not report this per VM so it is
+// taken from the stemcell aliases in the deployment manifest. Deployments
+// with a single stemcell don't need the manifest at all.
+func InstanceGroupStemcells(manifest string, stemcells []boshdir.Stemcell) (map[string]boshdir.Stemcell, error) {
+	groups := map[string]boshdir.Stemcell{}
+
+	var m deploymentManifest
+	err := yaml.Unmarshal([]byte(manifest), &m)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := map[string]boshdir.Stemcell{}
+	for _, s := range m.Stemcells {
+		if stemcell := findStemcell(stemcells, s.OS, s.Name, s.Version); stemcell != nil {
+			aliases[s.Alias] = stemcell
+		}
+	}
+
+	for _, group := range m.InstanceGroups {
+		if stemcell, found := aliases[group.Stemcell]; found {
+			groups[group.Name] = stemcell
+		} else if len(stemcells) == 1 {
+			groups[group.Name] = stemcells[0]
+		}
+	}
+
+	return groups, nil
+}
+
+func findStemcell(stemcells []boshdir.Stemcell, osName, name, version string) boshdir.Stemcell {
+	var found boshdir.Stemcell
+
+	for _, stemcell := range stemcells {
+		if osName != "" && stemcell.OSName() != osName {
+			continue
+		}
+
+		if name != "" && stemcell.Name() != name {
+			continue
+		}
+
+		if version == "latest" {
+			if found == nil || stemcell.Version().IsGt(found.Version()) {
+				found = stemcell
+			}
+			continue
+		}
+
+		if stemcell.Version().String() == version {
+			return stemcell
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,96 @@
+package bosh_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	"github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	"github.com/cppforlife/go-semi-semantic/version"
+
+	"github.com/pivotal-cf/scantron/bosh"
+)
+
+var _ = Describe("InstanceGroupStemcells", func() {
+	var xenial, windows *directorfakes.FakeStemcell
+
+	stemcell := func(name, osName, v string) *directorfakes.FakeStemcell {
+		s := &directorfakes.FakeStemcell{}
+		s.NameReturns(name)
+		s.OSNameReturns(osName)
+		s.VersionReturns(version.MustNewVersionFromString(v))
+		return s
+	}
+
+	BeforeEach(func() {
+		xenial = stemcell("bosh-aws-xen-hvm-ubuntu-xenial-go_agent", "ubuntu-xenial", "170.15")
+		windows = stemcell("bosh-aws-xen-hvm-windows2016-go_agent", "windows2016", "1803.5")
+	})
+
+	It("maps instance groups to stemcells through their aliases", func() {
+		manifest := `
+stemcells:
+- alias: default
+  os: ubuntu-xenial
+  version: "170.15"
+- alias: windows
+  os: windows2016
+  version: latest
+instance_groups:
+- name: router
+  stemcell: default
+- name: windows_cell
+  stemcell: windows
+`
+
+		groups, err := bosh.InstanceGroupStemcells(manifest, []boshdir.Stemcell{xenial, windows})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(Equal(map[string]boshdir.Stemcell{
+			"router":       xenial,
+			"windows_cell": windows,
+		}))
+	})
+
+	It("matches stemcells by name", func() {
+		manifest := `
+stemcells:
+- alias: default
+  name: bosh-aws-xen-hvm-windows2016-go_agent
+  version: 1803.5
+instance_groups:
+- name: windows_cell
+  stemcell: default
+`
+
+		groups, err := bosh.InstanceGroupStemcells(manifest, []boshdir.Stemcell{xenial, windows})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(Equal(map[string]boshdir.Stemcell{"windows_cell": windows}))
+	})
+
+	It("uses the only stemcell when the manifest does not say", func() {
+		manifest := `
+instance_groups:
+- name: router
+`
+
+		groups, err := bosh.InstanceGroupStemcells(manifest, []boshdir.Stemcell{xenial})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(Equal(map[string]boshdir.Stemcell{"router": xenial}))
+	})
+
+	It("leaves out instance groups it cannot work out", func() {
+		manifest := `
+instance_groups:
+- name: router
+`
+
+		groups, err := bosh.InstanceGroupStemcells(manifest, []boshdir.Stemcell{xenial, windows})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(BeEmpty())
+	})
+
+	It("returns an error when the manifest is malformed", func() {
+		_, err := bosh.InstanceGroupStemcells("instance_groups: {", []boshdir.Stemcell{xenial})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,72 @@
+package capability_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/capability"
+)
+
+var _ = Describe("Capabilities", func() {
+	Describe("Names", func() {
+		It("names each capability in the set", func() {
+			Expect(capability.Names(0x3400)).To(Equal([]string{
+				"CAP_NET_BIND_SERVICE",
+				"CAP_NET_ADMIN",
+				"CAP_NET_RAW",
+			}))
+		})
+
+		It("numbers capabilities it doesn't know", func() {
+			Expect(capability.Names(1 << 50)).To(Equal([]string{"CAP_50"}))
+		})
+
+		It("returns nothing for an empty set", func() {
+			Expect(capability.Names(0)).To(BeEmpty())
+		})
+	})
+
+	Describe("ParseFileXattr", func() {
+		It("reads revision 2 attributes", func() {
+			// setcap cap_net_bind_service,cap_net_raw+ep
+			set, err := capability.ParseFileXattr([]byte{
+				0x01, 0x00, 0x00, 0x02,
+				0x00, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(Equal(capability.FileSet{Permitted: 0x2400, Effective: true}))
+		})
+
+		It("reads revision 3 attributes with capabilities above 31", func() {
+			// setcap cap_sys_ptrace=i,cap_syslog=p in a user namespace
+			set, err := capability.ParseFileXattr([]byte{
+				0x00, 0x00, 0x00, 0x03,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0xe8, 0x03, 0x00, 0x00,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(Equal(capability.FileSet{Permitted: 1 << 34, Inheritable: 1 << 19}))
+		})
+
+		It("reads revision 1 attributes", func() {
+			set, err := capability.ParseFileXattr([]byte{
+				0x00, 0x00, 0x00, 0x01,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(Equal(capability.FileSet{Permitted: 1}))
+		})
+
+		It("returns an error for truncated attributes", func() {
+			_, err := capability.ParseFileXattr([]byte{0x01, 0x00, 0x00, 0x02, 0x00})
+			Expect(err).To(MatchError("capability attribute is too short: 5 bytes"))
+		})
+
+		It("returns an error for unknown revisions", func() {
+			_, err := capability.ParseFileXattr([]byte{0x00, 0x00, 0x00, 0x09, 0, 0, 0, 0, 0, 0, 0, 0})
+			Expect(err).To(MatchError("unknown capability attribute revision 0x9000000"))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package capability
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// FileXattr is the extended attribute holding the capabilities of an
+// executable, as set by setcap.
+const FileXattr = "security.capability"
+
+// From linux/capability.h
+const (
+	revisionMask    = 0xFF000000
+	revision1       = 0x01000000
+	revision2       = 0x02000000
+	revision3       = 0x03000000
+	effectiveFlag   = 0x000001
+	revision1Length = 4 + 8
+	revision2Length = 4 + 2*8
+)
+
+// FileSet is the capabilities an executable is given when it runs. When
+// Effective is set they are effective straight away rather than only
+// permitted.
+type FileSet struct {
+	Permitted   uint64
+	Inheritable uint64
+	Effective   bool
+}
+
+// ParseFileXattr reads the value of the security.capability extended
+// attribute.
+func ParseFileXattr(data []byte) (FileSet, error) {
+	if len(data) < 4 {
+		return FileSet{}, fmt.Errorf("capability attribute is too short: %d bytes", len(data))
+	}
+
+	magic := binary.LittleEndian.Uint32(data)
+
+	var words int
+	switch magic & revisionMask {
+	case revision1:
+		if len(data) < revision1Length {
+			return FileSet{}, fmt.Errorf("capability attribute is too short: %d bytes", len(data))
+		}
+		words = 1
+	case revision2, revision3:
+		if len(data) < revision2Length {
+			return FileSet{}, fmt.Errorf("capability attribute is too short: %d bytes", len(data))
+		}
+		words = 2
+	default:
+		return FileSet{}, fmt.Errorf("unknown capability attribute revision %#x", magic&revisionMask)
+	}
+
+	set := FileSet{Effective: magic&effectiveFlag != 0}
+	for i := 0; i < words; i++ {
+		offset := 4 + i*8
+		set.Permitted |= uint64(binary.LittleEndian.Uint32(data[offset:])) << (32 * uint(i))
+		set.Inheritable |= uint64(binary.LittleEndian.Uint32(data[offset+4:])) << (32 * uint(i))
+	}
+
+	return set, nil
+}
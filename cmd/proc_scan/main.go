@@ -1,25 +1,33 @@
 package main
 
 import (
-	"encoding/json"
+	"compress/gzip"
 	"fmt"
 	"github.com/jessevdk/go-flags"
-	"github.com/pivotal-cf/scantron/filesystem"
-	"github.com/pivotal-cf/scantron/ssh"
-	"github.com/pivotal-cf/scantron/tlsscan"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 
+	"golang.org/x/crypto/ssh"
+
 	"github.com/pivotal-cf/scantron"
-	"github.com/pivotal-cf/scantron/process"
+	"github.com/pivotal-cf/scantron/collector"
+	"github.com/pivotal-cf/scantron/resultfile"
+	"github.com/pivotal-cf/scantron/resultstream"
 	"github.com/pivotal-cf/scantron/scanlog"
 )
 
 func main() {
 	var opts struct {
-		Debug       bool               `long:"debug" description:"Show debug logs in output"`
-		Context     string             `long:"context" description:"Log context"`
-		FileRegexes scantron.FileMatch `group:"File Content Check"`
+		Debug         bool               `long:"debug" description:"Show debug logs in output"`
+		Context       string             `long:"context" description:"Log context"`
+		Gzip          bool               `long:"gzip" description:"Compress the results"`
+		RevealSecrets bool               `long:"reveal-secrets" description:"Keep the values of secrets given to processes instead of redacting them"`
+		Output        string             `long:"output" description:"Write the results to a file instead of stdout" value-name:"PATH"`
+		SignKey       string             `long:"sign-key" description:"Sign the results written with --output using this SSH private key" value-name:"PATH"`
+		FileRegexes   scantron.FileMatch `group:"File Content Check"`
+		FileScope     scantron.FileScope `group:"File Selection"`
 	}
 
 	_, err := flags.Parse(&opts)
@@ -35,44 +43,63 @@ func main() {
 		"context", opts.Context,
 	)
 
-	processScanner := process.ProcessScanner{
-		SysRes:  &process.SystemResourceImpl{},
-		TlsScan: &tlsscan.TlsScannerImpl{},
+	if opts.SignKey != "" && opts.Output == "" {
+		fmt.Fprintln(os.Stderr, "error: --sign-key can only be used with --output")
+		os.Exit(1)
 	}
 
-	processes, err := processScanner.ScanProcesses(logger)
+	systemInfo, err := collector.Collect(collector.Options{
+		FileRegexes:   opts.FileRegexes,
+		FileScope:     opts.FileScope,
+		RevealSecrets: opts.RevealSecrets,
+	}, logger)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: failed to get process list:", err)
+		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
 
-	fileWalker, err := filesystem.NewWalker(filesystem.GetFileConfig(), opts.FileRegexes, logger)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: failed to instantiate filewalker:", err)
-		os.Exit(1)
+	if opts.Output != "" {
+		var signer ssh.Signer
+		if opts.SignKey != "" {
+			signer, err = loadSigner(opts.SignKey)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error: failed to load signing key:", err)
+				os.Exit(1)
+			}
+		}
+
+		err = resultfile.Write(opts.Output, systemInfo, opts.Gzip, signer)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: failed to write results:", err)
+			os.Exit(1)
+		}
+
+		return
 	}
-	fs := filesystem.FileScanner{
-		Walker:   fileWalker,
-		Metadata: filesystem.GetFileMetadata(),
-		Logger:   logger,
+
+	var output io.WriteCloser = os.Stdout
+	if opts.Gzip {
+		output = gzip.NewWriter(os.Stdout)
 	}
-	files, err := fs.ScanFiles()
+
+	err = resultstream.Encode(output, systemInfo)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: failed to scan filesystem:", err)
+		fmt.Fprintln(os.Stderr, "error: failed to write results:", err)
 		os.Exit(1)
 	}
 
-	sshKeys, err := ssh.ScanSSH("localhost:22")
+	err = output.Close()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error: failed to scan ssh keys:", err)
+		fmt.Fprintln(os.Stderr, "error: failed to write results:", err)
 		os.Exit(1)
 	}
+}
 
-	systemInfo := scantron.SystemInfo{
-		Processes: processes,
-		Files:     files,
-		SSHKeys:   sshKeys,
+func loadSigner(path string) (ssh.Signer, error) {
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	json.NewEncoder(os.Stdout).Encode(systemInfo)
+	return ssh.ParsePrivateKey(key)
 }
@@ -0,0 +1,138 @@
+// Package collector gathers the processes, files, SSH keys, packages, kernel
+// settings and firewall rules of the machine it runs on. It is used by
+// proc_scan on remote machines and by local scans.
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/filesystem"
+	"github.com/pivotal-cf/scantron/firewall"
+	"github.com/pivotal-cf/scantron/kernel"
+	"github.com/pivotal-cf/scantron/packages"
+	"github.com/pivotal-cf/scantron/process"
+	"github.com/pivotal-cf/scantron/rules"
+	"github.com/pivotal-cf/scantron/scanlog"
+	"github.com/pivotal-cf/scantron/ssh"
+	"github.com/pivotal-cf/scantron/tlsscan"
+)
+
+type Options struct {
+	FileRegexes scantron.FileMatch
+	FileScope   scantron.FileScope
+
+	// SSHOptional lets machines without an SSH server, like containers, be
+	// scanned. They are recorded without any SSH keys.
+	SSHOptional bool
+
+	// RevealSecrets keeps the values of secrets given to processes instead
+	// of redacting them.
+	RevealSecrets bool
+}
+
+// Collect returns an error only when it cannot start. Failures to scan part
+// of the machine are recorded in the Errors of the results instead, so that
+// the rest of the results are kept.
+func Collect(options Options, logger scanlog.Logger) (scantron.SystemInfo, error) {
+	systemInfo := scantron.SystemInfo{
+		Processes:        []scantron.Process{},
+		Files:            []scantron.File{},
+		SSHKeys:          []scantron.SSHKey{},
+		Packages:         []scantron.Package{},
+		FirewallRulesets: []scantron.FirewallRuleset{},
+		Errors:           []scantron.CollectionError{},
+	}
+
+	fileMatch := options.FileRegexes
+	loadedRules, err := rules.Load(fileMatch.RulePacks, logger)
+	if err != nil {
+		return systemInfo, fmt.Errorf("failed to load rule packs: %s", err)
+	}
+	fileMatch.Rules = loadedRules
+
+	fileWalker, err := filesystem.NewWalker(filesystem.GetFileConfig().WithScope(options.FileScope), fileMatch, logger)
+	if err != nil {
+		return systemInfo, fmt.Errorf("failed to instantiate filewalker: %s", err)
+	}
+
+	failed := func(subsystem string, err error) {
+		logger.Errorf("Failed to scan %s: %s", subsystem, err)
+		systemInfo.Errors = append(systemInfo.Errors, scantron.CollectionError{
+			Subsystem: subsystem,
+			Message:   strings.TrimSpace(err.Error()),
+		})
+	}
+
+	processScanner := process.ProcessScanner{
+		SysRes:  &process.SystemResourceImpl{},
+		TlsScan: &tlsscan.TlsScannerImpl{},
+
+		RevealSecrets: options.RevealSecrets,
+	}
+
+	// Processes are kept when only some of their ports, sockets or status
+	// could not be read
+	processes, err := processScanner.ScanProcesses(logger)
+	if err != nil {
+		failed(scantron.ProcessSubsystem, err)
+	}
+	systemInfo.Processes = processes
+
+	// Rulesets which were read are used even if others could not be, since
+	// missing rules only make fewer ports look blocked
+	rulesets, err := firewall.Collect()
+	if err != nil {
+		failed(scantron.FirewallSubsystem, err)
+	}
+	systemInfo.FirewallRulesets = rulesets
+	firewall.Parse(rulesets).Annotate(systemInfo.Processes)
+
+	fs := filesystem.FileScanner{
+		Walker:   fileWalker,
+		Metadata: filesystem.GetFileMetadata(),
+		Logger:   logger,
+	}
+	files, err := fs.ScanFiles()
+	if err != nil {
+		failed(scantron.FileSubsystem, err)
+	} else {
+		systemInfo.Files = files
+	}
+
+	sshKeys, err := ssh.ScanSSH("localhost:22")
+	if err != nil {
+		if options.SSHOptional {
+			logger.Infof("Skipping SSH keys, no SSH server is reachable: %s", strings.TrimSpace(err.Error()))
+		} else {
+			failed(scantron.SSHSubsystem, err)
+		}
+	} else {
+		systemInfo.SSHKeys = sshKeys
+	}
+
+	installed, err := packages.Installed()
+	if err != nil {
+		failed(scantron.PackageSubsystem, err)
+	} else {
+		systemInfo.Packages = installed
+	}
+	systemInfo.Packages = append(systemInfo.Packages, packages.ListeningGoModules(systemInfo.Processes, logger)...)
+
+	release, err := packages.Release()
+	if err != nil {
+		failed(scantron.PackageSubsystem, err)
+	} else {
+		systemInfo.OSRelease = release
+	}
+
+	kernelInfo, err := kernel.Collect()
+	if err != nil {
+		failed(scantron.KernelSubsystem, err)
+	} else {
+		systemInfo.Kernel = kernelInfo
+	}
+
+	return systemInfo, nil
+}
@@ -15,6 +15,7 @@ var AuditError = ExitStatusError{message: "audit mismatch", exitStatus: 3}
 type AuditCommand struct {
 	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
 	Manifest string `long:"manifest" description:"path to manifest" required:"true" value-name:"PATH"`
+	HostOnly bool   `long:"host-only" description:"leave out ports opened inside containers"`
 }
 
 func (command *AuditCommand) Execute(args []string) error {
@@ -28,7 +29,7 @@ func (command *AuditCommand) Execute(args []string) error {
 		return err
 	}
 
-	report, err := audit.Audit(db.DB(), man)
+	report, err := audit.Audit(db.DB(), man, audit.Options{HostOnly: command.HostOnly})
 	if err != nil {
 		return err
 	}
@@ -68,8 +69,17 @@ func ShowReport(output io.Writer, report audit.AuditResult) error {
 		if len(hostReport.UnexpectedPorts) > 0 {
 			fmt.Fprintln(output, "  found unexpected ports:")
 
+			firewalled := map[audit.Port]bool{}
+			for _, port := range hostReport.FirewalledPorts {
+				firewalled[port] = true
+			}
+
 			for _, port := range hostReport.UnexpectedPorts {
-				fmt.Fprintf(output, "    %d\n", port)
+				if firewalled[port] {
+					fmt.Fprintf(output, "    %d (blocked by host firewall)\n", port)
+				} else {
+					fmt.Fprintf(output, "    %d\n", port)
+				}
 			}
 
 			fmt.Fprintln(output)
@@ -105,6 +115,36 @@ func ShowReport(output io.Writer, report audit.AuditResult) error {
 
 			fmt.Fprintln(output)
 		}
+
+		if hostReport.KernelNotCollected {
+			fmt.Fprintln(output, "  could not check the kernel settings mentioned in manifest: the kernel was not collected")
+			fmt.Fprintln(output)
+		}
+
+		if len(hostReport.MismatchedSysctls) > 0 {
+			fmt.Fprintln(output, "  kernel parameters did not have the values mentioned in manifest:")
+
+			for _, sysctl := range hostReport.MismatchedSysctls {
+				if sysctl.Actual == "" {
+					fmt.Fprintf(output, "    %s should be '%s' but was not set\n", sysctl.Name, sysctl.Expected)
+				} else {
+					fmt.Fprintf(output, "    %s should be '%s' but was actually '%s'\n", sysctl.Name,
+						sysctl.Expected, sysctl.Actual)
+				}
+			}
+
+			fmt.Fprintln(output)
+		}
+
+		if len(hostReport.MissingBootParameters) > 0 {
+			fmt.Fprintln(output, "  did not find boot parameters that were mentioned in manifest:")
+
+			for _, parameter := range hostReport.MissingBootParameters {
+				fmt.Fprintf(output, "    %s\n", parameter)
+			}
+
+			fmt.Fprintln(output)
+		}
 	}
 
 	if report.OK() {
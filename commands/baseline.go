@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pivotal-cf/scantron/baseline"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var BaselineError = ExitStatusError{message: "baseline mismatch", exitStatus: 3}
+
+type BaselineCommand struct {
+	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
+	Baseline string `long:"baseline" description:"path to the baseline of file hashes, which is empty if it does not exist" required:"true" value-name:"PATH"`
+	Update   bool   `long:"update" description:"record the hashes all instances agree on in the baseline"`
+}
+
+func (command *BaselineCommand) Execute(args []string) error {
+	b, err := baseline.Load(command.Baseline)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.OpenDatabase(command.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	hashes, err := baseline.Hashes(database.DB())
+	if err != nil {
+		return err
+	}
+
+	differences := baseline.Compare(hashes, b)
+
+	if command.Update {
+		err = baseline.Update(hashes, b).Save(command.Baseline)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Baseline saved:", command.Baseline)
+	}
+
+	if len(differences) == 0 {
+		return nil
+	}
+
+	differencesReport := report.Report{
+		Title:  "Files that differ from other instances or were modified or removed since the baseline:",
+		Header: []string{"Director", "Deployment", "Instance Group", "Host", "Path", "SHA-256", "Expected", "Reason"},
+	}
+	for _, d := range differences {
+		differencesReport.Rows = append(differencesReport.Rows, []string{
+			d.Director, d.Deployment, d.InstanceGroup, d.Host, d.Path, shortHash(d.SHA256), shortHash(d.Expected), d.Reason,
+		})
+	}
+	differencesReport.WriteTo(os.Stdout)
+
+	return BaselineError
+}
+
+// shortHash is enough of a SHA-256 to tell hashes apart in a table.
+func shortHash(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
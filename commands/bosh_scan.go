@@ -2,10 +2,10 @@ package commands
 
 import (
 	"fmt"
-	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/bosh"
 	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/rules"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/scanner"
 	"log"
@@ -13,17 +13,15 @@ import (
 )
 
 type BoshScanCommand struct {
-	Director struct {
-		URL          string   `long:"director-url" description:"BOSH Director URL" value-name:"URL" required:"true"`
-		Deployments  []string `long:"bosh-deployment" description:"BOSH Deployment" value-name:"DEPLOYMENT_NAME" required:"true"`
-		CACert       string   `long:"ca-cert" description:"Director CA certificate path" value-name:"CA_CERT"`
-		Client       string   `long:"client" description:"Username or UAA client" value-name:"CLIENT"`
-		ClientSecret string   `long:"client-secret" description:"Password or UAA client secret" value-name:"CLIENT_SECRET"`
-	} `group:"Director & Deployment"`
-
-	FileRegexes scantron.FileMatch `group:"File Content Check"`
-	Database    string             `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
-	Serial      bool               `long:"serial" description:"run scans serially"`
+	Director DirectorOptions `group:"Director & Deployment"`
+
+	Filter        bosh.InstanceFilter `group:"Instance Selection"`
+	FileRegexes   scantron.FileMatch  `group:"File Content Check"`
+	FileScope     scantron.FileScope  `group:"File Selection"`
+	Database      string              `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+	Serial        bool                `long:"serial" description:"run scans serially"`
+	Gzip          bool                `long:"gzip" description:"compress scan results sent back from each VM"`
+	RevealSecrets bool                `long:"reveal-secrets" description:"store the values of secrets given to processes instead of redacting them"`
 }
 
 type ScanResult struct {
@@ -32,7 +30,11 @@ type ScanResult struct {
 }
 
 func scan(dep bosh.TargetDeployment, command *BoshScanCommand, logger scanlog.Logger, results chan<- ScanResult) {
-	result, err := scanner.Bosh(dep).Scan(&command.FileRegexes, logger)
+	result, err := scanner.Bosh(dep, scanner.Options{
+		Gzip:          command.Gzip,
+		RevealSecrets: command.RevealSecrets,
+		FileScope:     command.FileScope,
+	}).Scan(&command.FileRegexes, logger)
 	if err != nil {
 		log.Fatalf("failed to scan: %s", err.Error())
 	}
@@ -50,16 +52,28 @@ func (command *BoshScanCommand) Execute(args []string) error {
 
 	logger.Debugf("Requested deployments to scan: %v", command.Director.Deployments)
 
-	deployments, err := bosh.GetDeployments(
-		boshconfig.Creds{
-			Client:       command.Director.Client,
-			ClientSecret: command.Director.ClientSecret,
-		},
-		command.Director.CACert,
-		command.Director.Deployments,
-		command.Director.URL,
-		logger,
-	)
+	selection := command.Director.Selection()
+	err = selection.Validate()
+	if err != nil {
+		log.Fatalf("invalid deployment selection: %s", err.Error())
+	}
+
+	err = command.Filter.Validate()
+	if err != nil {
+		log.Fatalf("invalid instance selection: %s", err.Error())
+	}
+
+	_, err = rules.Load(command.FileRegexes.RulePacks, logger)
+	if err != nil {
+		log.Fatalf("invalid rule pack: %s", err.Error())
+	}
+
+	env, err := command.Director.Environment()
+	if err != nil {
+		log.Fatalf("failed to resolve director: %s", err.Error())
+	}
+
+	deployments, err := bosh.GetDeployments(env, selection, command.Filter, logger)
 
 	if err != nil {
 		log.Fatalf("failed to set up director: %s", err.Error())
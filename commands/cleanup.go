@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/bosh"
+	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type CleanupCommand struct {
+	Director DirectorOptions `group:"Director & Deployment"`
+
+	Machine struct {
+		Address    string `long:"address" description:"Address of machine to clean up" value-name:"ADDRESS"`
+		Username   string `long:"username" description:"Username used to scan the machine" value-name:"USERNAME"`
+		Password   string `long:"password" description:"Password of machine to clean up" value-name:"PASSWORD"`
+		PrivateKey string `long:"private-key" description:"Private key of machine to clean up" value-name:"PATH"`
+	} `group:"Direct Machine"`
+}
+
+func (command *CleanupCommand) Execute(args []string) error {
+	scantron.SetDebug(Scantron.Debug)
+	logger, err := scanlog.NewLogger(Scantron.Debug)
+	if err != nil {
+		log.Fatalln("failed to set up logger:", err)
+	}
+
+	if command.Machine.Address != "" {
+		command.cleanupMachine(logger)
+		return nil
+	}
+
+	selection := command.Director.Selection()
+	err = selection.Validate()
+	if err != nil {
+		log.Fatalf("invalid deployment selection: %s", err.Error())
+	}
+
+	env, err := command.Director.Environment()
+	if err != nil {
+		log.Fatalf("failed to resolve director: %s", err.Error())
+	}
+
+	err = bosh.CleanupSSHUsers(env, selection, logger)
+	if err != nil {
+		log.Fatalf("failed to clean up SSH users: %s", err.Error())
+	}
+
+	fmt.Println("Removed scantron SSH users and their home directories")
+
+	return nil
+}
+
+func (command *CleanupCommand) cleanupMachine(logger scanlog.Logger) {
+	if command.Machine.Username == "" {
+		log.Fatalf("a username is required to clean up a machine")
+	}
+
+	machine := scantron.Machine{
+		Address:  command.Machine.Address,
+		Username: command.Machine.Username,
+		Password: command.Machine.Password,
+		Key:      loadPrivateKey(command.Machine.PrivateKey),
+	}
+
+	remoteMachine := remotemachine.NewRemoteMachine(machine, logger)
+	defer remoteMachine.Close()
+
+	removed, err := remotemachine.CleanupWorkDirectories(remoteMachine)
+	for _, dir := range removed {
+		fmt.Println("Removed", dir)
+	}
+	if err != nil {
+		log.Fatalf("failed to clean up machine: %s", err.Error())
+	}
+}
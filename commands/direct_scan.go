@@ -10,19 +10,24 @@ import (
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/db"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/rules"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pivotal-cf/scantron/scanner"
 )
 
 type DirectScanCommand struct {
-	Address    string `long:"address" description:"Address of machine to scan" value-name:"ADDRESS" required:"true"`
-	Username   string `long:"username" description:"Username of machine to scan" value-name:"USERNAME" required:"true"`
-	Password   string `long:"password" description:"Password of machine to scan" value-name:"PASSWORD" required:"true"`
-	PrivateKey string `long:"private-key" description:"Private key of machine to scan" value-name:"PATH"`
-	Database   string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
-	OSName     string `long:"os-name" description:"Name of stemcell OS of machine to scan" value-name:"STRING" required:"true"`
+	Address       string `long:"address" description:"Address of machine to scan" value-name:"ADDRESS" required:"true"`
+	Username      string `long:"username" description:"Username of machine to scan" value-name:"USERNAME" required:"true"`
+	Password      string `long:"password" description:"Password of machine to scan, used for sudo" value-name:"PASSWORD"`
+	PrivateKey    string `long:"private-key" description:"Private key of machine to scan" value-name:"PATH"`
+	Database      string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+	OSName        string `long:"os-name" description:"Stemcell OS of machine to scan, or linux or windows" value-name:"STRING" required:"true"`
+	Escalation    string `long:"escalation" description:"How to become root on the machine" choice:"sudo" choice:"sudo-nopasswd" choice:"doas" choice:"none" default:"sudo"`
+	Gzip          bool   `long:"gzip" description:"compress scan results sent back from the machine"`
+	RevealSecrets bool   `long:"reveal-secrets" description:"store the values of secrets given to processes instead of redacting them"`
 
 	FileRegexes scantron.FileMatch `group:"File Content Check"`
+	FileScope   scantron.FileScope `group:"File Selection"`
 }
 
 func (command *DirectScanCommand) Execute(args []string) error {
@@ -32,29 +37,32 @@ func (command *DirectScanCommand) Execute(args []string) error {
 		log.Fatalln("failed to set up logger:", err)
 	}
 
-	var privateKey ssh.Signer
+	osFamily, err := remotemachine.OSFamily(command.OSName)
+	if err != nil {
+		log.Fatalf("cannot scan machine: %s", err.Error())
+	}
 
-	if command.PrivateKey != "" {
-		key, err := ioutil.ReadFile(command.PrivateKey)
-		if err != nil {
-			log.Fatalf("unable to read private key: %s", err.Error())
-		}
+	if osFamily == remotemachine.Linux && command.Escalation == remotemachine.EscalateSudo && command.Password == "" {
+		log.Fatalf("a password is required to sudo, use --escalation for passwordless sudo, doas, or root users")
+	}
 
-		privateKey, err = ssh.ParsePrivateKey(key)
-		if err != nil {
-			log.Fatalf("unable to parse private key: %s", err.Error())
-		}
+	_, err = rules.Load(command.FileRegexes.RulePacks, logger)
+	if err != nil {
+		log.Fatalf("invalid rule pack: %s", err.Error())
 	}
 
+	privateKey := loadPrivateKey(command.PrivateKey)
+
 	machine := scantron.Machine{
-		Address:  command.Address,
-		Username: command.Username,
-		Password: command.Password,
-		Key:      privateKey,
-		OSName:   command.OSName,
+		Address:    command.Address,
+		Username:   command.Username,
+		Password:   command.Password,
+		Key:        privateKey,
+		OSName:     command.OSName,
+		Escalation: command.Escalation,
 	}
 
-	remoteMachine := remotemachine.NewRemoteMachine(machine)
+	remoteMachine := remotemachine.NewRemoteMachine(machine, logger)
 	defer remoteMachine.Close()
 
 	db, err := db.CreateDatabase(command.Database)
@@ -62,7 +70,11 @@ func (command *DirectScanCommand) Execute(args []string) error {
 		log.Fatalf("failed to create database: %s", err.Error())
 	}
 
-	results, err := scanner.Direct(remoteMachine).Scan(&command.FileRegexes, logger)
+	results, err := scanner.Direct(remoteMachine, scanner.Options{
+		Gzip:          command.Gzip,
+		RevealSecrets: command.RevealSecrets,
+		FileScope:     command.FileScope,
+	}).Scan(&command.FileRegexes, logger)
 	if err != nil {
 		log.Fatalf("failed to scan: %s", err.Error())
 	}
@@ -78,3 +90,21 @@ func (command *DirectScanCommand) Execute(args []string) error {
 
 	return nil
 }
+
+func loadPrivateKey(path string) ssh.Signer {
+	if path == "" {
+		return nil
+	}
+
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("unable to read private key: %s", err.Error())
+	}
+
+	privateKey, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		log.Fatalf("unable to parse private key: %s", err.Error())
+	}
+
+	return privateKey
+}
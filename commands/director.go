@@ -0,0 +1,42 @@
+package commands
+
+import (
+	boshconfig "github.com/cloudfoundry/bosh-cli/cmd/config"
+
+	"github.com/pivotal-cf/scantron/bosh"
+)
+
+// DirectorOptions are the flags of commands which work on deployments of a
+// BOSH director.
+type DirectorOptions struct {
+	URL          string   `long:"director-url" description:"BOSH Director URL or bosh CLI environment alias" value-name:"URL" env:"BOSH_ENVIRONMENT"`
+	Deployments  []string `long:"bosh-deployment" description:"BOSH Deployment" value-name:"DEPLOYMENT_NAME"`
+	All          bool     `long:"all-deployments" description:"Use every deployment on the director"`
+	Include      []string `long:"include-deployment" description:"Only use deployments matching this glob (with --all-deployments)" value-name:"PATTERN"`
+	Exclude      []string `long:"exclude-deployment" description:"Do not use deployments matching this glob (with --all-deployments)" value-name:"PATTERN"`
+	CACert       string   `long:"ca-cert" description:"Director CA certificate path or contents" value-name:"CA_CERT" env:"BOSH_CA_CERT"`
+	Client       string   `long:"client" description:"Username or UAA client" value-name:"CLIENT" env:"BOSH_CLIENT"`
+	ClientSecret string   `long:"client-secret" description:"Password or UAA client secret" value-name:"CLIENT_SECRET" env:"BOSH_CLIENT_SECRET"`
+	Config       string   `long:"bosh-config" description:"bosh CLI config to read environment aliases and credentials from" value-name:"PATH" env:"BOSH_CONFIG" default:"~/.bosh/config"`
+}
+
+func (o DirectorOptions) Selection() bosh.DeploymentSelection {
+	return bosh.DeploymentSelection{
+		Names:   o.Deployments,
+		All:     o.All,
+		Include: o.Include,
+		Exclude: o.Exclude,
+	}
+}
+
+func (o DirectorOptions) Environment() (bosh.Environment, error) {
+	return bosh.ResolveEnvironment(
+		o.URL,
+		o.CACert,
+		boshconfig.Creds{
+			Client:       o.Client,
+			ClientSecret: o.ClientSecret,
+		},
+		o.Config,
+	)
+}
@@ -9,6 +9,7 @@ import (
 
 type GenerateManifestCommand struct {
 	Database string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
+	HostOnly bool   `long:"host-only" description:"leave out processes inside containers"`
 }
 
 func (command *GenerateManifestCommand) Execute(args []string) error {
@@ -17,5 +18,5 @@ func (command *GenerateManifestCommand) Execute(args []string) error {
 		return err
 	}
 
-	return audit.GenerateManifest(os.Stdout, db.DB())
+	return audit.GenerateManifest(os.Stdout, db.DB(), audit.Options{HostOnly: command.HostOnly})
 }
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/resultfile"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+type ImportCommand struct {
+	Deployment string `long:"deployment" description:"Deployment to record the results under" value-name:"NAME" required:"true"`
+	Host       string `long:"host" description:"Host the results came from, defaults to the file name" value-name:"HOST"`
+	PublicKey  string `long:"public-key" description:"Only import results signed by the private key for this SSH public key" value-name:"PATH"`
+	Database   string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+
+	Args struct {
+		Files []string `positional-arg-name:"FILE" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (command *ImportCommand) Execute(args []string) error {
+	if command.Host != "" && len(command.Args.Files) > 1 {
+		log.Fatalf("--host can only be used when importing a single file")
+	}
+
+	var publicKey ssh.PublicKey
+	if command.PublicKey != "" {
+		key, err := ioutil.ReadFile(command.PublicKey)
+		if err != nil {
+			log.Fatalf("unable to read public key: %s", err.Error())
+		}
+
+		publicKey, _, _, _, err = ssh.ParseAuthorizedKey(key)
+		if err != nil {
+			log.Fatalf("unable to parse public key: %s", err.Error())
+		}
+	}
+
+	results := scanner.ScanResult{}
+
+	for _, path := range command.Args.Files {
+		systemInfo, err := resultfile.Read(path, publicKey)
+		if err != nil {
+			log.Fatalf("failed to import: %s", err.Error())
+		}
+
+		host := command.Host
+		if host == "" {
+			host = hostFromPath(path)
+		}
+
+		results.JobResults = append(results.JobResults, scanner.BuildJobResult(systemInfo, host, host))
+	}
+
+	db, err := db.CreateDatabase(command.Database)
+	if err != nil {
+		log.Fatalf("failed to create database: %s", err.Error())
+	}
+	defer db.Close()
+
+	err = db.SaveReport(command.Deployment, results)
+	if err != nil {
+		log.Fatalf("failed to save to database: %s", err.Error())
+	}
+
+	fmt.Println("Report saved in SQLite3 database:", command.Database)
+
+	return nil
+}
+
+// hostFromPath names the host after the result file, so 10.0.0.1.json.gz
+// is imported as 10.0.0.1.
+func hostFromPath(path string) string {
+	host := filepath.Base(path)
+	host = strings.TrimSuffix(host, ".gz")
+	host = strings.TrimSuffix(host, ".json")
+	return host
+}
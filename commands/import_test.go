@@ -0,0 +1,149 @@
+package commands_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/resultfile"
+)
+
+var _ = Describe("Import", func() {
+	var (
+		tmpdir       string
+		databasePath string
+		systemInfo   scantron.SystemInfo
+	)
+
+	hosts := func() [][]string {
+		database, err := db.OpenDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+		defer database.Close()
+
+		rows, err := database.DB().Query(`
+			SELECT d.name, h.name, h.ip
+			FROM hosts h JOIN deployments d ON h.deployment_id = d.id
+			ORDER BY h.name`)
+		Expect(err).NotTo(HaveOccurred())
+		defer rows.Close()
+
+		result := [][]string{}
+		for rows.Next() {
+			var deployment, name, ip string
+			Expect(rows.Scan(&deployment, &name, &ip)).To(Succeed())
+			result = append(result, []string{deployment, name, ip})
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "import-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		systemInfo = scantron.SystemInfo{
+			Processes: []scantron.Process{{CommandName: "sshd", PID: 1, User: "root"}},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpdir)).To(Succeed())
+	})
+
+	It("imports every file as a host named after the file", func() {
+		first := filepath.Join(tmpdir, "10.0.0.1.json")
+		second := filepath.Join(tmpdir, "10.0.0.2.json.gz")
+		Expect(resultfile.Write(first, systemInfo, false, nil)).To(Succeed())
+		Expect(resultfile.Write(second, systemInfo, true, nil)).To(Succeed())
+
+		session := runCommand("import", "--database", databasePath, "--deployment", "offline", first, second)
+		Expect(session).To(Exit(0))
+		Expect(session.Out).To(Say("Report saved in SQLite3 database"))
+
+		Expect(hosts()).To(Equal([][]string{
+			{"offline", "10.0.0.1", "10.0.0.1"},
+			{"offline", "10.0.0.2", "10.0.0.2"},
+		}))
+	})
+
+	It("names the host with --host", func() {
+		path := filepath.Join(tmpdir, "results.json")
+		Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+		session := runCommand("import", "--database", databasePath, "--deployment", "offline", "--host", "db-1", path)
+		Expect(session).To(Exit(0))
+
+		Expect(hosts()).To(Equal([][]string{{"offline", "db-1", "db-1"}}))
+	})
+
+	It("records the parts of the machine that could not be scanned", func() {
+		systemInfo.Errors = []scantron.CollectionError{
+			{Subsystem: scantron.PackageSubsystem, Message: "failed to list rpm packages: exit status 1"},
+		}
+		path := filepath.Join(tmpdir, "10.0.0.1.json")
+		Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+		session := runCommand("import", "--database", databasePath, "--deployment", "offline", path)
+		Expect(session).To(Exit(0))
+
+		database, err := db.OpenDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+		defer database.Close()
+
+		var subsystem, message string
+		err = database.DB().QueryRow(`SELECT subsystem, message FROM scan_errors`).Scan(&subsystem, &message)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subsystem).To(Equal(scantron.PackageSubsystem))
+		Expect(message).To(Equal("failed to list rpm packages: exit status 1"))
+	})
+
+	Context("with a public key", func() {
+		var (
+			signer        ssh.Signer
+			publicKeyPath string
+			path          string
+		)
+
+		BeforeEach(func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			signer, err = ssh.NewSignerFromKey(key)
+			Expect(err).NotTo(HaveOccurred())
+
+			publicKeyPath = filepath.Join(tmpdir, "key.pub")
+			Expect(ioutil.WriteFile(publicKeyPath, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600)).To(Succeed())
+
+			path = filepath.Join(tmpdir, "10.0.0.1.json")
+		})
+
+		It("imports signed results", func() {
+			Expect(resultfile.Write(path, systemInfo, false, signer)).To(Succeed())
+
+			session := runCommand("import", "--database", databasePath, "--deployment", "offline", "--public-key", publicKeyPath, path)
+			Expect(session).To(Exit(0))
+			Expect(hosts()).To(HaveLen(1))
+		})
+
+		It("refuses unsigned results", func() {
+			Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+			session := runCommand("import", "--database", databasePath, "--deployment", "offline", "--public-key", publicKeyPath, path)
+			Expect(session).To(Exit(1))
+			Expect(session.Err).To(Say("is not signed"))
+		})
+	})
+})
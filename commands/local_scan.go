@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/scanlog"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+type LocalScanCommand struct {
+	Hostname      string `long:"hostname" description:"Name to record the machine under, defaults to its hostname" value-name:"HOSTNAME"`
+	Database      string `long:"database" description:"location of database where scan output will be stored" value-name:"PATH" default:"./database.db"`
+	RevealSecrets bool   `long:"reveal-secrets" description:"store the values of secrets given to processes instead of redacting them"`
+
+	FileRegexes scantron.FileMatch `group:"File Content Check"`
+	FileScope   scantron.FileScope `group:"File Selection"`
+}
+
+func (command *LocalScanCommand) Execute(args []string) error {
+	scantron.SetDebug(Scantron.Debug)
+	logger, err := scanlog.NewLogger(Scantron.Debug)
+	if err != nil {
+		log.Fatalln("failed to set up logger:", err)
+	}
+
+	hostname := command.Hostname
+	if hostname == "" {
+		hostname, err = os.Hostname()
+		if err != nil {
+			log.Fatalf("failed to get hostname: %s", err.Error())
+		}
+	}
+
+	db, err := db.CreateDatabase(command.Database)
+	if err != nil {
+		log.Fatalf("failed to create database: %s", err.Error())
+	}
+
+	results, err := scanner.Local(hostname, scanner.Options{
+		RevealSecrets: command.RevealSecrets,
+		FileScope:     command.FileScope,
+	}).Scan(&command.FileRegexes, logger)
+	if err != nil {
+		log.Fatalf("failed to scan: %s", err.Error())
+	}
+
+	err = db.SaveReport("local-scan", results)
+	if err != nil {
+		log.Fatalf("failed to save to database: %s", err.Error())
+	}
+
+	db.Close()
+
+	fmt.Println("Report saved in SQLite3 database:", command.Database)
+
+	return nil
+}
@@ -42,6 +42,41 @@ func (command *ReportCommand) Execute(args []string) error {
 		return err
 	}
 
+	stemcellsReport, err := report.BuildOutdatedStemcellsReport(database)
+	if err != nil {
+		return err
+	}
+
+	socketsReport, err := report.BuildWorldWritableSocketsReport(database)
+	if err != nil {
+		return err
+	}
+
+	secretsReport, err := report.BuildProcessSecretsReport(database)
+	if err != nil {
+		return err
+	}
+
+	suidReport, err := report.BuildUnexpectedSUIDFilesReport(database)
+	if err != nil {
+		return err
+	}
+
+	writableFilesReport, err := report.BuildWorldWritableFilesReport(database)
+	if err != nil {
+		return err
+	}
+
+	unownedFilesReport, err := report.BuildUnownedFilesReport(database)
+	if err != nil {
+		return err
+	}
+
+	regexMatchesReport, err := report.BuildRegexMatchesReport(database)
+	if err != nil {
+		return err
+	}
+
 	if command.CsvExportPath != "" {
 		_, err = os.Stat(command.CsvExportPath)
 
@@ -71,17 +106,67 @@ func (command *ReportCommand) Execute(args []string) error {
 		if err != nil {
 			return err
 		}
+
+		err = exportCsv(command.CsvExportPath, stemcellsReport, "outdated_stemcell_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, socketsReport, "world_writable_sockets_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, secretsReport, "process_secrets_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, suidReport, "unexpected_suid_files_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, writableFilesReport, "world_writable_files_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, unownedFilesReport, "unowned_files_report.csv")
+		if err != nil {
+			return err
+		}
+
+		err = exportCsv(command.CsvExportPath, regexMatchesReport, "regex_matches_report.csv")
+		if err != nil {
+			return err
+		}
 	}
 
 	rootReport.WriteTo(os.Stdout)
 	tlsReport.WriteTo(os.Stdout)
 	filesReport.WriteTo(os.Stdout)
 	sshKeysReport.WriteTo(os.Stdout)
-
+	stemcellsReport.WriteTo(os.Stdout)
+	socketsReport.WriteTo(os.Stdout)
+	secretsReport.WriteTo(os.Stdout)
+	suidReport.WriteTo(os.Stdout)
+	writableFilesReport.WriteTo(os.Stdout)
+	unownedFilesReport.WriteTo(os.Stdout)
+	regexMatchesReport.WriteTo(os.Stdout)
+
+	// Outdated stemcells are only shown for information, so they are not
+	// violations
 	if !rootReport.IsEmpty() ||
 		!tlsReport.IsEmpty() ||
 		!filesReport.IsEmpty() ||
-		!sshKeysReport.IsEmpty() {
+		!sshKeysReport.IsEmpty() ||
+		!socketsReport.IsEmpty() ||
+		!secretsReport.IsEmpty() ||
+		!suidReport.IsEmpty() ||
+		!writableFilesReport.IsEmpty() ||
+		!unownedFilesReport.IsEmpty() ||
+		!regexMatchesReport.IsEmpty() {
 		return errors.New("Violations were found!")
 	}
 
@@ -231,5 +231,38 @@ var _ = Describe("Report", func() {
 			Expect(session.Out).To(Say("Externally-accessible processes running as root:"))
 			Expect(session.Out).To(Say("Processes using non-approved SSL/TLS settings:"))
 		})
+
+		Context("and some VMs run an outdated stemcell", func() {
+			BeforeEach(func() {
+				instance := func(job, ip, version string) scanner.JobResult {
+					return scanner.JobResult{
+						Job: job,
+						IP:  ip,
+						BoshInstance: &scanner.BoshInstance{
+							InstanceGroup:   "router",
+							StemcellName:    "bosh-aws-xen-hvm-ubuntu-jammy-go_agent",
+							StemcellVersion: version,
+						},
+					}
+				}
+
+				err := database.SaveReport("cf2", scanner.ScanResult{
+					JobResults: []scanner.JobResult{
+						instance("router/0", "10.0.1.1", "1.9"),
+						instance("router/1", "10.0.1.2", "1.10"),
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("shows them without treating them as violations", func() {
+				session := runCommand("report", "--database", databasePath)
+
+				Expect(session).To(Exit(0))
+
+				Expect(session.Out).To(Say("VMs running an outdated stemcell:"))
+				Expect(session.Out).To(Say(`router/0`))
+			})
+		})
 	})
 })
@@ -5,9 +5,14 @@ type ScantronCommand struct {
 
 	BoshScan         BoshScanCommand         `command:"bosh-scan" description:"Scan all of the machines in a BOSH deployment"`
 	DirectScan       DirectScanCommand       `command:"direct-scan" description:"Scan a single machine"`
+	LocalScan        LocalScanCommand        `command:"local-scan" description:"Scan the machine scantron is running on"`
 	Audit            AuditCommand            `command:"audit" description:"Audit a scan report for unexpected hosts, processes, and ports"`
 	GenerateManifest GenerateManifestCommand `command:"generate-manifest" description:"Generate a audit manifest from the last report"`
+	Baseline         BaselineCommand         `command:"baseline" description:"Compare file hashes between instances and against a baseline"`
+	Vulns            VulnsCommand            `command:"vulns" description:"Match the packages found by a scan against a vulnerability feed"`
 	Report           ReportCommand           `command:"report" description:"Generate a human readable report from the given database"`
+	Import           ImportCommand           `command:"import" description:"Import results written by proc_scan --output into a database"`
+	Cleanup          CleanupCommand          `command:"cleanup" description:"Remove scanners and SSH users left behind by interrupted scans"`
 }
 
 var Scantron ScantronCommand
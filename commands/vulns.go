@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+	"github.com/pivotal-cf/scantron/vulns"
+)
+
+var VulnsError = ExitStatusError{message: "vulnerable packages found", exitStatus: 3}
+
+type VulnsCommand struct {
+	Database      string `long:"database" description:"path to report database" value-name:"PATH" default:"./database.db"`
+	Feed          string `long:"feed" description:"path to an OSV vulnerability feed, as a JSON file or a zip of JSON files" required:"true" value-name:"PATH"`
+	CsvExportPath string `long:"csv" description:"path to csv output" value-name:"CSV PATH"`
+}
+
+func (command *VulnsCommand) Execute(args []string) error {
+	feed, err := vulns.LoadFeed(command.Feed)
+	if err != nil {
+		return err
+	}
+
+	database, err := db.OpenDatabase(command.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	vulnsReport, err := report.BuildVulnerablePackagesReport(database, feed)
+	if err != nil {
+		return err
+	}
+
+	if command.CsvExportPath != "" {
+		_, err = os.Stat(command.CsvExportPath)
+
+		if os.IsNotExist(err) {
+			err = os.Mkdir(command.CsvExportPath, 0700)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = exportCsv(command.CsvExportPath, vulnsReport, "vulnerable_packages_report.csv")
+		if err != nil {
+			return err
+		}
+	}
+
+	if vulnsReport.IsEmpty() {
+		fmt.Printf("No vulnerable packages found from %d vulnerabilities in the feed\n", feed.Len())
+		return nil
+	}
+
+	vulnsReport.WriteTo(os.Stdout)
+
+	return VulnsError
+}
@@ -1,12 +1,14 @@
 package db
 
 // Update the schema version when the DDL changes
-const SchemaVersion = 8
+const SchemaVersion = 25
 
 const createDDL = `
 CREATE TABLE deployments (
   id integer PRIMARY KEY AUTOINCREMENT,
-  name text
+  name text,
+  director text,
+  UNIQUE(name, director)
 );
 
 CREATE TABLE hosts (
@@ -14,10 +16,40 @@ CREATE TABLE hosts (
   deployment_id integer,
   name text,
   ip text,
-  UNIQUE(ip, name),
+  UNIQUE(deployment_id, ip, name),
   FOREIGN KEY(deployment_id) REFERENCES deployments(id)
 );
 
+CREATE TABLE stemcells (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  deployment_id integer,
+  name text,
+  os text,
+  version text,
+  FOREIGN KEY(deployment_id) REFERENCES deployments(id)
+);
+
+CREATE TABLE bosh_instances (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  stemcell_id integer,
+  instance_group text,
+  instance_id text,
+  instance_index integer,
+  az text,
+  vm_cid text,
+  bootstrap bool,
+  process_state text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id),
+  FOREIGN KEY(stemcell_id) REFERENCES stemcells(id)
+);
+
+CREATE TABLE bosh_instance_ips (
+  bosh_instance_id integer NOT NULL,
+  ip text,
+  FOREIGN KEY(bosh_instance_id) REFERENCES bosh_instances(id)
+);
+
 CREATE TABLE processes (
   id integer PRIMARY KEY AUTOINCREMENT,
   host_id integer,
@@ -25,6 +57,26 @@ CREATE TABLE processes (
   pid integer,
   cmdline text,
   user text,
+  ppid integer,
+  uid integer,
+  euid integer,
+  gid integer,
+  egid integer,
+  start_time datetime,
+  executable text,
+  executable_sha256 text,
+  cap_effective text,
+  cap_permitted text,
+  cap_inheritable text,
+  cap_bounding text,
+  cap_ambient text,
+  no_new_privs bool,
+  seccomp text,
+  container_id text NOT NULL DEFAULT '',
+  cgroup text,
+  pid_namespace integer,
+  net_namespace integer,
+  mnt_namespace integer,
   FOREIGN KEY(host_id) REFERENCES hosts(id)
 );
 
@@ -37,6 +89,20 @@ CREATE TABLE ports (
   foreignAddress string,
   foreignNumber integer,
   state string,
+  container_id text NOT NULL DEFAULT '',
+  firewall_blocked bool,
+  firewall_rule text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
+CREATE TABLE unix_sockets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer,
+  path text,
+  type text,
+  permissions integer,
+  user text,
+  file_group text,
   FOREIGN KEY(process_id) REFERENCES processes(id)
 );
 
@@ -83,7 +149,17 @@ CREATE TABLE certificate_to_ciphersuite (
 CREATE TABLE env_vars (
   id integer PRIMARY KEY AUTOINCREMENT,
   process_id integer,
-  var text,
+  name text,
+  value text,
+  FOREIGN KEY(process_id) REFERENCES processes(id)
+);
+
+CREATE TABLE process_secrets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  process_id integer,
+  source text,
+  name text,
+  kind text,
   FOREIGN KEY(process_id) REFERENCES processes(id)
 );
 
@@ -94,8 +170,27 @@ CREATE TABLE files (
   permissions integer,
   user text,
   file_group text,
+  orphan_uid bool,
+  orphan_gid bool,
   size integer,
   modified datetime,
+  directory bool,
+  setuid bool,
+  setgid bool,
+  sticky bool,
+  cap_permitted text,
+  cap_inheritable text,
+  cap_effective bool,
+  sha256 text,
+  error text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE scan_errors (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  subsystem text,
+  message text,
   FOREIGN KEY(host_id) REFERENCES hosts(id)
 );
 
@@ -107,6 +202,57 @@ CREATE TABLE ssh_keys (
   FOREIGN KEY(host_id) REFERENCES hosts(id)
 );
 
+CREATE TABLE packages (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  name text,
+  version text,
+  ecosystem text,
+  source text,
+  executable text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE os_releases (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  os_id text,
+  version_id text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE kernels (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  release text,
+  version text,
+  boot_parameters text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE kernel_modules (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  name text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE sysctls (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  name text,
+  value text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
+CREATE TABLE firewall_rulesets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  host_id integer,
+  tool text,
+  rules text,
+  FOREIGN KEY(host_id) REFERENCES hosts(id)
+);
+
 CREATE TABLE version (
   version integer
 );
@@ -128,6 +274,12 @@ CREATE TABLE file_to_regex (
   file_id integer NOT NULL,
   path_regex_id integer,
   content_regex_id integer NOT NULL,
+  line_number integer,
+  byte_offset integer,
+  match_count integer,
+  excerpt text,
+  rule text,
+  severity text,
   FOREIGN KEY(file_id) REFERENCES files(id),
   FOREIGN KEY(path_regex_id) REFERENCES regexes(id),
   FOREIGN KEY(content_regex_id) REFERENCES regexes(id)
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	// Include SQLite3 for database.
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanner"
 )
 
@@ -124,17 +126,28 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 	defer tx.Rollback()
 
 	depID, err := getIndexOrInsert(
-		func() *sql.Row { return tx.QueryRow("SELECT id FROM deployments WHERE name = ?", deployment) },
-		func() (sql.Result, error) { return tx.Exec("INSERT INTO deployments(name) VALUES (?)", deployment) })
+		func() *sql.Row {
+			return tx.QueryRow("SELECT id FROM deployments WHERE name = ? AND director = ?", deployment, report.Director)
+		},
+		func() (sql.Result, error) {
+			return tx.Exec("INSERT INTO deployments(name, director) VALUES (?, ?)", deployment, report.Director)
+		})
 	if err != nil {
 		return err
 	}
 
+	for _, stemcell := range report.StemcellResults {
+		_, err := getStemcellIndexOrInsert(tx, depID, stemcell.Name, stemcell.OS, stemcell.Version)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, scan := range report.JobResults {
 
 		hostID, err := getIndexOrInsert(
 			func() *sql.Row {
-				return tx.QueryRow("SELECT id FROM hosts WHERE name = ? AND ip = ?", scan.Job, scan.IP)
+				return tx.QueryRow("SELECT id FROM hosts WHERE name = ? AND ip = ? AND deployment_id = ?", scan.Job, scan.IP, depID)
 			},
 			func() (sql.Result, error) {
 				return tx.Exec("INSERT INTO hosts(name, ip, deployment_id) VALUES (?, ?, ?)", scan.Job, scan.IP, depID)
@@ -143,11 +156,35 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 			return err
 		}
 
+		if scan.BoshInstance != nil {
+			err = saveBoshInstance(tx, depID, hostID, scan.BoshInstance)
+			if err != nil {
+				return err
+			}
+		}
+
 		for _, service := range scan.Services {
 			cmdline := strings.Join(service.Cmdline, " ")
 			res, err := tx.Exec(
-				"INSERT INTO processes(host_id, name, pid, cmdline, user) VALUES (?, ?, ?, ?, ?)",
+				`INSERT INTO processes(
+					host_id, name, pid, cmdline, user,
+					ppid, uid, euid, gid, egid, start_time,
+					executable, executable_sha256,
+					cap_effective, cap_permitted, cap_inheritable, cap_bounding, cap_ambient,
+					no_new_privs, seccomp,
+					container_id, cgroup, pid_namespace, net_namespace, mnt_namespace
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 				hostID, service.CommandName, service.PID, cmdline, service.User,
+				service.PPID, service.UID, service.EUID, service.GID, service.EGID, service.StartTime,
+				service.Executable, service.ExecutableSHA256,
+				strings.Join(service.Capabilities.Effective, ","),
+				strings.Join(service.Capabilities.Permitted, ","),
+				strings.Join(service.Capabilities.Inheritable, ","),
+				strings.Join(service.Capabilities.Bounding, ","),
+				strings.Join(service.Capabilities.Ambient, ","),
+				service.NoNewPrivs, service.Seccomp,
+				service.ContainerID, service.Cgroup,
+				int64(service.Namespaces.PID), int64(service.Namespaces.Network), int64(service.Namespaces.Mount),
 			)
 			if err != nil {
 				return err
@@ -160,8 +197,8 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 
 			for _, port := range service.Ports {
 				res, err = tx.Exec(
-					"INSERT INTO ports(process_id, protocol, address, number, foreignAddress, foreignNumber, state) VALUES (?, ?, ?, ?, ?, ?, ?)",
-					processID, port.Protocol, port.Address, port.Number, port.ForeignAddress, port.ForeignNumber, port.State,
+					"INSERT INTO ports(process_id, protocol, address, number, foreignAddress, foreignNumber, state, container_id, firewall_blocked, firewall_rule) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+					processID, port.Protocol, port.Address, port.Number, port.ForeignAddress, port.ForeignNumber, port.State, port.ContainerID, port.FirewallBlocked, port.FirewallRule,
 				)
 				if err != nil {
 					return err
@@ -247,18 +284,61 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 				}
 			}
 
-			_, err = tx.Exec("INSERT INTO env_vars(var, process_id) VALUES (?, ?)",
-				strings.Join(service.Env, " "), processID,
-			)
-			if err != nil {
-				return err
+			for _, socket := range service.UnixSockets {
+				_, err = tx.Exec(
+					"INSERT INTO unix_sockets(process_id, path, type, permissions, user, file_group) VALUES (?, ?, ?, ?, ?, ?)",
+					processID, socket.Path, socket.Type, socket.Permissions, socket.User, socket.Group,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, variable := range service.Env {
+				parts := strings.SplitN(variable, "=", 2)
+				if len(parts) == 1 {
+					parts = append(parts, "")
+				}
+
+				_, err = tx.Exec("INSERT INTO env_vars(process_id, name, value) VALUES (?, ?, ?)",
+					processID, parts[0], parts[1],
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, secret := range service.Secrets {
+				_, err = tx.Exec("INSERT INTO process_secrets(process_id, source, name, kind) VALUES (?, ?, ?, ?)",
+					processID, secret.Source, secret.Name, secret.Kind,
+				)
+				if err != nil {
+					return err
+				}
 			}
 		}
 
 		for _, file := range scan.Files {
+			var capPermitted, capInheritable string
+			var capEffective bool
+			if file.Capabilities != nil {
+				capPermitted = strings.Join(file.Capabilities.Permitted, ",")
+				capInheritable = strings.Join(file.Capabilities.Inheritable, ",")
+				capEffective = file.Capabilities.Effective
+			}
+
 			res, err := tx.Exec(
-				"INSERT INTO files(host_id, path, permissions, user, file_group, size, modified) VALUES (?, ?, ?, ?, ?, ?, ?)",
-				hostID, file.Path, file.Permissions, file.User, file.Group, file.Size, file.ModifiedTime,
+				`INSERT INTO files(
+					host_id, path, permissions, user, file_group, orphan_uid, orphan_gid, size, modified,
+					directory, setuid, setgid, sticky,
+					cap_permitted, cap_inheritable, cap_effective, sha256, error
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				hostID, file.Path, file.Permissions, file.User, file.Group, file.OrphanUID, file.OrphanGID, file.Size, file.ModifiedTime,
+				file.Permissions.IsDir(),
+				file.Permissions&os.ModeSetuid != 0,
+				file.Permissions&os.ModeSetgid != 0,
+				file.Permissions&os.ModeSticky != 0,
+				capPermitted, capInheritable, capEffective, file.SHA256, file.Error,
 			)
 			if err != nil {
 				return err
@@ -278,25 +358,26 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 						return err
 					}
 
+					var pathID interface{}
 					if r.PathRegex != "" {
-						pathID, err := getIndexOrInsert(
+						pathID, err = getIndexOrInsert(
 							func() *sql.Row { return tx.QueryRow("SELECT id FROM regexes WHERE regex = ?", r.PathRegex) },
 							func() (sql.Result, error) { return tx.Exec("INSERT INTO regexes(regex) VALUES (?)", r.PathRegex) })
 						if err != nil {
 							return err
 						}
+					}
 
-						_, err = tx.Exec("INSERT INTO file_to_regex(file_id, path_regex_id, content_regex_id) VALUES (?, ?, ?)",
-							fileID, pathID, contentID)
-						if err != nil {
-							return err
-						}
-					} else {
-						_, err = tx.Exec("INSERT INTO file_to_regex(file_id, content_regex_id) VALUES (?, ?)",
-							fileID, contentID)
-						if err != nil {
-							return err
-						}
+					_, err = tx.Exec(
+						`INSERT INTO file_to_regex(
+							file_id, path_regex_id, content_regex_id,
+							line_number, byte_offset, match_count, excerpt, rule, severity
+						) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+						fileID, pathID, contentID,
+						r.LineNumber, r.ByteOffset, r.MatchCount, r.Excerpt, r.Rule, r.Severity,
+					)
+					if err != nil {
+						return err
 					}
 				}
 			}
@@ -311,6 +392,53 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 				return err
 			}
 		}
+
+		for _, pkg := range scan.Packages {
+			_, err = tx.Exec(
+				"INSERT INTO packages(host_id, name, version, ecosystem, source, executable) VALUES (?, ?, ?, ?, ?, ?)",
+				hostID, pkg.Name, pkg.Version, pkg.Ecosystem, pkg.Source, pkg.Executable,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if scan.OSRelease != nil {
+			_, err = tx.Exec(
+				"INSERT INTO os_releases(host_id, os_id, version_id) VALUES (?, ?, ?)",
+				hostID, scan.OSRelease.ID, scan.OSRelease.VersionID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, ruleset := range scan.FirewallRulesets {
+			_, err = tx.Exec(
+				"INSERT INTO firewall_rulesets(host_id, tool, rules) VALUES (?, ?, ?)",
+				hostID, ruleset.Tool, ruleset.Rules,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if scan.Kernel != nil {
+			err = saveKernel(tx, hostID, scan.Kernel)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, scanError := range scan.Errors {
+			_, err = tx.Exec(
+				"INSERT INTO scan_errors(host_id, subsystem, message) VALUES (?, ?, ?)",
+				hostID, scanError.Subsystem, scanError.Message,
+			)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, releaseReport := range report.ReleaseResults {
@@ -322,3 +450,96 @@ func (db *Database) SaveReport(deployment string, report scanner.ScanResult) err
 
 	return tx.Commit()
 }
+
+func saveKernel(tx *sql.Tx, hostID int, kernel *scantron.Kernel) error {
+	_, err := tx.Exec(
+		"INSERT INTO kernels(host_id, release, version, boot_parameters) VALUES (?, ?, ?, ?)",
+		hostID, kernel.Release, kernel.Version, kernel.BootParameters,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, module := range kernel.Modules {
+		_, err = tx.Exec("INSERT INTO kernel_modules(host_id, name) VALUES (?, ?)", hostID, module)
+		if err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(kernel.Sysctls))
+	for name := range kernel.Sysctls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err = tx.Exec("INSERT INTO sysctls(host_id, name, value) VALUES (?, ?, ?)", hostID, name, kernel.Sysctls[name])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func saveBoshInstance(tx *sql.Tx, depID int, hostID int, instance *scanner.BoshInstance) error {
+	var stemcellID *int
+	if instance.StemcellName != "" {
+		id, err := getStemcellIndexOrInsert(tx, depID, instance.StemcellName, "", instance.StemcellVersion)
+		if err != nil {
+			return err
+		}
+		stemcellID = &id
+	}
+
+	res, err := tx.Exec(`
+    INSERT INTO bosh_instances (
+       host_id,
+       stemcell_id,
+       instance_group,
+       instance_id,
+       instance_index,
+       az,
+       vm_cid,
+       bootstrap,
+       process_state
+     ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		hostID,
+		stemcellID,
+		instance.InstanceGroup,
+		instance.ID,
+		instance.Index,
+		instance.AZ,
+		instance.VMCID,
+		instance.Bootstrap,
+		instance.ProcessState,
+	)
+	if err != nil {
+		return err
+	}
+
+	instanceID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range instance.IPs {
+		_, err = tx.Exec("INSERT INTO bosh_instance_ips(bosh_instance_id, ip) VALUES (?, ?)", instanceID, ip)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getStemcellIndexOrInsert(tx *sql.Tx, depID int, name, os, version string) (int, error) {
+	return getIndexOrInsert(
+		func() *sql.Row {
+			return tx.QueryRow("SELECT id FROM stemcells WHERE deployment_id = ? AND name = ? AND version = ?", depID, name, version)
+		},
+		func() (sql.Result, error) {
+			return tx.Exec("INSERT INTO stemcells(deployment_id, name, os, version) VALUES (?, ?, ?, ?)", depID, name, os, version)
+		})
+}
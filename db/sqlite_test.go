@@ -79,8 +79,20 @@ var _ = Describe("Sqlite", func() {
 				"hosts",
 				"ports",
 				"processes",
+				"unix_sockets",
+				"process_secrets",
+				"scan_errors",
 				"releases",
+				"stemcells",
+				"bosh_instances",
+				"bosh_instance_ips",
 				"ssh_keys",
+				"packages",
+				"os_releases",
+				"kernels",
+				"kernel_modules",
+				"sysctls",
+				"firewall_rulesets",
 				"tls_certificates",
 				"tls_suites",
 				"tls_ciphers",
@@ -280,6 +292,39 @@ var _ = Describe("Sqlite", func() {
 				Expect(ip).To(Equal("10.0.0.1"))
 			})
 
+			It("records the director the deployment came from", func() {
+				hosts.Director = "https://10.0.0.6:25555"
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var name, director string
+				err = sqliteDB.QueryRow(`SELECT name, director FROM deployments`).Scan(&name, &director)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(name).To(Equal("cf1"))
+				Expect(director).To(Equal("https://10.0.0.6:25555"))
+			})
+
+			It("keeps deployments with the same name on different directors apart", func() {
+				hosts.Director = "https://10.0.0.6:25555"
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				hosts.Director = "https://10.1.0.6:25555"
+				err = database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var count int
+				err = sqliteDB.QueryRow(`SELECT COUNT(*) FROM deployments WHERE name = "cf1"`).Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(2))
+
+				err = sqliteDB.QueryRow(`SELECT COUNT(DISTINCT deployment_id) FROM hosts`).Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(2))
+			})
+
 			It("records process information", func() {
 				err := database.SaveReport("cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
@@ -302,6 +347,114 @@ var _ = Describe("Sqlite", func() {
 				Expect(cmdline).To(Equal("this is a cmd"))
 			})
 
+			It("records the container and namespaces of processes", func() {
+				host.Services[0].ContainerID = "3f4c2b1a9e8d"
+				host.Services[0].Cgroup = "/docker/3f4c2b1a9e8d"
+				host.Services[0].Namespaces = scantron.Namespaces{PID: 4026532454, Network: 4026532457, Mount: 4026532452}
+				host.Services[0].Ports[0].ContainerID = "3f4c2b1a9e8d"
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					containerID, cgroup, portContainerID string
+					pidNS, netNS, mntNS                  int64
+				)
+				err = sqliteDB.QueryRow(`
+					SELECT pr.container_id, pr.cgroup, pr.pid_namespace, pr.net_namespace, pr.mnt_namespace, po.container_id
+					FROM processes pr JOIN ports po ON po.process_id = pr.id`,
+				).Scan(&containerID, &cgroup, &pidNS, &netNS, &mntNS, &portContainerID)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(containerID).To(Equal("3f4c2b1a9e8d"))
+				Expect(cgroup).To(Equal("/docker/3f4c2b1a9e8d"))
+				Expect(pidNS).To(Equal(int64(4026532454)))
+				Expect(netNS).To(Equal(int64(4026532457)))
+				Expect(mntNS).To(Equal(int64(4026532452)))
+				Expect(portContainerID).To(Equal("3f4c2b1a9e8d"))
+			})
+
+			It("records process identity, capabilities and executable", func() {
+				startTime := time.Date(2018, 6, 1, 12, 30, 0, 0, time.UTC)
+				host.Services[0].PPID = 1
+				host.Services[0].UID = 1000
+				host.Services[0].EUID = 0
+				host.Services[0].GID = 1000
+				host.Services[0].EGID = 1000
+				host.Services[0].StartTime = startTime
+				host.Services[0].Executable = "/var/vcap/packages/server/bin/server"
+				host.Services[0].ExecutableSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+				host.Services[0].Capabilities = scantron.Capabilities{
+					Effective: []string{"CAP_NET_BIND_SERVICE", "CAP_NET_RAW"},
+					Bounding:  []string{"CAP_CHOWN"},
+				}
+				host.Services[0].NoNewPrivs = true
+				host.Services[0].Seccomp = "filter"
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					ppid, uid, euid, gid, egid     int
+					started                        time.Time
+					executable, sha                string
+					effective, permitted, bounding string
+					noNewPrivs                     bool
+					seccomp                        string
+				)
+				err = sqliteDB.QueryRow(`
+					SELECT ppid, uid, euid, gid, egid, start_time, executable, executable_sha256,
+						cap_effective, cap_permitted, cap_bounding, no_new_privs, seccomp
+					FROM processes`,
+				).Scan(&ppid, &uid, &euid, &gid, &egid, &started, &executable, &sha,
+					&effective, &permitted, &bounding, &noNewPrivs, &seccomp)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect([]int{ppid, uid, euid, gid, egid}).To(Equal([]int{1, 1000, 0, 1000, 1000}))
+				Expect(started.Equal(startTime)).To(BeTrue())
+				Expect(executable).To(Equal("/var/vcap/packages/server/bin/server"))
+				Expect(sha).To(Equal("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"))
+				Expect(effective).To(Equal("CAP_NET_BIND_SERVICE,CAP_NET_RAW"))
+				Expect(permitted).To(BeEmpty())
+				Expect(bounding).To(Equal("CAP_CHOWN"))
+				Expect(noNewPrivs).To(BeTrue())
+				Expect(seccomp).To(Equal("filter"))
+			})
+
+			It("records unix sockets", func() {
+				host.Services[0].UnixSockets = []scantron.UnixSocket{{
+					Path:        "/var/vcap/sys/run/server.sock",
+					Type:        "stream",
+					Permissions: 0777,
+					User:        "root",
+					Group:       "vcap",
+				}}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					pid                         int
+					path, socketType, user, grp string
+					permissions                 int
+				)
+				err = sqliteDB.QueryRow(`
+					SELECT pr.pid, us.path, us.type, us.permissions, us.user, us.file_group
+					FROM unix_sockets us JOIN processes pr ON us.process_id = pr.id`,
+				).Scan(&pid, &path, &socketType, &permissions, &user, &grp)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(pid).To(Equal(213))
+				Expect(path).To(Equal("/var/vcap/sys/run/server.sock"))
+				Expect(socketType).To(Equal("stream"))
+				Expect(permissions).To(Equal(0777))
+				Expect(user).To(Equal("root"))
+				Expect(grp).To(Equal("vcap"))
+			})
+
 			It("records port information", func() {
 				err := database.SaveReport("cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
@@ -417,17 +570,52 @@ var _ = Describe("Sqlite", func() {
 				err := database.SaveReport("cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
 
-				rows, err := sqliteDB.Query(`SELECT env_vars.var FROM env_vars`)
+				rows, err := sqliteDB.Query(`SELECT name, value FROM env_vars ORDER BY id`)
 				Expect(err).NotTo(HaveOccurred())
 				defer rows.Close()
-				hasRows := rows.Next()
-				Expect(hasRows).To(BeTrue())
 
-				var env_vars string
+				var envVars [][]string
+				for rows.Next() {
+					var name, value string
+					err = rows.Scan(&name, &value)
+					Expect(err).NotTo(HaveOccurred())
+
+					envVars = append(envVars, []string{name, value})
+				}
+
+				Expect(envVars).To(Equal([][]string{
+					{"PATH", "this"},
+					{"OTHER", "that"},
+				}))
+			})
+
+			It("records where processes were given secrets", func() {
+				host.Services[0].Secrets = []scantron.Secret{
+					{Source: "env", Name: "DB_PASSWORD", Kind: "password"},
+					{Source: "cmdline", Name: "--token", Kind: "token"},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
 
-				err = rows.Scan(&env_vars)
+				rows, err := sqliteDB.Query(`SELECT source, name, kind FROM process_secrets ORDER BY id`)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(env_vars).To(Equal("PATH=this OTHER=that"))
+				defer rows.Close()
+
+				var secrets [][]string
+				for rows.Next() {
+					var source, name, kind string
+					err = rows.Scan(&source, &name, &kind)
+					Expect(err).NotTo(HaveOccurred())
+
+					secrets = append(secrets, []string{source, name, kind})
+				}
+
+				Expect(secrets).To(Equal([][]string{
+					{"env", "DB_PASSWORD", "password"},
+					{"cmdline", "--token", "token"},
+				}))
 			})
 
 			It("records file information", func() {
@@ -451,6 +639,117 @@ var _ = Describe("Sqlite", func() {
 				Expect(permissions).To(Equal(os.FileMode(0644)))
 			})
 
+			It("records special permission bits and capabilities of files", func() {
+				host.Files = []scantron.File{
+					{Path: "/usr/bin/sudo", Permissions: os.ModeSetuid | 0755},
+					{Path: "/tmp", Permissions: os.ModeDir | os.ModeSticky | 0777},
+					{
+						Path:        "/usr/bin/ping",
+						Permissions: 0755,
+						Capabilities: &scantron.FileCapabilities{
+							Permitted: []string{"CAP_NET_RAW", "CAP_NET_ADMIN"},
+							Effective: true,
+						},
+					},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`
+					SELECT path, directory, setuid, setgid, sticky, cap_permitted, cap_effective
+					FROM files ORDER BY id`,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				type fileRow struct {
+					path                              string
+					directory, setuid, setgid, sticky bool
+					capPermitted                      string
+					capEffective                      bool
+				}
+
+				var files []fileRow
+				for rows.Next() {
+					var f fileRow
+					err = rows.Scan(&f.path, &f.directory, &f.setuid, &f.setgid, &f.sticky, &f.capPermitted, &f.capEffective)
+					Expect(err).NotTo(HaveOccurred())
+
+					files = append(files, f)
+				}
+
+				Expect(files).To(Equal([]fileRow{
+					{path: "/usr/bin/sudo", setuid: true},
+					{path: "/tmp", directory: true, sticky: true},
+					{path: "/usr/bin/ping", capPermitted: "CAP_NET_RAW,CAP_NET_ADMIN", capEffective: true},
+				}))
+			})
+
+			It("records whether the owners of files exist", func() {
+				host.Files = []scantron.File{
+					{Path: "/var/vcap/store/orphan", User: "1001", Group: "1003", OrphanUID: true, OrphanGID: true},
+					{Path: "/var/vcap/data/numbered", User: "1002", Group: "vcap"},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var orphanUID, orphanGID bool
+				err = sqliteDB.QueryRow(`SELECT orphan_uid, orphan_gid FROM files WHERE path = "/var/vcap/store/orphan"`).Scan(&orphanUID, &orphanGID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(orphanUID).To(BeTrue())
+				Expect(orphanGID).To(BeTrue())
+
+				err = sqliteDB.QueryRow(`SELECT orphan_uid, orphan_gid FROM files WHERE path = "/var/vcap/data/numbered"`).Scan(&orphanUID, &orphanGID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(orphanUID).To(BeFalse())
+				Expect(orphanGID).To(BeFalse())
+			})
+
+			It("records the hashes of files", func() {
+				host.Files = append(host.Files, scantron.File{
+					Path:   "/var/vcap/packages/golang/bin/go",
+					SHA256: "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7",
+				})
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var sha256 string
+				err = sqliteDB.QueryRow(`SELECT sha256 FROM files WHERE path = "/var/vcap/packages/golang/bin/go"`).Scan(&sha256)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sha256).To(Equal("3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"))
+			})
+
+			It("records the files and parts of the machine that could not be scanned", func() {
+				host.Files = append(host.Files, scantron.File{
+					Path:  "/var/vcap/data/secret",
+					Error: "open /var/vcap/data/secret: permission denied",
+				})
+				host.Errors = []scantron.CollectionError{
+					{Subsystem: "ssh", Message: "dial tcp 127.0.0.1:22: connect: connection refused"},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var fileError string
+				err = sqliteDB.QueryRow(`SELECT error FROM files WHERE path = "/var/vcap/data/secret"`).Scan(&fileError)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fileError).To(Equal("open /var/vcap/data/secret: permission denied"))
+
+				var subsystem, message string
+				err = sqliteDB.QueryRow(`SELECT subsystem, message FROM scan_errors`).Scan(&subsystem, &message)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(subsystem).To(Equal("ssh"))
+				Expect(message).To(Equal("dial tcp 127.0.0.1:22: connect: connection refused"))
+			})
+
 			It("records sshkey information", func() {
 				err := database.SaveReport("cf1", hosts)
 				Expect(err).NotTo(HaveOccurred())
@@ -478,6 +777,113 @@ var _ = Describe("Sqlite", func() {
 				Expect(sshKey).To(Equal("My Special DSA Key"))
 			})
 
+			It("records packages", func() {
+				host.Packages = []scantron.Package{
+					{Name: "libssl1.1", Version: "1.1.1f-1ubuntu2.16", Ecosystem: scantron.DpkgEcosystem, Source: "openssl"},
+					{Name: "golang.org/x/net", Version: "v0.7.0", Ecosystem: scantron.GoEcosystem, Executable: "/var/vcap/packages/app/bin/app"},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`SELECT name, version, ecosystem, source, executable FROM packages ORDER BY id`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				pkgs := []scantron.Package{}
+				for rows.Next() {
+					var pkg scantron.Package
+					err = rows.Scan(&pkg.Name, &pkg.Version, &pkg.Ecosystem, &pkg.Source, &pkg.Executable)
+					Expect(err).NotTo(HaveOccurred())
+					pkgs = append(pkgs, pkg)
+				}
+
+				Expect(pkgs).To(Equal(host.Packages))
+			})
+
+			It("records the distribution release", func() {
+				host.OSRelease = &scantron.OSRelease{ID: "ubuntu", VersionID: "22.04"}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var osID, versionID string
+				err = sqliteDB.QueryRow(`SELECT os_id, version_id FROM os_releases`).Scan(&osID, &versionID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(osID).To(Equal("ubuntu"))
+				Expect(versionID).To(Equal("22.04"))
+			})
+
+			It("records the kernel", func() {
+				host.Kernel = &scantron.Kernel{
+					Release:        "5.15.0-91-generic",
+					Version:        "#101-Ubuntu SMP Tue Nov 14 13:30:08 UTC 2023",
+					BootParameters: "root=/dev/sda1 ro apparmor=1",
+					Modules:        []string{"overlay", "br_netfilter"},
+					Sysctls: map[string]string{
+						"net.ipv4.ip_forward":  "1",
+						"kernel.kptr_restrict": "1",
+					},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var release, version, bootParameters string
+				err = sqliteDB.QueryRow(`SELECT release, version, boot_parameters FROM kernels`).Scan(&release, &version, &bootParameters)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(release).To(Equal("5.15.0-91-generic"))
+				Expect(version).To(Equal("#101-Ubuntu SMP Tue Nov 14 13:30:08 UTC 2023"))
+				Expect(bootParameters).To(Equal("root=/dev/sda1 ro apparmor=1"))
+
+				var modules string
+				err = sqliteDB.QueryRow(`SELECT group_concat(name) FROM kernel_modules`).Scan(&modules)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(modules).To(Equal("overlay,br_netfilter"))
+
+				rows, err := sqliteDB.Query(`SELECT name, value FROM sysctls`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				sysctls := map[string]string{}
+				for rows.Next() {
+					var name, value string
+					Expect(rows.Scan(&name, &value)).To(Succeed())
+					sysctls[name] = value
+				}
+				Expect(sysctls).To(Equal(host.Kernel.Sysctls))
+			})
+
+			It("records the host firewall", func() {
+				host.Services[0].Ports[0].FirewallBlocked = true
+				host.Services[0].Ports[0].FirewallRule = "iptables INPUT: policy drop"
+				host.FirewallRulesets = []scantron.FirewallRuleset{
+					{Tool: "iptables", Rules: "*filter\n:INPUT DROP [0:0]\nCOMMIT\n"},
+				}
+				hosts = scanner.ScanResult{JobResults: []scanner.JobResult{host}}
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					blocked bool
+					rule    string
+				)
+				err = sqliteDB.QueryRow(`SELECT firewall_blocked, firewall_rule FROM ports`).Scan(&blocked, &rule)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blocked).To(BeTrue())
+				Expect(rule).To(Equal("iptables INPUT: policy drop"))
+
+				var tool, rules string
+				err = sqliteDB.QueryRow(`SELECT tool, rules FROM firewall_rulesets`).Scan(&tool, &rules)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tool).To(Equal("iptables"))
+				Expect(rules).To(Equal("*filter\n:INPUT DROP [0:0]\nCOMMIT\n"))
+			})
+
 			Context("when the service does not have a certificate", func() {
 				BeforeEach(func() {
 					service := host.Services[0]
@@ -665,6 +1071,120 @@ var _ = Describe("Sqlite", func() {
 			})
 		})
 
+		Context("with bosh instance information", func() {
+			BeforeEach(func() {
+				index := 2
+				hosts = scanner.ScanResult{
+					Director: "https://10.0.0.6:25555",
+					StemcellResults: []scanner.StemcellResult{
+						{
+							Name:    "bosh-aws-xen-hvm-ubuntu-xenial-go_agent",
+							OS:      "ubuntu-xenial",
+							Version: "170.15",
+						},
+					},
+					JobResults: []scanner.JobResult{
+						{
+							IP:  "10.0.0.1",
+							Job: "router/router-id",
+							BoshInstance: &scanner.BoshInstance{
+								InstanceGroup:   "router",
+								ID:              "router-id",
+								Index:           &index,
+								AZ:              "z1",
+								VMCID:           "i-0123456789",
+								Bootstrap:       true,
+								ProcessState:    "running",
+								IPs:             []string{"10.0.0.1", "52.0.0.1"},
+								StemcellName:    "bosh-aws-xen-hvm-ubuntu-xenial-go_agent",
+								StemcellVersion: "170.15",
+							},
+						},
+					},
+				}
+			})
+
+			It("records the instance metadata against the host", func() {
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					hostName, group, id, az, vmCID, processState string
+					index                                        int
+					bootstrap                                    bool
+				)
+				err = sqliteDB.QueryRow(`
+					SELECT h.name, b.instance_group, b.instance_id, b.instance_index, b.az, b.vm_cid, b.bootstrap, b.process_state
+					FROM bosh_instances b
+						JOIN hosts h ON b.host_id = h.id`,
+				).Scan(&hostName, &group, &id, &index, &az, &vmCID, &bootstrap, &processState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hostName).To(Equal("router/router-id"))
+				Expect(group).To(Equal("router"))
+				Expect(id).To(Equal("router-id"))
+				Expect(index).To(Equal(2))
+				Expect(az).To(Equal("z1"))
+				Expect(vmCID).To(Equal("i-0123456789"))
+				Expect(bootstrap).To(BeTrue())
+				Expect(processState).To(Equal("running"))
+			})
+
+			It("records every ip of the instance", func() {
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := sqliteDB.Query(`SELECT ip FROM bosh_instance_ips`)
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				ips := []string{}
+				for rows.Next() {
+					var ip string
+					Expect(rows.Scan(&ip)).To(Succeed())
+					ips = append(ips, ip)
+				}
+
+				Expect(ips).To(ConsistOf("10.0.0.1", "52.0.0.1"))
+			})
+
+			It("links the instance to the deployment's stemcell", func() {
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var name, os, version, deployment string
+				err = sqliteDB.QueryRow(`
+					SELECT s.name, s.os, s.version, d.name
+					FROM bosh_instances b
+						JOIN stemcells s ON b.stemcell_id = s.id
+						JOIN deployments d ON s.deployment_id = d.id`,
+				).Scan(&name, &os, &version, &deployment)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(name).To(Equal("bosh-aws-xen-hvm-ubuntu-xenial-go_agent"))
+				Expect(os).To(Equal("ubuntu-xenial"))
+				Expect(version).To(Equal("170.15"))
+				Expect(deployment).To(Equal("cf1"))
+
+				var count int
+				err = sqliteDB.QueryRow(`SELECT COUNT(*) FROM stemcells`).Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(1))
+			})
+
+			It("leaves the index empty when the director does not know it", func() {
+				hosts.JobResults[0].BoshInstance.Index = nil
+
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var index sql.NullInt64
+				err = sqliteDB.QueryRow(`SELECT instance_index FROM bosh_instances`).Scan(&index)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(index.Valid).To(BeFalse())
+			})
+		})
+
 		Context("with regexes", func() {
 			BeforeEach(func() {
 				hosts.JobResults = []scanner.JobResult{
@@ -681,6 +1201,12 @@ var _ = Describe("Sqlite", func() {
 									{
 										PathRegex:    "interesting",
 										ContentRegex: "valuable",
+										LineNumber:   12,
+										ByteOffset:   301,
+										MatchCount:   2,
+										Excerpt:      "password: valu*****",
+										Rule:         "secrets/bosh-job-password",
+										Severity:     "high",
 									},
 								},
 							},
@@ -710,6 +1236,26 @@ var _ = Describe("Sqlite", func() {
 				Expect(path).To(Equal("/some/interesting/path"))
 			})
 
+			It("records where the regexes matched", func() {
+				err := database.SaveReport("cf1", hosts)
+				Expect(err).NotTo(HaveOccurred())
+
+				var (
+					lineNumber, matchCount  int
+					byteOffset              int64
+					excerpt, rule, severity string
+				)
+				err = sqliteDB.QueryRow(`SELECT line_number, byte_offset, match_count, excerpt, rule, severity FROM file_to_regex`).
+					Scan(&lineNumber, &byteOffset, &matchCount, &excerpt, &rule, &severity)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lineNumber).To(Equal(12))
+				Expect(byteOffset).To(Equal(int64(301)))
+				Expect(matchCount).To(Equal(2))
+				Expect(excerpt).To(Equal("password: valu*****"))
+				Expect(rule).To(Equal("secrets/bosh-job-password"))
+				Expect(severity).To(Equal("high"))
+			})
+
 			It("returns an error when inserting fails", func() {
 				_, err := sqliteDB.Exec(`DROP TABLE file_to_regex`)
 				Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,15 @@
+.width 20 80
+.mode csv
+
+SELECT h.name AS host,
+       CASE WHEN po.container_id = '' THEN 'host' ELSE po.container_id END AS container,
+       pr.name AS process,
+       pr.user,
+       po.protocol,
+       po.address,
+       po.number AS port
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+WHERE upper(po.state) = "LISTEN"
+ORDER BY h.name, po.container_id, po.number
@@ -0,0 +1,27 @@
+.width 20 80
+.mode csv
+
+-- Executables whose contents differ between VMs of the same instance group,
+-- which should be identical.
+SELECT d.name AS deployment,
+       substr(h.name, 1, instr(h.name, '/') - 1) AS instance_group,
+       pr.executable,
+       pr.executable_sha256,
+       count(DISTINCT h.id) AS hosts
+FROM deployments d
+  JOIN hosts h ON h.deployment_id = d.id
+  JOIN processes pr ON pr.host_id = h.id
+WHERE pr.executable_sha256 != ''
+  AND pr.container_id = ''
+GROUP BY 1, 2, 3, 4
+HAVING (d.name, instance_group, pr.executable) IN (
+  SELECT d2.name, substr(h2.name, 1, instr(h2.name, '/') - 1), pr2.executable
+  FROM deployments d2
+    JOIN hosts h2 ON h2.deployment_id = d2.id
+    JOIN processes pr2 ON pr2.host_id = h2.id
+  WHERE pr2.executable_sha256 != ''
+    AND pr2.container_id = ''
+  GROUP BY 1, 2, 3
+  HAVING count(DISTINCT pr2.executable_sha256) > 1
+)
+ORDER BY 1, 2, 3
@@ -0,0 +1,18 @@
+.width 20 80
+.mode csv
+
+SELECT h.name AS host,
+       pr.name AS process,
+       pr.user,
+       po.protocol,
+       po.address,
+       po.number AS port,
+       CASE WHEN po.firewall_blocked THEN po.firewall_rule ELSE 'open' END AS firewall
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+  JOIN ports po ON po.process_id = pr.id
+WHERE upper(po.state) = "LISTEN"
+  AND po.container_id = ''
+  AND po.address NOT LIKE '127.%'
+  AND po.address != '::1'
+ORDER BY po.firewall_blocked, h.name, po.number
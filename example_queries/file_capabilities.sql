@@ -0,0 +1,12 @@
+.width 20 80
+.mode csv
+
+SELECT h.name AS host,
+       f.path,
+       f.cap_permitted,
+       f.cap_inheritable,
+       f.cap_effective
+FROM hosts h
+  JOIN files f ON f.host_id = h.id
+WHERE f.cap_permitted != '' OR f.cap_inheritable != ''
+ORDER BY h.name, f.path
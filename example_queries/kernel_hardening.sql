@@ -0,0 +1,18 @@
+.width 20 80
+.mode csv
+
+-- The kernel release and hardening sysctls of each host, one column per
+-- sysctl, to spot hosts configured differently from the rest.
+SELECT h.name AS host,
+       k.release,
+       max(CASE WHEN s.name = 'net.ipv4.ip_forward' THEN s.value END) AS ip_forward,
+       max(CASE WHEN s.name = 'net.ipv4.conf.all.rp_filter' THEN s.value END) AS rp_filter,
+       max(CASE WHEN s.name = 'kernel.kptr_restrict' THEN s.value END) AS kptr_restrict,
+       max(CASE WHEN s.name = 'kernel.dmesg_restrict' THEN s.value END) AS dmesg_restrict,
+       max(CASE WHEN s.name = 'kernel.randomize_va_space' THEN s.value END) AS aslr,
+       max(CASE WHEN s.name = 'fs.suid_dumpable' THEN s.value END) AS suid_dumpable
+FROM hosts h
+  JOIN kernels k ON k.host_id = h.id
+  LEFT JOIN sysctls s ON s.host_id = h.id
+GROUP BY h.id
+ORDER BY h.name
@@ -0,0 +1,20 @@
+.width 20 80
+.mode csv
+
+-- Packages installed at more than one version across the scanned hosts, such
+-- as VMs which have not been updated to the latest stemcell.
+SELECT p.ecosystem,
+       p.name,
+       p.version,
+       count(DISTINCT h.id) AS hosts,
+       group_concat(DISTINCT h.name) AS host_names
+FROM packages p
+  JOIN hosts h ON h.id = p.host_id
+WHERE (p.ecosystem, p.name) IN (
+  SELECT ecosystem, name
+  FROM packages
+  GROUP BY 1, 2
+  HAVING count(DISTINCT version) > 1
+)
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3
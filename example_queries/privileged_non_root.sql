@@ -0,0 +1,14 @@
+.width 20 80
+.mode csv
+
+SELECT h.name AS host,
+       pr.name AS process,
+       pr.user,
+       pr.euid,
+       pr.cap_effective,
+       pr.executable
+FROM hosts h
+  JOIN processes pr ON pr.host_id = h.id
+WHERE pr.euid != 0
+  AND pr.cap_effective != ''
+ORDER BY h.name, pr.name
@@ -0,0 +1,12 @@
+.width 20 80
+.mode csv
+
+SELECT h.name AS host, e.subsystem AS what, e.message AS error
+FROM hosts h
+  JOIN scan_errors e ON e.host_id = h.id
+UNION ALL
+SELECT h.name AS host, f.path AS what, f.error AS error
+FROM hosts h
+  JOIN files f ON f.host_id = h.id
+WHERE f.error != ''
+ORDER BY host, what
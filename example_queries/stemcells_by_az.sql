@@ -0,0 +1,9 @@
+.mode csv
+
+SELECT d.name AS deployment, b.az, s.name AS stemcell, s.version, COUNT(*) AS vms
+FROM bosh_instances b
+  JOIN hosts h ON b.host_id = h.id
+  JOIN deployments d ON h.deployment_id = d.id
+  LEFT JOIN stemcells s ON b.stemcell_id = s.id
+GROUP BY d.name, b.az, s.name, s.version
+ORDER BY d.name, b.az, s.version
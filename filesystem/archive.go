@@ -0,0 +1,368 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveSeparator separates the path of an archive from the path of a file
+// inside it, as in /var/vcap/packages/app/app.jar!config/application.yml.
+const ArchiveSeparator = "!"
+
+const (
+	archiveZip  = "zip"
+	archiveTar  = "tar"
+	archiveTgz  = "tgz"
+	archiveTbz2 = "tbz2"
+	archiveGz   = "gz"
+	archiveBz2  = "bz2"
+)
+
+// archiveKind is how to read the files in an archive, or "" if name is not
+// an archive.
+func archiveKind(name string) string {
+	name = strings.ToLower(name)
+
+	suffixes := []struct {
+		suffix string
+		kind   string
+	}{
+		{".tar.gz", archiveTgz},
+		{".tgz", archiveTgz},
+		{".tar.bz2", archiveTbz2},
+		{".tbz2", archiveTbz2},
+		{".tar", archiveTar},
+		{".zip", archiveZip},
+		{".jar", archiveZip},
+		{".war", archiveZip},
+		{".ear", archiveZip},
+		{".gz", archiveGz},
+		{".bz2", archiveBz2},
+	}
+	for _, s := range suffixes {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.kind
+		}
+	}
+
+	return ""
+}
+
+var errArchiveTooLarge = errors.New("archive is too large to check")
+
+// archiveScan checks the files in an archive on disk and in the archives
+// inside it. Everything decompressed counts towards one budget so that
+// small archives which expand enormously are not read in full.
+type archiveScan struct {
+	fw        *fileWalker
+	remaining int64
+	matched   []WalkedFile
+
+	// held is how much of the walker's archive memory the scan is using.
+	held int64
+}
+
+func (fw *fileWalker) scanArchive(archivePath string, info os.FileInfo) ([]WalkedFile, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scan := &archiveScan{
+		fw:        fw,
+		remaining: fw.maxArchiveSize + 1,
+	}
+
+	err = scan.members(archivePath, f, info.Size(), 1)
+	if err == errArchiveTooLarge {
+		err = fmt.Errorf("%s: more than %d bytes when decompressed", err, fw.maxArchiveSize)
+	}
+
+	return scan.matched, err
+}
+
+func (s *archiveScan) members(archivePath string, r io.Reader, size int64, depth int) error {
+	kind := archiveKind(archivePath)
+	s.fw.logger.Debugf("Checking files in %s archive %s", kind, archivePath)
+
+	switch kind {
+	case archiveZip:
+		// Zips inside archives are read into memory by zipMember first
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			return fmt.Errorf("cannot read zip %s from a stream", archivePath)
+		}
+
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+
+			err = s.member(archivePath, f.Name, s.budget(rc), f.FileInfo().Size(), f.Modified, depth)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case archiveTar, archiveTgz, archiveTbz2:
+		stream, err := s.decompress(kind, r)
+		if err != nil {
+			return err
+		}
+
+		tr := tar.NewReader(stream)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+				continue
+			}
+
+			err = s.member(archivePath, hdr.Name, tr, hdr.Size, hdr.ModTime, depth)
+			if err != nil {
+				return err
+			}
+		}
+
+	case archiveGz, archiveBz2:
+		stream, err := s.decompress(kind, r)
+		if err != nil {
+			return err
+		}
+
+		// The only file is the archive without its extension
+		name := path.Base(archivePath[strings.LastIndex(archivePath, ArchiveSeparator)+1:])
+		name = strings.TrimSuffix(name, path.Ext(name))
+
+		return s.member(archivePath, name, stream, -1, time.Time{}, depth)
+	}
+
+	return nil
+}
+
+func (s *archiveScan) decompress(kind string, r io.Reader) (io.Reader, error) {
+	switch kind {
+	case archiveTgz, archiveGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.budget(gz), nil
+	case archiveTbz2, archiveBz2:
+		return s.budget(bzip2.NewReader(r)), nil
+	default:
+		return r, nil
+	}
+}
+
+// member checks the content of a file in an archive, or the files in it if
+// it is an archive itself. size is -1 if it is not known.
+func (s *archiveScan) member(archivePath, name string, r io.Reader, size int64, modTime time.Time, depth int) error {
+	memberPath := archivePath + ArchiveSeparator + name
+
+	if kind := archiveKind(name); kind != "" && depth < s.fw.maxArchiveDepth {
+		if kind == archiveZip {
+			return s.zipMember(memberPath, r, size, depth)
+		}
+
+		// The other kinds of archive are read as they are decompressed
+		return s.members(memberPath, r, size, depth+1)
+	}
+
+	matchedPathRegexes := s.fw.matchPath(memberPath)
+	checkRegexes := len(s.fw.compiledContentRegexes) > 0 &&
+		(len(s.fw.compiledPathRegexes) == 0 || len(matchedPathRegexes) > 0)
+	matchedRules := s.fw.matchRules(memberPath)
+	if !checkRegexes && len(matchedRules) == 0 {
+		return nil
+	}
+
+	if size > s.fw.maxRegexFileSize {
+		s.fw.logger.Debugf("Skipping content scan for %s: file too large", memberPath)
+		return nil
+	}
+
+	content, ok, err := s.read(r, s.fw.maxRegexFileSize)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.fw.logger.Debugf("Skipping content scan for %s: file too large", memberPath)
+		return nil
+	}
+
+	regexMatches := s.fw.matchContent(memberPath, content, checkRegexes, matchedPathRegexes, matchedRules)
+	if len(regexMatches) > 0 {
+		s.matched = append(s.matched, WalkedFile{
+			Path:         memberPath,
+			Info:         memberInfo{name: path.Base(name), size: int64(len(content)), modTime: modTime},
+			RegexMatches: regexMatches,
+			InArchive:    true,
+		})
+	}
+
+	return nil
+}
+
+// zipMember checks the files in a zip inside an archive. Zips keep their
+// directory at the end so they are read into memory first, out of the memory
+// shared by every archive the walker is checking.
+func (s *archiveScan) zipMember(memberPath string, r io.Reader, size int64, depth int) error {
+	if size > s.fw.maxArchiveSize {
+		s.fw.logger.Debugf("Skipping archive %s: too large", memberPath)
+		return nil
+	}
+
+	need := size
+	if need < 0 {
+		need = s.fw.maxArchiveSize
+	}
+
+	// Waiting while holding memory for an outer zip could leave every worker
+	// waiting for the others, so zips in zips are skipped instead
+	if !s.fw.archiveMemory.acquire(need, s.held == 0) {
+		s.fw.logger.Warnf("Skipping archive %s: not enough memory to check it", memberPath)
+		return nil
+	}
+	s.held += need
+	defer func() {
+		s.held -= need
+		s.fw.archiveMemory.release(need)
+	}()
+
+	data, ok, err := s.read(r, need)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.fw.logger.Debugf("Skipping archive %s: too large", memberPath)
+		return nil
+	}
+
+	return s.members(memberPath, bytes.NewReader(data), int64(len(data)), depth+1)
+}
+
+// read reads all of r unless it is more than max bytes, which is not ok.
+func (s *archiveScan) read(r io.Reader, max int64) ([]byte, bool, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, int64(len(data)) <= max, nil
+}
+
+func (s *archiveScan) budget(r io.Reader) io.Reader {
+	return &budgetReader{r: r, scan: s}
+}
+
+// budgetReader fails once more has been decompressed than is allowed.
+type budgetReader struct {
+	r    io.Reader
+	scan *archiveScan
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.scan.remaining <= 0 {
+		return 0, errArchiveTooLarge
+	}
+	if int64(len(p)) > b.scan.remaining {
+		p = p[:b.scan.remaining]
+	}
+
+	n, err := b.r.Read(p)
+	b.scan.remaining -= int64(n)
+	return n, err
+}
+
+// memoryLimit is a weighted semaphore for the bytes of archives read into
+// memory, shared by all of the walker's workers.
+type memoryLimit struct {
+	mu        sync.Mutex
+	released  *sync.Cond
+	available int64
+	max       int64
+}
+
+func newMemoryLimit(max int64) *memoryLimit {
+	m := &memoryLimit{available: max, max: max}
+	m.released = sync.NewCond(&m.mu)
+	return m
+}
+
+// acquire takes n bytes, waiting for them to be released if wait is set. It
+// returns false if it did not take them, which is always the case when n is
+// more than the limit.
+func (m *memoryLimit) acquire(n int64, wait bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n > m.max {
+		return false
+	}
+
+	for m.available < n {
+		if !wait {
+			return false
+		}
+		m.released.Wait()
+	}
+
+	m.available -= n
+	return true
+}
+
+func (m *memoryLimit) release(n int64) {
+	m.mu.Lock()
+	m.available += n
+	m.mu.Unlock()
+
+	m.released.Broadcast()
+}
+
+// memberInfo describes a file inside an archive, which has no owner or
+// permissions of its own on the machine.
+type memberInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memberInfo) Name() string       { return i.name }
+func (i memberInfo) Size() int64        { return i.size }
+func (i memberInfo) Mode() os.FileMode  { return 0 }
+func (i memberInfo) ModTime() time.Time { return i.modTime }
+func (i memberInfo) IsDir() bool        { return false }
+func (i memberInfo) Sys() interface{}   { return nil }
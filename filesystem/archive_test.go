@@ -0,0 +1,245 @@
+package filesystem_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/filesystem"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+var _ = Describe("Checking archives", func() {
+	var (
+		root      string
+		fileMatch scantron.FileMatch
+	)
+
+	tgz := func(files map[string][]byte) []byte {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+		for name, content := range files {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write(content)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(gz.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	zipped := func(files map[string][]byte) []byte {
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		for name, content := range files {
+			w, err := zw.Create(name)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = w.Write(content)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(zw.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	gzipped := func(content []byte) []byte {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		_, err := gz.Write(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	write := func(name string, content []byte) string {
+		p := filepath.Join(root, name)
+		Expect(ioutil.WriteFile(p, content, 0644)).To(Succeed())
+		return p
+	}
+
+	walk := func() map[string]filesystem.WalkedFile {
+		walker, err := filesystem.NewWalker(filesystem.FileConfig{RootPaths: []string{root}}, fileMatch, scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		files, err := walker.Walk()
+		Expect(err).NotTo(HaveOccurred())
+
+		byPath := map[string]filesystem.WalkedFile{}
+		for _, f := range files {
+			byPath[f.Path] = f
+		}
+		return byPath
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "archive-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		fileMatch = scantron.FileMatch{
+			ContentRegexes:   []string{"valuable"},
+			MaxRegexFileSize: 1000,
+			ExcerptMask:      filesystem.MaskNone,
+			Archives:         true,
+			MaxArchiveSize:   100000,
+			MaxArchiveDepth:  2,
+			MaxArchiveMemory: 100000,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("records matches in files inside archives", func() {
+		archive := write("release.tgz", tgz(map[string][]byte{
+			"config/app.yml": []byte("key: valuable"),
+			"config/other":   []byte("nothing here"),
+		}))
+
+		files := walk()
+
+		Expect(files).To(HaveKey(archive))
+		Expect(files[archive].RegexMatches).To(BeEmpty())
+
+		member := files[archive+"!config/app.yml"]
+		Expect(member.InArchive).To(BeTrue())
+		Expect(member.Info.Size()).To(Equal(int64(13)))
+		Expect(member.RegexMatches).To(ConsistOf(scantron.RegexMatch{
+			ContentRegex: "valuable",
+			LineNumber:   1,
+			ByteOffset:   5,
+			MatchCount:   1,
+			Excerpt:      "key: valuable",
+		}))
+
+		Expect(files).NotTo(HaveKey(archive + "!config/other"))
+	})
+
+	It("checks zip files, jars and compressed files", func() {
+		jar := write("app.jar", zipped(map[string][]byte{"application.properties": []byte("password=valuable")}))
+		log := write("app.log.gz", gzipped([]byte("line\nvaluable\n")))
+
+		files := walk()
+
+		Expect(files).To(HaveKey(jar + "!application.properties"))
+		Expect(files).To(HaveKey(log + "!app.log"))
+		Expect(files[log+"!app.log"].RegexMatches[0].LineNumber).To(Equal(2))
+	})
+
+	It("checks archives inside archives up to the maximum depth", func() {
+		jar := zipped(map[string][]byte{"application.properties": []byte("valuable")})
+		archive := write("release.tgz", tgz(map[string][]byte{
+			"lib/app.jar": jar,
+			"lib/deep.tgz": tgz(map[string][]byte{
+				"too-deep.tgz": tgz(map[string][]byte{"secret": []byte("valuable")}),
+			}),
+		}))
+
+		files := walk()
+
+		Expect(files).To(HaveKey(archive + "!lib/app.jar!application.properties"))
+		Expect(files).NotTo(HaveKey(archive + "!lib/deep.tgz!too-deep.tgz!secret"))
+	})
+
+	It("applies path regexes and rules to the paths of files in archives", func() {
+		fileMatch.PathRegexes = []string{`!config/`}
+		fileMatch.Rules = []scantron.Rule{
+			{Name: "custom/properties", Severity: "low", PathRegex: `\.properties$`, ContentRegex: "valuable"},
+		}
+		archive := write("app.zip", zipped(map[string][]byte{
+			"config/app.yml":     []byte("valuable"),
+			"lib/app.yml":        []byte("valuable"),
+			"lib/app.properties": []byte("valuable"),
+		}))
+
+		files := walk()
+
+		Expect(files).To(HaveKey(archive + "!config/app.yml"))
+		Expect(files).NotTo(HaveKey(archive + "!lib/app.yml"))
+		Expect(files[archive+"!lib/app.properties"].RegexMatches).To(ConsistOf(
+			MatchFields(IgnoreExtras, Fields{"Rule": Equal("custom/properties")}),
+		))
+	})
+
+	It("stops and records an error when an archive decompresses to too much", func() {
+		fileMatch.MaxArchiveSize = 5000
+		archive := write("bomb.tgz", tgz(map[string][]byte{
+			"a": []byte(strings.Repeat("0", 4000)),
+			"b": []byte(strings.Repeat("0", 4000)),
+		}))
+
+		files := walk()
+
+		Expect(files[archive].Err).To(MatchError(ContainSubstring("more than 5000 bytes when decompressed")))
+	})
+
+	It("only reads zips inside archives into memory, up to the memory limit", func() {
+		fileMatch.MaxArchiveMemory = 100
+		archive := write("release.tgz", tgz(map[string][]byte{
+			"lib/app.jar":    zipped(map[string][]byte{"application.properties": []byte("valuable")}),
+			"lib/config.tgz": tgz(map[string][]byte{"secret": []byte("valuable")}),
+			"lib/app.log.gz": gzipped([]byte(strings.Repeat("line\n", 100) + "valuable\n")),
+			"config/app.yml": []byte("valuable"),
+		}))
+
+		files := walk()
+
+		Expect(files).NotTo(HaveKey(archive + "!lib/app.jar!application.properties"))
+		Expect(files).To(HaveKey(archive + "!lib/config.tgz!secret"))
+		Expect(files).To(HaveKey(archive + "!lib/app.log.gz!app.log"))
+		Expect(files).To(HaveKey(archive + "!config/app.yml"))
+		Expect(files[archive].Err).NotTo(HaveOccurred())
+	})
+
+	Context("when zips are inside zips", func() {
+		var inner, middle []byte
+
+		BeforeEach(func() {
+			fileMatch.MaxArchiveDepth = 3
+			inner = zipped(map[string][]byte{"secret": []byte("valuable")})
+			middle = zipped(map[string][]byte{"inner.zip": inner, "config": []byte("valuable")})
+		})
+
+		It("checks them when they fit in memory together", func() {
+			fileMatch.MaxArchiveMemory = int64(len(middle) + len(inner))
+			archive := write("release.tgz", tgz(map[string][]byte{"middle.zip": middle}))
+
+			files := walk()
+
+			Expect(files).To(HaveKey(archive + "!middle.zip!config"))
+			Expect(files).To(HaveKey(archive + "!middle.zip!inner.zip!secret"))
+		})
+
+		It("skips the zips that do not fit in the memory left", func() {
+			fileMatch.MaxArchiveMemory = int64(len(middle) + len(inner) - 1)
+			archive := write("release.tgz", tgz(map[string][]byte{"middle.zip": middle}))
+
+			files := walk()
+
+			Expect(files).To(HaveKey(archive + "!middle.zip!config"))
+			Expect(files).NotTo(HaveKey(archive + "!middle.zip!inner.zip!secret"))
+			Expect(files[archive].Err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("does not look inside archives unless asked to", func() {
+		fileMatch.Archives = false
+		archive := write("release.tgz", tgz(map[string][]byte{"config/app.yml": []byte("valuable")}))
+
+		files := walk()
+
+		Expect(files).To(HaveLen(1))
+		Expect(files).To(HaveKey(archive))
+	})
+})
@@ -0,0 +1,92 @@
+package filesystem
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// How much of the matching text is hidden in excerpts, since content regexes
+// are usually looking for secrets.
+const (
+	MaskAll     = "all"
+	MaskPartial = "partial"
+	MaskNone    = "none"
+)
+
+const (
+	excerptContext  = 40
+	excerptMaxMatch = 80
+	unmaskedPrefix  = 4
+)
+
+// excerpt is the line the match starts on, cut down to some context either
+// side of the match, with the match masked. Regexes often match only the
+// name of a secret, like "password" or "AKIA", so when masking the rest of
+// the line after the match is masked too rather than shown as context.
+func excerpt(content []byte, start, end int, mask string) string {
+	lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+	lineEnd := len(content)
+	if i := bytes.IndexByte(content[start:], '\n'); i >= 0 {
+		lineEnd = start + i
+	}
+	if end > lineEnd || mask != MaskNone {
+		end = lineEnd
+	}
+
+	before, after := "", ""
+	from, to := lineStart, lineEnd
+	if start-from > excerptContext {
+		from = start - excerptContext
+		before = "..."
+	}
+	if to-end > excerptContext {
+		to = end + excerptContext
+		after = "..."
+	}
+
+	return before +
+		printable(content[from:start]) +
+		maskMatch(printable(content[start:end]), mask) +
+		printable(content[end:to]) +
+		after
+}
+
+// maskMatch hides all of the match, or all but its start when it is long
+// enough that the start does not give much of it away.
+func maskMatch(match string, mask string) string {
+	runes := []rune(match)
+	truncated := ""
+	if len(runes) > excerptMaxMatch {
+		runes = runes[:excerptMaxMatch]
+		truncated = "..."
+	}
+
+	switch {
+	case mask == MaskNone:
+		return string(runes) + truncated
+	case mask == MaskPartial && len(runes) > unmaskedPrefix*2:
+		return string(runes[:unmaskedPrefix]) + strings.Repeat("*", len(runes)-unmaskedPrefix) + truncated
+	default:
+		return strings.Repeat("*", len(runes)) + truncated
+	}
+}
+
+// printable replaces bytes that would garble a report, like those of binary
+// files, with dots.
+func printable(b []byte) string {
+	var s strings.Builder
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == '\t' {
+			r = ' '
+		} else if r == utf8.RuneError || !unicode.IsPrint(r) {
+			r = '.'
+		}
+		s.WriteRune(r)
+		b = b[size:]
+	}
+
+	return s.String()
+}
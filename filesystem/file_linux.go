@@ -1,4 +1,4 @@
-//+build !windows
+// +build !windows
 
 package filesystem
 
@@ -7,6 +7,9 @@ import (
 	"os"
 	"os/user"
 	"syscall"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/capability"
 )
 
 type metadata struct {
@@ -18,18 +21,44 @@ func GetFileMetadata() FileMetadata {
 
 func GetFileConfig() FileConfig {
 	return FileConfig{
-		RootPath: "/",
+		RootPaths: []string{"/"},
 		ExcludedPaths: []string{
 			"/dev", "/proc", "/sys", "/run",
 		},
+		FilesystemTypes:   getFilesystemTypes(),
+		RecordDirectories: true,
+	}
+}
+
+func getFilesystemTypes() map[string]string {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	types, err := ParseMountInfo(file)
+	if err != nil {
+		return nil
+	}
+
+	return types
+}
+
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
 	}
+
+	return uint64(stat.Dev), true
 }
 
 func (f *metadata) GetUser(_ string, fileInfo os.FileInfo) (string, error) {
 	uid := fmt.Sprint(fileInfo.Sys().(*syscall.Stat_t).Uid)
 	user, err := user.LookupId(uid)
 	if err != nil {
-		return uid, nil
+		return uid, UnknownIDError{ID: uid}
 	}
 	return user.Username, nil
 }
@@ -38,7 +67,29 @@ func (f *metadata) GetGroup(_ string, fileInfo os.FileInfo) (string, error) {
 	gid := fmt.Sprint(fileInfo.Sys().(*syscall.Stat_t).Gid)
 	group, err := user.LookupGroupId(gid)
 	if err != nil {
-		return gid, nil
+		return gid, UnknownIDError{ID: gid}
 	}
 	return group.Name, nil
 }
+
+func (f *metadata) GetCapabilities(path string, _ os.FileInfo) (*scantron.FileCapabilities, error) {
+	data := make([]byte, 64)
+	n, err := syscall.Getxattr(path, capability.FileXattr, data)
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := capability.ParseFileXattr(data[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	return &scantron.FileCapabilities{
+		Permitted:   capability.Names(set.Permitted),
+		Inheritable: capability.Names(set.Inheritable),
+		Effective:   set.Effective,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"fmt"
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanlog"
 	"os"
@@ -9,6 +10,17 @@ import (
 type FileMetadata interface {
 	GetUser(path string, fileInfo os.FileInfo) (string, error)
 	GetGroup(path string, fileInfo os.FileInfo) (string, error)
+	GetCapabilities(path string, fileInfo os.FileInfo) (*scantron.FileCapabilities, error)
+}
+
+// UnknownIDError is returned by GetUser and GetGroup, along with the numeric
+// ID, when the owner of a file does not exist on the machine.
+type UnknownIDError struct {
+	ID string
+}
+
+func (e UnknownIDError) Error() string {
+	return fmt.Sprintf("no user or group with id %s", e.ID)
 }
 
 type FileScanner struct {
@@ -26,25 +38,58 @@ func (fs *FileScanner) ScanFiles() ([]scantron.File, error) {
 
 	files := []scantron.File{}
 	for _, wf := range walkedFiles {
+		if wf.Info == nil {
+			files = append(files, scantron.File{Path: wf.Path, Error: wf.Err.Error()})
+			continue
+		}
+
+		// Files in archives only have the metadata the archive gives them
+		if wf.InArchive {
+			files = append(files, scantron.File{
+				Path:         wf.Path,
+				Size:         wf.Info.Size(),
+				ModifiedTime: wf.Info.ModTime(),
+				RegexMatches: wf.RegexMatches,
+			})
+			continue
+		}
+
 		user, err := fs.Metadata.GetUser(wf.Path, wf.Info)
+		_, orphanUID := err.(UnknownIDError)
 
 		// Some files (e.g. C:\pagefile.sys) don't have user/group
-		if err != nil {
+		if err != nil && !orphanUID {
 			fs.Logger.Warnf("Error retrieving user for %s: %s", wf.Path, err)
 		}
 		group, err := fs.Metadata.GetGroup(wf.Path, wf.Info)
-		if err != nil {
+		_, orphanGID := err.(UnknownIDError)
+		if err != nil && !orphanGID {
 			fs.Logger.Warnf("Error retrieving group for %s: %s", wf.Path, err)
 		}
 
+		var capabilities *scantron.FileCapabilities
+		if wf.Info.Mode().IsRegular() {
+			capabilities, err = fs.Metadata.GetCapabilities(wf.Path, wf.Info)
+			if err != nil {
+				fs.Logger.Warnf("Error retrieving capabilities for %s: %s", wf.Path, err)
+			}
+		}
+
 		file := scantron.File{
 			Path:         wf.Path,
 			Permissions:  wf.Info.Mode(),
 			Size:         wf.Info.Size(),
 			User:         user,
 			Group:        group,
+			OrphanUID:    orphanUID,
+			OrphanGID:    orphanGID,
 			ModifiedTime: wf.Info.ModTime(),
 			RegexMatches: wf.RegexMatches,
+			SHA256:       wf.SHA256,
+			Capabilities: capabilities,
+		}
+		if wf.Err != nil {
+			file.Error = wf.Err.Error()
 		}
 
 		fs.Logger.Debugf("Record file %s: Permissions: '%d' User: '%s' Group: '%s' Size: '%d' Modified: '%s'",
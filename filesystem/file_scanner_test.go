@@ -14,6 +14,7 @@ import (
 )
 
 type fakeFileInfo struct {
+	mode os.FileMode
 }
 
 func (f *fakeFileInfo) Name() string {
@@ -23,14 +24,17 @@ func (f *fakeFileInfo) Size() int64 {
 	return 123
 }
 func (f *fakeFileInfo) Mode() os.FileMode {
-	return 0755
+	if f.mode == 0 {
+		return 0755
+	}
+	return f.mode
 }
 func (f *fakeFileInfo) ModTime() time.Time {
 	loc, _ := time.LoadLocation("UTC")
 	return time.Date(2018, time.August, 3, 5, 2, 5, 2, loc)
 }
 func (f *fakeFileInfo) IsDir() bool {
-	return false
+	return f.mode.IsDir()
 }
 func (f *fakeFileInfo) Sys() interface{} {
 	return nil
@@ -80,6 +84,7 @@ var _ = Describe("FileScanner", func() {
 		}, nil).Times(1)
 		mockFileMetadata.EXPECT().GetUser(path, info).Return("user", nil).Times(1)
 		mockFileMetadata.EXPECT().GetGroup(path, info).Return("group", nil).Times(1)
+		mockFileMetadata.EXPECT().GetCapabilities(path, info).Return(nil, nil).Times(1)
 
 		files, err := subject.ScanFiles()
 
@@ -114,6 +119,7 @@ var _ = Describe("FileScanner", func() {
 		}, nil).Times(1)
 		mockFileMetadata.EXPECT().GetUser(path, info).Return("user", nil).Times(1)
 		mockFileMetadata.EXPECT().GetGroup(path, info).Return("group", nil).Times(1)
+		mockFileMetadata.EXPECT().GetCapabilities(path, info).Return(nil, nil).Times(1)
 
 		files, err := subject.ScanFiles()
 
@@ -134,4 +140,110 @@ var _ = Describe("FileScanner", func() {
 			},
 		}))
 	})
+
+	It("records files that could not be read", func() {
+		info := &fakeFileInfo{}
+		mockFileWalker.EXPECT().Walk().Return([]filesystem.WalkedFile{
+			{Path: "/gone", Err: errors.New("lstat /gone: no such file or directory")},
+			{Path: "/var/vcap/data/secret", Info: info, Err: errors.New("open /var/vcap/data/secret: permission denied")},
+		}, nil).Times(1)
+		mockFileMetadata.EXPECT().GetUser("/var/vcap/data/secret", info).Return("root", nil).Times(1)
+		mockFileMetadata.EXPECT().GetGroup("/var/vcap/data/secret", info).Return("root", nil).Times(1)
+		mockFileMetadata.EXPECT().GetCapabilities("/var/vcap/data/secret", info).Return(nil, nil).Times(1)
+
+		files, err := subject.ScanFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(files).To(HaveLen(2))
+		Expect(files[0]).To(Equal(scantron.File{
+			Path:  "/gone",
+			Error: "lstat /gone: no such file or directory",
+		}))
+		Expect(files[1].Path).To(Equal("/var/vcap/data/secret"))
+		Expect(files[1].User).To(Equal("root"))
+		Expect(files[1].Error).To(Equal("open /var/vcap/data/secret: permission denied"))
+	})
+
+	It("records files in archives without looking up their metadata", func() {
+		info := &fakeFileInfo{mode: 0644}
+		path := "/var/vcap/packages/app/app.jar!application.properties"
+		matches := []scantron.RegexMatch{{ContentRegex: "password", LineNumber: 3, MatchCount: 1}}
+
+		mockFileWalker.EXPECT().Walk().Return([]filesystem.WalkedFile{
+			{Path: path, Info: info, RegexMatches: matches, InArchive: true},
+		}, nil).Times(1)
+
+		files, err := subject.ScanFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(files).To(Equal([]scantron.File{
+			{
+				Path:         path,
+				Size:         info.Size(),
+				ModifiedTime: info.ModTime(),
+				RegexMatches: matches,
+			},
+		}))
+	})
+
+	It("marks files whose user or group does not exist", func() {
+		info := &fakeFileInfo{}
+		path := "/var/vcap/store/orphan"
+
+		mockFileWalker.EXPECT().Walk().Return([]filesystem.WalkedFile{
+			{Path: path, Info: info},
+		}, nil).Times(1)
+		mockFileMetadata.EXPECT().GetUser(path, info).Return("1001", filesystem.UnknownIDError{ID: "1001"}).Times(1)
+		mockFileMetadata.EXPECT().GetGroup(path, info).Return("vcap", nil).Times(1)
+		mockFileMetadata.EXPECT().GetCapabilities(path, info).Return(nil, nil).Times(1)
+
+		files, err := subject.ScanFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].User).To(Equal("1001"))
+		Expect(files[0].OrphanUID).To(BeTrue())
+		Expect(files[0].Group).To(Equal("vcap"))
+		Expect(files[0].OrphanGID).To(BeFalse())
+	})
+
+	It("records the capabilities of executables", func() {
+		info := &fakeFileInfo{}
+		path := "/usr/bin/ping"
+		capabilities := &scantron.FileCapabilities{
+			Permitted: []string{"CAP_NET_RAW"},
+			Effective: true,
+		}
+
+		mockFileWalker.EXPECT().Walk().Return([]filesystem.WalkedFile{
+			{Path: path, Info: info},
+		}, nil).Times(1)
+		mockFileMetadata.EXPECT().GetUser(path, info).Return("root", nil).Times(1)
+		mockFileMetadata.EXPECT().GetGroup(path, info).Return("root", nil).Times(1)
+		mockFileMetadata.EXPECT().GetCapabilities(path, info).Return(capabilities, nil).Times(1)
+
+		files, err := subject.ScanFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Capabilities).To(Equal(capabilities))
+	})
+
+	It("does not look for capabilities on directories", func() {
+		info := &fakeFileInfo{mode: os.ModeDir | os.ModeSticky | 0777}
+		path := "/tmp"
+
+		mockFileWalker.EXPECT().Walk().Return([]filesystem.WalkedFile{
+			{Path: path, Info: info},
+		}, nil).Times(1)
+		mockFileMetadata.EXPECT().GetUser(path, info).Return("root", nil).Times(1)
+		mockFileMetadata.EXPECT().GetGroup(path, info).Return("root", nil).Times(1)
+
+		files, err := subject.ScanFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Permissions).To(Equal(os.ModeDir | os.ModeSticky | 0777))
+		Expect(files[0].Capabilities).To(BeNil())
+	})
 })
@@ -1,12 +1,18 @@
 package filesystem
 
 import (
-	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/scanlog"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 )
 
@@ -14,11 +20,56 @@ type WalkedFile struct {
 	Path         string
 	Info         os.FileInfo
 	RegexMatches []scantron.RegexMatch
+	SHA256       string
+
+	// InArchive is set for files inside archives, whose Path is the path of
+	// the archive and then the path in the archive after ArchiveSeparator.
+	InArchive bool
+
+	// Err is why the file could not be read. Info is nil if it could not
+	// even be looked at.
+	Err error
 }
 
 type FileConfig struct {
 	ExcludedPaths []string
-	RootPath      string
+	RootPaths     []string
+
+	// ExcludedGlobs without a slash match file names and the rest match
+	// whole paths.
+	ExcludedGlobs   []string
+	ExcludedRegexes []string
+
+	// ExcludedFilesystemTypes are skipped when they are mounted below a root.
+	// A type also matches its subtypes, so fuse matches fuse.sshfs.
+	ExcludedFilesystemTypes []string
+	FilesystemTypes         map[string]string // mount point to type
+
+	// OneFilesystem does not descend into other filesystems than the root's.
+	OneFilesystem bool
+
+	// MaxDepth is how many directories below a root to descend, or 0 for no
+	// limit.
+	MaxDepth int
+
+	// RecordDirectories records directories as well as regular files so that
+	// their permissions, like world-writable directories, can be checked.
+	RecordDirectories bool
+}
+
+// WithScope limits the files walked to those selected on the command line.
+func (config FileConfig) WithScope(scope scantron.FileScope) FileConfig {
+	if len(scope.Roots) > 0 {
+		config.RootPaths = scope.Roots
+	}
+
+	config.ExcludedGlobs = append(config.ExcludedGlobs, scope.ExcludedGlobs...)
+	config.ExcludedRegexes = append(config.ExcludedRegexes, scope.ExcludedRegexes...)
+	config.ExcludedFilesystemTypes = append(config.ExcludedFilesystemTypes, scope.ExcludedFilesystemTypes...)
+	config.OneFilesystem = scope.OneFilesystem
+	config.MaxDepth = scope.MaxDepth
+
+	return config
 }
 
 type FileWalker interface {
@@ -30,12 +81,30 @@ type fileWalker struct {
 	logger                 scanlog.Logger
 	compiledPathRegexes    []*regexp.Regexp
 	compiledContentRegexes []*regexp.Regexp
+	compiledExcludeRegexes []*regexp.Regexp
+	compiledRules          []compiledRule
 	maxRegexFileSize       int64
+	hashPaths              []string
+	excerptMask            string
+	archives               bool
+	maxArchiveSize         int64
+	maxArchiveDepth        int
+	archiveMemory          *memoryLimit
 }
 
 type regexJob struct {
 	wf           WalkedFile
 	matchedPaths []string
+	checkContent bool
+	rules        []compiledRule
+	archive      bool
+	hash         bool
+}
+
+type compiledRule struct {
+	rule    scantron.Rule
+	path    *regexp.Regexp
+	content *regexp.Regexp
 }
 
 func NewWalker(config FileConfig,
@@ -50,13 +119,49 @@ func NewWalker(config FileConfig,
 	if err != nil {
 		return nil, err
 	}
+	compiledExcludeRegexes, err := compileRegexes(logger, config.ExcludedRegexes)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledRules := []compiledRule{}
+	for _, rule := range fileMatch.Rules {
+		compiled := compiledRule{rule: rule}
+		if rule.PathRegex != "" {
+			compiled.path, err = regexp.Compile(rule.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path regex for rule %s: %s", rule.Name, err)
+			}
+		}
+		compiled.content, err = regexp.Compile(rule.ContentRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content regex for rule %s: %s", rule.Name, err)
+		}
+
+		compiledRules = append(compiledRules, compiled)
+	}
+
+	for _, glob := range config.ExcludedGlobs {
+		_, err := filepath.Match(glob, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude glob %q: %s", glob, err)
+		}
+	}
 
 	return &fileWalker{
 		config:                 config,
 		logger:                 logger,
 		compiledPathRegexes:    compiledPathRegexes,
 		compiledContentRegexes: compiledContentRegexes,
+		compiledExcludeRegexes: compiledExcludeRegexes,
+		compiledRules:          compiledRules,
 		maxRegexFileSize:       fileMatch.MaxRegexFileSize,
+		hashPaths:              fileMatch.HashPaths,
+		excerptMask:            fileMatch.ExcerptMask,
+		archives:               fileMatch.Archives,
+		maxArchiveSize:         fileMatch.MaxArchiveSize,
+		maxArchiveDepth:        fileMatch.MaxArchiveDepth,
+		archiveMemory:          newMemoryLimit(fileMatch.MaxArchiveMemory),
 	}, nil
 }
 
@@ -87,22 +192,61 @@ func (fw *fileWalker) Walk() ([]WalkedFile, error) {
 	regexQueue := make(chan regexJob, maxInFlight)
 	wg := &sync.WaitGroup{}
 
-	go func() {
-		done <- filepath.Walk(fw.config.RootPath, func(path string, info os.FileInfo, err error) error {
+	walkRoot := func(root string) error {
+		var rootDevice uint64
+		if fw.config.OneFilesystem {
+			info, err := os.Stat(root)
+			if err != nil {
+				fw.logger.Warnf("Error accessing %s: %s", root, err)
+				wf <- WalkedFile{Path: root, Err: err}
+				return nil
+			}
+			rootDevice, _ = deviceOf(info)
+		}
+
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 			fw.logger.Debugf("Visiting file %s", path)
+			// A directory which cannot be listed is visited once, with its
+			// info and the error, so it may still be excluded
+			if info != nil {
+				if reason := fw.excluded(root, path, info); reason != "" {
+					if info.IsDir() {
+						fw.logger.Infof("Skipping %s: %s", path, reason)
+						return filepath.SkipDir
+					}
+
+					fw.logger.Debugf("Skipping %s: %s", path, reason)
+					return nil
+				}
+			}
+
 			if err != nil {
-				fw.logger.Errorf("Error accessing %s: %s", path, err)
-				return err
+				// Record the error and carry on with the rest of the walk.
+				// Returning nil for a directory that cannot be listed skips it.
+				fw.logger.Warnf("Error accessing %s: %s", path, err)
+				wf <- WalkedFile{Path: path, Info: info, Err: err}
+				return nil
 			}
 
 			if info.IsDir() {
-				for _, excludedPath := range fw.config.ExcludedPaths {
-					if excludedPath == path {
-						fw.logger.Infof("Skipping excluded directory %s", path)
+				if path != root && fw.config.OneFilesystem {
+					device, ok := deviceOf(info)
+					if ok && device != rootDevice {
+						fw.logger.Infof("Skipping %s: on another filesystem", path)
 						return filepath.SkipDir
 					}
 				}
 
+				if fw.config.RecordDirectories {
+					wf <- WalkedFile{Path: path, Info: info}
+					fw.logger.Debugf("Recorded directory %s", path)
+				}
+
+				if fw.config.MaxDepth > 0 && depth(root, path) > fw.config.MaxDepth {
+					fw.logger.Debugf("Not descending into %s: maximum depth reached", path)
+					return filepath.SkipDir
+				}
+
 				return nil
 			}
 
@@ -115,20 +259,30 @@ func (fw *fileWalker) Walk() ([]WalkedFile, error) {
 			pathMatch := len(fw.compiledPathRegexes) == 0 || len(matchedPathRegexes) > 0
 			sizeMatch := info.Size() <= fw.maxRegexFileSize
 			checkContent := pathMatch && sizeMatch && len(fw.compiledContentRegexes) > 0
-			if pathMatch && !sizeMatch {
+			matchedRules := fw.matchRules(path)
+			if (pathMatch || len(matchedRules) > 0) && !sizeMatch {
 				fw.logger.Debugf("Skipping content scan for %s: file too large", path)
+				matchedRules = nil
 			}
 
+			hash := fw.shouldHash(path)
+			archive := fw.archives && archiveKind(path) != "" && info.Size() <= fw.maxArchiveSize &&
+				(len(fw.compiledContentRegexes) > 0 || len(fw.compiledRules) > 0)
+
 			file := WalkedFile{
 				Path:         path,
 				Info:         info,
 				RegexMatches: nil,
 			}
-			if checkContent {
+			if checkContent || len(matchedRules) > 0 || archive || hash {
 				wg.Add(1)
 				regexQueue <- regexJob{
-					file,
-					matchedPathRegexes,
+					wf:           file,
+					matchedPaths: matchedPathRegexes,
+					checkContent: checkContent,
+					rules:        matchedRules,
+					archive:      archive,
+					hash:         hash,
 				}
 				fw.logger.Debugf("Queued file %s for content check", path)
 			} else {
@@ -138,19 +292,58 @@ func (fw *fileWalker) Walk() ([]WalkedFile, error) {
 
 			return nil
 		})
+	}
+
+	go func() {
+		for _, root := range fw.config.RootPaths {
+			err := walkRoot(root)
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+
+		done <- nil
 	}()
 
-	if len(fw.compiledContentRegexes) > 0 {
+	if len(fw.compiledContentRegexes) > 0 || len(fw.compiledRules) > 0 || len(fw.hashPaths) > 0 {
 		for i := 0; i < maxInFlight; i++ {
 			go func() {
 				for job := range regexQueue {
 					fw.logger.Debugf("Checking file %s", job.wf.Path)
-					regexMatches, err := fw.matchContent(job.wf.Path, job.matchedPaths)
-					if err != nil {
-						fw.logger.Warnf("Error checking content of %s: %s", job.wf.Path, err)
+					if job.checkContent || len(job.rules) > 0 {
+						content, err := readContent(job.wf.Path, fw.maxRegexFileSize)
+						if err != nil {
+							fw.logger.Warnf("Error checking content of %s: %s", job.wf.Path, err)
+							job.wf.Err = err
+						} else {
+							job.wf.RegexMatches = fw.matchContent(job.wf.Path, content, job.checkContent, job.matchedPaths, job.rules)
+						}
+					}
+
+					if job.archive && job.wf.Err == nil {
+						members, err := fw.scanArchive(job.wf.Path, job.wf.Info)
+						if err != nil {
+							fw.logger.Warnf("Error checking files in archive %s: %s", job.wf.Path, err)
+							job.wf.Err = err
+						}
+
+						for _, member := range members {
+							wf <- member
+							fw.logger.Debugf("Recorded file %s", member.Path)
+						}
+					}
+
+					if job.hash && job.wf.Err == nil {
+						sum, err := hashFile(job.wf.Path)
+						if err != nil {
+							fw.logger.Warnf("Error hashing %s: %s", job.wf.Path, err)
+							job.wf.Err = err
+						}
+
+						job.wf.SHA256 = sum
 					}
 
-					job.wf.RegexMatches = regexMatches
 					wf <- job.wf
 
 					fw.logger.Debugf("Recorded file %s", job.wf.Path)
@@ -187,6 +380,85 @@ func (fw *fileWalker) Walk() ([]WalkedFile, error) {
 	return files, nil
 }
 
+// excluded returns why path should not be walked, or "" if it should.
+func (fw *fileWalker) excluded(root, path string, info os.FileInfo) string {
+	if info.IsDir() {
+		for _, excludedPath := range fw.config.ExcludedPaths {
+			if excludedPath == path {
+				return "excluded directory"
+			}
+		}
+
+		if path != root {
+			if fsType, ok := fw.config.FilesystemTypes[path]; ok {
+				for _, excludedType := range fw.config.ExcludedFilesystemTypes {
+					if fsType == excludedType || strings.HasPrefix(fsType, excludedType+".") {
+						return fmt.Sprintf("excluded %s filesystem", fsType)
+					}
+				}
+			}
+		}
+	}
+
+	for _, glob := range fw.config.ExcludedGlobs {
+		name := path
+		if !strings.Contains(glob, "/") {
+			name = filepath.Base(path)
+		}
+
+		if matched, _ := filepath.Match(glob, name); matched {
+			return fmt.Sprintf("matches %s", glob)
+		}
+	}
+
+	for _, regex := range fw.compiledExcludeRegexes {
+		if regex.MatchString(path) {
+			return fmt.Sprintf("matches %s", regex.String())
+		}
+	}
+
+	return ""
+}
+
+// depth is how many directories below root path is.
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// shouldHash is whether path is under one of the directories whose files are
+// hashed.
+func (fw *fileWalker) shouldHash(path string) bool {
+	for _, dir := range fw.hashPaths {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func (fw *fileWalker) matchPath(path string) []string {
 	var matchedPathRegexes []string
 
@@ -200,42 +472,80 @@ func (fw *fileWalker) matchPath(path string) []string {
 	return matchedPathRegexes
 }
 
-func (fw *fileWalker) matchContent(path string, matchedPathRegexes []string) ([]scantron.RegexMatch, error) {
+func (fw *fileWalker) matchRules(path string) []compiledRule {
+	var matchedRules []compiledRule
+
+	for _, rule := range fw.compiledRules {
+		if rule.path == nil || rule.path.MatchString(path) {
+			matchedRules = append(matchedRules, rule)
+		}
+	}
+
+	return matchedRules
+}
+
+func (fw *fileWalker) matchContent(path string, content []byte, checkRegexes bool, matchedPathRegexes []string, rules []compiledRule) []scantron.RegexMatch {
 	var regexMatches []scantron.RegexMatch
 
+	for _, rule := range rules {
+		match, ok := fw.findMatches(path, content, rule.content)
+		if ok {
+			match.PathRegex = rule.rule.PathRegex
+			match.Rule = rule.rule.Name
+			match.Severity = rule.rule.Severity
+			regexMatches = append(regexMatches, match)
+		}
+	}
+
+	if !checkRegexes {
+		return regexMatches
+	}
+
 	for _, contentRegex := range fw.compiledContentRegexes {
-		match, err := fw.checkContent(contentRegex, path)
-		if err != nil {
-			return nil, err
+		match, ok := fw.findMatches(path, content, contentRegex)
+		if !ok {
+			continue
 		}
-		if match {
-			fw.logger.Debugf("Content of %s matches %s", path, contentRegex.String())
-			if len(matchedPathRegexes) == 0 {
-				regexMatches = append(regexMatches, scantron.RegexMatch{
-					ContentRegex: contentRegex.String(),
-					PathRegex:    "",
-				})
-			} else {
-				for _, pr := range matchedPathRegexes {
-					regexMatches = append(regexMatches, scantron.RegexMatch{
-						ContentRegex: contentRegex.String(),
-						PathRegex:    pr,
-					})
-				}
+
+		if len(matchedPathRegexes) == 0 {
+			regexMatches = append(regexMatches, match)
+		} else {
+			for _, pr := range matchedPathRegexes {
+				match.PathRegex = pr
+				regexMatches = append(regexMatches, match)
 			}
 		}
 	}
 
-	return regexMatches, nil
+	return regexMatches
 }
 
-func (fw *fileWalker) checkContent(contentRegex *regexp.Regexp, path string) (bool, error) {
+// findMatches describes where contentRegex matches content, if it does.
+func (fw *fileWalker) findMatches(path string, content []byte, contentRegex *regexp.Regexp) (scantron.RegexMatch, bool) {
+	locations := contentRegex.FindAllIndex(content, -1)
+	if len(locations) == 0 {
+		return scantron.RegexMatch{}, false
+	}
+
+	fw.logger.Debugf("Content of %s matches %s", path, contentRegex.String())
+	start, end := locations[0][0], locations[0][1]
+	return scantron.RegexMatch{
+		ContentRegex: contentRegex.String(),
+		LineNumber:   bytes.Count(content[:start], []byte("\n")) + 1,
+		ByteOffset:   int64(start),
+		MatchCount:   len(locations),
+		Excerpt:      excerpt(content, start, end, fw.excerptMask),
+	}, true
+}
+
+// readContent reads at most max bytes of the file, which is all of it since
+// larger files are not checked, in case it has grown since it was walked.
+func readContent(path string, max int64) ([]byte, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer f.Close()
 
-	rr := bufio.NewReader(f)
-	return contentRegex.MatchReader(rr), nil
+	return ioutil.ReadAll(io.LimitReader(f, max))
 }
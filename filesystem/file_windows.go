@@ -1,10 +1,11 @@
-//+build windows
+// +build windows
 
 package filesystem
 
 import (
 	"fmt"
 	"github.com/hectane/go-acl/api"
+	"github.com/pivotal-cf/scantron"
 	"golang.org/x/sys/windows"
 	"os"
 )
@@ -18,11 +19,17 @@ func GetFileMetadata() FileMetadata {
 
 func GetFileConfig() FileConfig {
 	return FileConfig{
-		RootPath:      "C:\\",
+		RootPaths:     []string{"C:\\"},
 		ExcludedPaths: []string{},
 	}
 }
 
+// deviceOf does not know the devices of files on Windows, so the whole tree
+// below a root is always walked.
+func deviceOf(_ os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
 func (f *metadata) GetUser(path string, fileInfo os.FileInfo) (string, error) {
 	ownerSid, _, err := f.getSids(path, fileInfo)
 	if err != nil {
@@ -39,6 +46,11 @@ func (f *metadata) GetGroup(path string, fileInfo os.FileInfo) (string, error) {
 	return f.lookupSid(groupSid)
 }
 
+// GetCapabilities returns nothing since Windows has no file capabilities
+func (f *metadata) GetCapabilities(_ string, _ os.FileInfo) (*scantron.FileCapabilities, error) {
+	return nil, nil
+}
+
 func (f *metadata) getSids(path string, fileInfo os.FileInfo) (*windows.SID, *windows.SID, error) {
 	var (
 		owner *windows.SID
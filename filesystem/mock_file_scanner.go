@@ -6,6 +6,7 @@ package filesystem
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	scantron "github.com/pivotal-cf/scantron"
 	os "os"
 	reflect "reflect"
 	time "time"
@@ -60,6 +61,19 @@ func (mr *MockFileMetadataMockRecorder) GetGroup(path, fileInfo interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockFileMetadata)(nil).GetGroup), path, fileInfo)
 }
 
+// GetCapabilities mocks base method
+func (m *MockFileMetadata) GetCapabilities(path string, fileInfo os.FileInfo) (*scantron.FileCapabilities, error) {
+	ret := m.ctrl.Call(m, "GetCapabilities", path, fileInfo)
+	ret0, _ := ret[0].(*scantron.FileCapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCapabilities indicates an expected call of GetCapabilities
+func (mr *MockFileMetadataMockRecorder) GetCapabilities(path, fileInfo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCapabilities", reflect.TypeOf((*MockFileMetadata)(nil).GetCapabilities), path, fileInfo)
+}
+
 // GetModifiedTime mocks base method
 func (m *MockFileMetadata) GetModifiedTime(path string, fileInfo os.FileInfo) time.Time {
 	ret := m.ctrl.Call(m, "GetModifiedTime", path, fileInfo)
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseMountInfo reads /proc/<pid>/mountinfo and returns the filesystem type
+// mounted at each mount point. The last mount wins when mounts are stacked.
+func ParseMountInfo(r io.Reader) (map[string]string, error) {
+	types := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+
+		separator := -1
+		for i, field := range fields {
+			if field == "-" {
+				separator = i
+				break
+			}
+		}
+
+		if len(fields) < 5 || separator == -1 || separator+1 >= len(fields) {
+			return nil, fmt.Errorf("malformed mountinfo line %q", scanner.Text())
+		}
+
+		types[unescapeMountPath(fields[4])] = fields[separator+1]
+	}
+
+	return types, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes the kernel uses for spaces,
+// tabs, newlines and backslashes in paths.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(path[i])
+	}
+
+	return unescaped.String()
+}
@@ -0,0 +1,32 @@
+package filesystem_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/filesystem"
+)
+
+var _ = Describe("ParseMountInfo", func() {
+	It("returns the filesystem type of each mount point", func() {
+		types, err := filesystem.ParseMountInfo(strings.NewReader(`22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:5 - proc proc rw
+45 22 0:40 / /var/vcap/data/grootfs/store/unprivileged/images/abc/rootfs rw,relatime - overlay overlay rw,lowerdir=/a,upperdir=/b
+46 22 0:41 / /mnt/my\040share rw,relatime shared:30 master:2 - fuse.sshfs host:/share rw,user_id=0
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(types).To(Equal(map[string]string{
+			"/":     "ext4",
+			"/proc": "proc",
+			"/var/vcap/data/grootfs/store/unprivileged/images/abc/rootfs": "overlay",
+			"/mnt/my share": "fuse.sshfs",
+		}))
+	})
+
+	It("returns an error for malformed lines", func() {
+		_, err := filesystem.ParseMountInfo(strings.NewReader("22 1 8:1 / /\n"))
+		Expect(err).To(MatchError(ContainSubstring("malformed mountinfo line")))
+	})
+})
@@ -0,0 +1,299 @@
+// Package firewall reads the rules iptables and nftables apply to traffic
+// coming in to the machine, to tell which listening ports they block.
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// The tools whose rulesets are collected.
+const (
+	Iptables  = "iptables"
+	Ip6tables = "ip6tables"
+	Nft       = "nft"
+)
+
+const (
+	verdictAccept = "accept"
+	verdictDrop   = "drop"
+	verdictReturn = "return"
+	verdictJump   = "jump"
+	verdictGoto   = "goto"
+)
+
+// maxJumps stops rulesets whose chains jump to each other forever.
+const maxJumps = 16
+
+// Firewall is the chains that new connections from other machines pass
+// through. Rules are read conservatively: connections are only blocked when
+// every rule they might match agrees, so that exposed ports are never
+// reported as blocked.
+type Firewall struct {
+	inputs []*chain
+}
+
+type chain struct {
+	// name is how the chain is described, like "iptables INPUT".
+	name string
+
+	ipv4, ipv6 bool
+
+	// policy is what happens to packets which reach the end of a base
+	// chain.
+	policy string
+	rules  []rule
+}
+
+type portRange struct {
+	from, to int
+}
+
+type rule struct {
+	text string
+
+	// protocol and ports are empty when the rule matches any.
+	protocol string
+	ports    []portRange
+
+	// local rules only match traffic which is not a new connection from
+	// another machine, like that on the loopback interface or of
+	// established connections.
+	local bool
+
+	// narrowed rules only match some new connections, like those from
+	// certain addresses, in ways this package does not follow.
+	narrowed bool
+
+	verdict string
+	target  string
+	jump    *chain
+}
+
+func (r rule) matches(protocol string, port int) bool {
+	if r.protocol != "" && r.protocol != protocol {
+		return false
+	}
+
+	if len(r.ports) == 0 {
+		return true
+	}
+
+	for _, p := range r.ports {
+		if port >= p.from && port <= p.to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evaluate is the verdict of the chain for a new connection to a port, or ""
+// if it reaches the end of the chain or returns.
+func (c *chain) evaluate(protocol string, port int, depth int) (string, string) {
+	if depth > maxJumps {
+		return "", ""
+	}
+
+	for _, r := range c.rules {
+		if r.local || !r.matches(protocol, port) {
+			continue
+		}
+
+		switch r.verdict {
+		case verdictAccept:
+			return verdictAccept, c.name + ": " + r.text
+		case verdictDrop:
+			if r.narrowed {
+				continue
+			}
+			return verdictDrop, c.name + ": " + r.text
+		case verdictReturn:
+			return "", ""
+		case verdictJump, verdictGoto:
+			verdict, text := r.jump.evaluate(protocol, port, depth+1)
+			if verdict == verdictAccept || (verdict == verdictDrop && !r.narrowed) {
+				return verdict, text
+			}
+			if r.verdict == verdictGoto && !r.narrowed {
+				return "", ""
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// Parse reads the rulesets printed by iptables-save, ip6tables-save and nft
+// list ruleset.
+func Parse(rulesets []scantron.FirewallRuleset) *Firewall {
+	f := &Firewall{}
+
+	for _, ruleset := range rulesets {
+		switch ruleset.Tool {
+		case Iptables, Ip6tables:
+			f.inputs = append(f.inputs, parseIptables(ruleset.Tool, ruleset.Rules)...)
+		case Nft:
+			f.inputs = append(f.inputs, parseNft(ruleset.Rules)...)
+		}
+	}
+
+	return f
+}
+
+// Blocks is whether new connections from other machines to a tcp or udp port
+// are dropped or rejected, and the rule or chain policy which does it.
+func (f *Firewall) Blocks(protocol string, port int, ipv6 bool) (bool, string) {
+	for _, c := range f.inputs {
+		if (ipv6 && !c.ipv6) || (!ipv6 && !c.ipv4) {
+			continue
+		}
+
+		verdict, text := c.evaluate(protocol, port, 0)
+		if verdict == "" {
+			verdict, text = c.policy, c.name+": policy "+c.policy
+		}
+
+		if verdict == verdictDrop {
+			return true, text
+		}
+	}
+
+	return false, ""
+}
+
+// Annotate records on each port listened on by the host whether the firewall
+// blocks connections to it.
+func (f *Firewall) Annotate(processes []scantron.Process) {
+	for i := range processes {
+		for j := range processes[i].Ports {
+			port := &processes[i].Ports[j]
+
+			if port.ContainerID != "" || !listening(*port) {
+				continue
+			}
+
+			ip := net.ParseIP(port.Address)
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+
+			protocol := strings.TrimSuffix(strings.ToLower(port.Protocol), "6")
+
+			if ip.To4() != nil {
+				port.FirewallBlocked, port.FirewallRule = f.Blocks(protocol, port.Number, false)
+				continue
+			}
+
+			blocked, text := f.Blocks(protocol, port.Number, true)
+
+			// Sockets on :: accept IPv4 connections too
+			if blocked && ip.IsUnspecified() {
+				blocked, text = f.Blocks(protocol, port.Number, false)
+			}
+
+			port.FirewallBlocked, port.FirewallRule = blocked, text
+		}
+	}
+}
+
+func listening(port scantron.Port) bool {
+	if strings.HasPrefix(strings.ToLower(port.Protocol), "udp") {
+		return port.State == ""
+	}
+
+	return port.State == "LISTEN"
+}
+
+// parsePorts reads ports like 22, 8000:8100 or 8000-8100, and names of
+// services like ssh.
+func parsePorts(protocol string, list []string) ([]portRange, error) {
+	ranges := []portRange{}
+	for _, item := range list {
+		bounds := strings.FieldsFunc(item, func(r rune) bool { return r == ':' || r == '-' })
+		if len(bounds) == 0 || len(bounds) > 2 {
+			return nil, fmt.Errorf("malformed port %q", item)
+		}
+
+		numbers := []int{}
+		for _, bound := range bounds {
+			number, err := strconv.Atoi(bound)
+			if err != nil {
+				number, err = net.LookupPort(protocol, bound)
+				if err != nil {
+					return nil, err
+				}
+			}
+			numbers = append(numbers, number)
+		}
+
+		r := portRange{from: numbers[0], to: numbers[len(numbers)-1]}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+// localAddress is whether an address or network only has loopback addresses.
+func localAddress(address string) bool {
+	ip, _, err := net.ParseCIDR(address)
+	if err != nil {
+		ip = net.ParseIP(address)
+	}
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// anyAddress is whether a network has every address.
+func anyAddress(address string) bool {
+	return address == "0.0.0.0/0" || address == "::/0"
+}
+
+// onlyEstablished is whether connection tracking states never include new
+// connections.
+func onlyEstablished(states string) bool {
+	for _, state := range strings.Split(strings.ToLower(states), ",") {
+		if strings.TrimSpace(state) == "new" || strings.TrimSpace(state) == "untracked" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tokenize splits a rule into words, keeping quoted strings, like comments,
+// together.
+func tokenize(line string) []string {
+	tokens := []string{}
+	var (
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if started {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
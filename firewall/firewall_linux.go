@@ -0,0 +1,52 @@
+// +build !windows
+
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+var commands = []struct {
+	tool string
+	args []string
+}{
+	{Iptables, []string{"iptables-save"}},
+	{Ip6tables, []string{"ip6tables-save"}},
+	{Nft, []string{"nft", "list", "ruleset"}},
+}
+
+// Collect returns the rulesets of whichever of iptables and nftables the
+// machine has. Every tool is run even if others fail, and the rulesets that
+// could be read are returned along with the errors of those that could not.
+func Collect() ([]scantron.FirewallRuleset, error) {
+	rulesets := []scantron.FirewallRuleset{}
+	failures := []string{}
+
+	for _, command := range commands {
+		if _, err := exec.LookPath(command.args[0]); err != nil {
+			continue
+		}
+
+		output, err := exec.Command(command.args[0], command.args[1:]...).CombinedOutput()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("failed to run %s: %s: %s", command.args[0], err, strings.TrimSpace(string(output))))
+			continue
+		}
+
+		rulesets = append(rulesets, scantron.FirewallRuleset{
+			Tool:  command.tool,
+			Rules: string(output),
+		})
+	}
+
+	if len(failures) > 0 {
+		return rulesets, errors.New(strings.Join(failures, "; "))
+	}
+
+	return rulesets, nil
+}
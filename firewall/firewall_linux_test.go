@@ -0,0 +1,63 @@
+package firewall_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/firewall"
+)
+
+var _ = Describe("Collect", func() {
+	var (
+		bin  string
+		path string
+	)
+
+	tool := func(name, script string) {
+		err := ioutil.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script+"\n"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		bin, err = ioutil.TempDir("", "firewall")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", bin)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(bin)
+	})
+
+	It("returns the rulesets of the tools the machine has", func() {
+		tool("iptables-save", `echo "*filter"`)
+
+		rulesets, err := firewall.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rulesets).To(Equal([]scantron.FirewallRuleset{{Tool: firewall.Iptables, Rules: "*filter\n"}}))
+	})
+
+	It("runs the other tools when one fails", func() {
+		tool("iptables-save", `echo "iptables modules are not loaded" >&2; exit 1`)
+		tool("ip6tables-save", `echo "*filter"`)
+		tool("nft", `echo "table inet filter {"; echo "}"`)
+
+		rulesets, err := firewall.Collect()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed to run iptables-save"))
+		Expect(err.Error()).To(ContainSubstring("iptables modules are not loaded"))
+
+		Expect(rulesets).To(Equal([]scantron.FirewallRuleset{
+			{Tool: firewall.Ip6tables, Rules: "*filter\n"},
+			{Tool: firewall.Nft, Rules: "table inet filter {\n}\n"},
+		}))
+	})
+})
@@ -0,0 +1,182 @@
+package firewall_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/firewall"
+)
+
+const iptablesSave = `# Generated by iptables-save v1.8.7 on Mon Jan  8 10:00:00 2024
+*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -p tcp --dport 9999 -j DROP
+COMMIT
+*filter
+:INPUT DROP [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+:services - [0:0]
+:blocklist - [0:0]
+-A INPUT -i lo -j ACCEPT
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 22 -m comment --comment "ssh from anywhere" -j ACCEPT
+-A INPUT -s 10.0.0.0/8 -p tcp -m tcp --dport 5432 -j ACCEPT
+-A INPUT -j blocklist
+-A INPUT -j services
+-A INPUT -j LOG --log-prefix "dropped: "
+-A services -p tcp -m multiport --dports 80,443,8000:8100 -j ACCEPT
+-A services -p udp -m udp --dport 53 -j ACCEPT
+-A blocklist -s 192.168.0.0/16 -j DROP
+-A blocklist -p tcp -m tcp --dport 6379 -j REJECT --reject-with tcp-reset
+COMMIT
+`
+
+const nftRuleset = `table inet filter {
+	set allowed_ports {
+		type inet_service
+		elements = { 9000,
+			     9001 }
+	}
+
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		iifname "lo" accept
+		tcp dport { 22, 443 } counter packets 10 bytes 600 accept
+		ip saddr 10.0.0.0/8 tcp dport 5432 accept
+		jump services
+		tcp dport 3306 drop
+	}
+
+	chain services {
+		udp dport 8000-8100 accept
+		tcp dport 2049 log prefix "nfs " reject with tcp reset
+	}
+}
+table ip6 extra {
+	chain input {
+		type filter hook input priority 10; policy accept;
+		tcp dport 443 drop
+	}
+}
+`
+
+var _ = Describe("Firewall", func() {
+	DescribeTable("iptables rules",
+		func(protocol string, port int, blocked bool, rule string) {
+			f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Iptables, Rules: iptablesSave}})
+
+			isBlocked, text := f.Blocks(protocol, port, false)
+			Expect(isBlocked).To(Equal(blocked))
+			Expect(text).To(Equal(rule))
+		},
+		Entry("accepted port", "tcp", 22, false, ""),
+		Entry("port accepted from some addresses", "tcp", 5432, false, ""),
+		Entry("port accepted in a chain jumped to", "tcp", 8050, false, ""),
+		Entry("udp port accepted in a chain jumped to", "udp", 53, false, ""),
+		Entry("port rejected in a chain jumped to", "tcp", 6379, true, "iptables blocklist: -A blocklist -p tcp -m tcp --dport 6379 -j REJECT --reject-with tcp-reset"),
+		Entry("port dropped by the policy", "tcp", 8080+1000, true, "iptables INPUT: policy drop"),
+		Entry("port of another protocol dropped by the policy", "udp", 22, true, "iptables INPUT: policy drop"),
+		Entry("port dropped in another table", "tcp", 9999, true, "iptables INPUT: policy drop"),
+	)
+
+	It("does not apply iptables rules to IPv6", func() {
+		f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Iptables, Rules: iptablesSave}})
+
+		blocked, _ := f.Blocks("tcp", 6379, true)
+		Expect(blocked).To(BeFalse())
+	})
+
+	DescribeTable("nftables rules",
+		func(protocol string, port int, ipv6 bool, blocked bool, rule string) {
+			f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Nft, Rules: nftRuleset}})
+
+			isBlocked, text := f.Blocks(protocol, port, ipv6)
+			Expect(isBlocked).To(Equal(blocked))
+			Expect(text).To(Equal(rule))
+		},
+		Entry("port in a set", "tcp", 22, false, false, ""),
+		Entry("port accepted from some addresses", "tcp", 5432, false, false, ""),
+		Entry("port range in a chain jumped to", "udp", 8050, false, false, ""),
+		Entry("port rejected in a chain jumped to", "tcp", 2049, false, true, `nft inet filter services: tcp dport 2049 log prefix "nfs " reject with tcp reset`),
+		Entry("port dropped", "tcp", 3306, false, true, "nft inet filter input: tcp dport 3306 drop"),
+		Entry("port dropped by the policy", "tcp", 8080, false, true, "nft inet filter input: policy drop"),
+		Entry("port accepted by one base chain and dropped by another", "tcp", 443, true, true, "nft ip6 extra input: tcp dport 443 drop"),
+		Entry("port accepted by every base chain", "tcp", 443, false, false, ""),
+	)
+
+	It("does not block ports a rule with a named set may accept", func() {
+		ruleset := strings.Replace(nftRuleset, "jump services", "tcp dport @allowed_ports accept", 1)
+		f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Nft, Rules: ruleset}})
+
+		blocked, _ := f.Blocks("tcp", 3306, false)
+		Expect(blocked).To(BeFalse())
+
+		blocked, _ = f.Blocks("udp", 8050, false)
+		Expect(blocked).To(BeTrue())
+	})
+
+	Describe("Annotate", func() {
+		var processes []scantron.Process
+
+		BeforeEach(func() {
+			processes = []scantron.Process{{
+				CommandName: "server",
+				Ports: []scantron.Port{
+					{Protocol: "tcp", Address: "10.0.0.1", Number: 22, State: "LISTEN"},
+					{Protocol: "tcp", Address: "0.0.0.0", Number: 6379, State: "LISTEN"},
+					{Protocol: "tcp", Address: "127.0.0.1", Number: 6380, State: "LISTEN"},
+					{Protocol: "tcp", Address: "10.0.0.1", Number: 6379, ForeignAddress: "10.0.0.2", ForeignNumber: 40000, State: "ESTABLISHED"},
+					{Protocol: "udp", Address: "0.0.0.0", Number: 123},
+					{Protocol: "tcp6", Address: "::", Number: 7000, State: "LISTEN"},
+					{Protocol: "tcp", Address: "10.255.0.2", Number: 8080, State: "LISTEN", ContainerID: "3f4c2b1a9e8d"},
+				},
+			}}
+		})
+
+		It("records which ports are blocked and by what", func() {
+			f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Iptables, Rules: iptablesSave}})
+			f.Annotate(processes)
+
+			blocked := map[int]string{}
+			for _, port := range processes[0].Ports {
+				if port.FirewallBlocked {
+					blocked[port.Number] = port.FirewallRule
+				}
+			}
+
+			Expect(blocked).To(Equal(map[int]string{
+				6379: "iptables blocklist: -A blocklist -p tcp -m tcp --dport 6379 -j REJECT --reject-with tcp-reset",
+				123:  "iptables INPUT: policy drop",
+			}))
+		})
+
+		It("only blocks ports on :: when both IPv4 and IPv6 are blocked", func() {
+			ip6tablesSave := "*filter\n:INPUT DROP [0:0]\nCOMMIT\n"
+
+			f := firewall.Parse([]scantron.FirewallRuleset{{Tool: firewall.Ip6tables, Rules: ip6tablesSave}})
+			f.Annotate(processes)
+			Expect(processes[0].Ports[5].FirewallBlocked).To(BeFalse())
+
+			f = firewall.Parse([]scantron.FirewallRuleset{
+				{Tool: firewall.Iptables, Rules: iptablesSave},
+				{Tool: firewall.Ip6tables, Rules: ip6tablesSave},
+			})
+			f.Annotate(processes)
+			Expect(processes[0].Ports[5].FirewallBlocked).To(BeTrue())
+		})
+
+		It("blocks nothing without rulesets", func() {
+			firewall.Parse(nil).Annotate(processes)
+
+			for _, port := range processes[0].Ports {
+				Expect(port.FirewallBlocked).To(BeFalse())
+			}
+		})
+	})
+})
@@ -0,0 +1,181 @@
+package firewall
+
+import (
+	"strings"
+)
+
+// parseIptables reads the filter table of iptables-save or ip6tables-save
+// output and returns its INPUT chain.
+func parseIptables(tool, text string) []*chain {
+	chains := map[string]*chain{}
+	var rules []chainRule
+	inFilter := false
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "*"):
+			inFilter = line == "*filter"
+		case !inFilter:
+		case strings.HasPrefix(line, ":"):
+			// :INPUT DROP [0:0] declares a chain and its policy, which is
+			// - for chains that are not built in
+			fields := strings.Fields(line[1:])
+			if len(fields) < 2 {
+				continue
+			}
+			chains[fields[0]] = &chain{
+				name:   tool + " " + fields[0],
+				ipv4:   tool == Iptables,
+				ipv6:   tool == Ip6tables,
+				policy: strings.ToLower(fields[1]),
+			}
+		case strings.HasPrefix(line, "-A "):
+			chainName, r := parseIptablesRule(line)
+			rules = append(rules, chainRule{chainName, r})
+		}
+	}
+
+	for _, cr := range rules {
+		c, ok := chains[cr.chain]
+		if !ok {
+			continue
+		}
+
+		r := cr.rule
+		if r.verdict == verdictJump || r.verdict == verdictGoto {
+			r.jump, ok = chains[r.target]
+			if !ok {
+				continue
+			}
+		}
+
+		c.rules = append(c.rules, r)
+	}
+
+	input, ok := chains["INPUT"]
+	if !ok {
+		return nil
+	}
+
+	return []*chain{input}
+}
+
+// chainRule is a rule before the chains it jumps to are known.
+type chainRule struct {
+	chain string
+	rule  rule
+}
+
+// parseIptablesRule reads a rule like -A INPUT -p tcp --dport 22 -j ACCEPT
+// and the chain it is added to.
+func parseIptablesRule(line string) (string, rule) {
+	r := rule{text: line}
+	chainName := ""
+	tokens := tokenize(line)
+
+	var ports []string
+	negated := false
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := func() string {
+			if i+1 < len(tokens) {
+				i++
+				return tokens[i]
+			}
+			return ""
+		}
+
+		if token == "!" {
+			negated = true
+			continue
+		}
+
+		switch token {
+		case "-A", "--append":
+			chainName = next()
+		case "-p", "--protocol":
+			protocol := strings.ToLower(next())
+			if negated {
+				r.narrowed = true
+			} else if protocol != "all" {
+				r.protocol = protocol
+			}
+		case "--dport", "--destination-port", "--dports", "--destination-ports":
+			if negated {
+				r.narrowed = true
+				next()
+			} else {
+				ports = strings.Split(next(), ",")
+			}
+		case "-i", "--in-interface":
+			iface := next()
+			if iface == "lo" && !negated {
+				r.local = true
+			} else {
+				r.narrowed = true
+			}
+		case "-s", "--source":
+			source := next()
+			switch {
+			case negated:
+				r.narrowed = true
+			case localAddress(source):
+				r.local = true
+			case !anyAddress(source):
+				r.narrowed = true
+			}
+		case "--ctstate", "--state":
+			states := next()
+			if negated {
+				r.narrowed = true
+			} else if onlyEstablished(states) {
+				r.local = true
+			}
+		case "-m", "--match":
+			// Loading a match module does not match anything by itself
+			next()
+		case "--comment":
+			next()
+		case "-j", "--jump", "-g", "--goto":
+			target := next()
+			switch strings.ToUpper(target) {
+			case "ACCEPT":
+				r.verdict = verdictAccept
+			case "DROP", "REJECT":
+				r.verdict = verdictDrop
+			case "RETURN":
+				r.verdict = verdictReturn
+			case "LOG", "NFLOG", "MARK", "CONNMARK", "CT", "NOTRACK", "TRACE", "AUDIT":
+				// Extensions that let packets carry on
+			default:
+				r.verdict = verdictJump
+				if token == "-g" || token == "--goto" {
+					r.verdict = verdictGoto
+				}
+				r.target = target
+			}
+		case "--reject-with", "--log-prefix", "--log-level", "--set-mark", "--set-xmark":
+			next()
+		default:
+			// Anything else, like -d or -m recent, limits the rule in ways
+			// that are not followed
+			r.narrowed = true
+		}
+
+		negated = false
+	}
+
+	if len(ports) > 0 {
+		ranges, err := parsePorts(r.protocol, ports)
+		if err != nil {
+			r.narrowed = true
+		} else {
+			r.ports = ranges
+		}
+	}
+
+	return chainName, r
+}
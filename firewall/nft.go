@@ -0,0 +1,308 @@
+package firewall
+
+import (
+	"strings"
+)
+
+// parseNft reads nft list ruleset output and returns the base chains that
+// filter packets coming in to the machine, from every ip, ip6 and inet table.
+func parseNft(text string) []*chain {
+	var (
+		inputs []*chain
+
+		family, table string
+		chains        map[string]*chain
+		current       *chain
+		skipDepth     int
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Sets, maps and other objects in tables are skipped, including
+		// elements which span several lines
+		if skipDepth > 0 {
+			skipDepth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+
+		tokens := tokenize(line)
+
+		switch {
+		case chains == nil && tokens[0] == "table":
+			family, table = "ip", tokens[1]
+			if len(tokens) > 3 {
+				family, table = tokens[1], tokens[2]
+			}
+			chains = map[string]*chain{}
+
+		case chains == nil:
+
+		case current == nil && tokens[0] == "chain":
+			current = &chain{
+				name:   strings.Join([]string{Nft, family, table, tokens[1]}, " "),
+				ipv4:   family == "ip" || family == "inet",
+				ipv6:   family == "ip6" || family == "inet",
+				policy: verdictAccept,
+			}
+			chains[tokens[1]] = current
+
+		case current == nil && line == "}":
+			resolveJumps(chains)
+			chains = nil
+
+		case current == nil:
+			skipDepth = strings.Count(line, "{") - strings.Count(line, "}")
+
+		case line == "}":
+			current = nil
+
+		case tokens[0] == "type":
+			// type filter hook input priority filter; policy drop;
+			fields := strings.Fields(strings.Replace(line, ";", " ", -1))
+			hook, policy := "", ""
+			for i := 0; i+1 < len(fields); i++ {
+				switch fields[i] {
+				case "hook":
+					hook = fields[i+1]
+				case "policy":
+					policy = fields[i+1]
+				}
+			}
+
+			if policy != "" {
+				current.policy = verdictAccept
+				if policy == "drop" {
+					current.policy = verdictDrop
+				}
+			}
+			if fields[1] == "filter" && hook == "input" && (current.ipv4 || current.ipv6) {
+				inputs = append(inputs, current)
+			}
+
+		default:
+			current.rules = append(current.rules, parseNftRule(line))
+		}
+	}
+
+	return inputs
+}
+
+// resolveJumps links rules to the chains they jump to, leaving out those
+// whose chain does not exist.
+func resolveJumps(chains map[string]*chain) {
+	for _, c := range chains {
+		rules := []rule{}
+		for _, r := range c.rules {
+			if r.verdict == verdictJump || r.verdict == verdictGoto {
+				jump, ok := chains[r.target]
+				if !ok {
+					continue
+				}
+				r.jump = jump
+			}
+			rules = append(rules, r)
+		}
+		c.rules = rules
+	}
+}
+
+// parseNftRule reads a rule like tcp dport { 80, 443 } accept.
+func parseNftRule(line string) rule {
+	r := rule{text: line}
+	tokens := tokenize(line)
+
+	i := 0
+	peek := func() string {
+		if i+1 < len(tokens) {
+			return tokens[i+1]
+		}
+		return ""
+	}
+	next := func() string {
+		if i+1 < len(tokens) {
+			i++
+			return tokens[i]
+		}
+		return ""
+	}
+
+	// value reads the value of a match, which may be a set of values like
+	// { 80, 443 }, and whether the match is negated.
+	value := func() ([]string, bool) {
+		negated := false
+		switch peek() {
+		case "!=":
+			negated = true
+			next()
+		case "==":
+			next()
+		}
+
+		token := next()
+		if token != "{" {
+			return splitValues(token), negated
+		}
+
+		values := []string{}
+		for i+1 < len(tokens) {
+			token = next()
+			if token == "}" {
+				break
+			}
+			values = append(values, splitValues(token)...)
+		}
+		return values, negated
+	}
+
+	var ports []string
+	for ; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "tcp", "udp", "th":
+			protocol := tokens[i]
+			if next() != "dport" {
+				r.narrowed = true
+				continue
+			}
+
+			if protocol != "th" {
+				r.protocol = protocol
+			}
+
+			values, negated := value()
+			if negated || isSetReference(values) {
+				r.narrowed = true
+				continue
+			}
+			ports = values
+
+		case "meta", "ip", "ip6":
+			switch next() {
+			case "l4proto", "protocol", "nexthdr":
+				values, negated := value()
+				if negated || isSetReference(values) {
+					r.narrowed = true
+				} else if len(values) == 1 {
+					r.protocol = values[0]
+				}
+			case "iif", "iifname":
+				r.matchInterface(value())
+			case "saddr":
+				r.matchSource(value())
+			case "nftrace":
+				next()
+				next()
+			default:
+				r.narrowed = true
+			}
+
+		case "iif", "iifname":
+			r.matchInterface(value())
+
+		case "ct":
+			if next() != "state" {
+				r.narrowed = true
+				continue
+			}
+
+			values, negated := value()
+			if negated {
+				r.narrowed = true
+			} else if onlyEstablished(strings.Join(values, ",")) {
+				r.local = true
+			}
+
+		case "counter":
+			for peek() == "packets" || peek() == "bytes" {
+				next()
+				next()
+			}
+
+		case "log":
+			for peek() == "prefix" || peek() == "level" || peek() == "flags" || peek() == "group" {
+				next()
+				next()
+			}
+
+		case "comment":
+			next()
+
+		case "accept":
+			r.verdict = verdictAccept
+
+		case "drop":
+			r.verdict = verdictDrop
+
+		case "reject":
+			// What is sent back, like reject with tcp reset, does not matter
+			r.verdict = verdictDrop
+			i = len(tokens)
+
+		case "return":
+			r.verdict = verdictReturn
+
+		case "jump", "goto":
+			r.verdict = tokens[i]
+			r.target = next()
+
+		default:
+			// Anything else, like limit rate or fib, limits the rule in ways
+			// that are not followed
+			r.narrowed = true
+		}
+	}
+
+	if len(ports) > 0 {
+		ranges, err := parsePorts(r.protocol, ports)
+		if err != nil {
+			r.narrowed = true
+		} else {
+			r.ports = ranges
+		}
+	}
+
+	return r
+}
+
+func (r *rule) matchInterface(values []string, negated bool) {
+	if !negated && len(values) == 1 && values[0] == "lo" {
+		r.local = true
+	} else {
+		r.narrowed = true
+	}
+}
+
+func (r *rule) matchSource(values []string, negated bool) {
+	switch {
+	case negated || isSetReference(values):
+		r.narrowed = true
+	case len(values) == 1 && localAddress(values[0]):
+		r.local = true
+	case len(values) != 1 || !anyAddress(values[0]):
+		r.narrowed = true
+	}
+}
+
+func splitValues(token string) []string {
+	values := []string{}
+	for _, v := range strings.Split(token, ",") {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// isSetReference is whether a value is a named set, like @allowed_ports,
+// whose elements are not followed.
+func isSetReference(values []string) bool {
+	for _, v := range values {
+		if strings.HasPrefix(v, "@") {
+			return true
+		}
+	}
+	return false
+}
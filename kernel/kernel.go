@@ -0,0 +1,114 @@
+// Package kernel reads the version, boot parameters, modules and hardening
+// sysctls of the running Linux kernel from /proc.
+package kernel
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// Sysctls are the kernel parameters recorded by scans, since they decide
+// whether the machine routes packets, leaks kernel addresses or writes core
+// dumps of privileged processes.
+var Sysctls = []string{
+	"net.ipv4.ip_forward",
+	"net.ipv6.conf.all.forwarding",
+	"net.ipv4.conf.all.rp_filter",
+	"net.ipv4.conf.default.rp_filter",
+	"net.ipv4.conf.all.accept_redirects",
+	"net.ipv4.conf.all.send_redirects",
+	"net.ipv4.conf.all.accept_source_route",
+	"net.ipv4.tcp_syncookies",
+	"kernel.kptr_restrict",
+	"kernel.dmesg_restrict",
+	"kernel.randomize_va_space",
+	"kernel.yama.ptrace_scope",
+	"kernel.unprivileged_bpf_disabled",
+	"kernel.sysrq",
+	"kernel.core_pattern",
+	"fs.suid_dumpable",
+	"fs.protected_hardlinks",
+	"fs.protected_symlinks",
+}
+
+// Scan reads the kernel from a proc filesystem mounted at procDir.
+// Parameters the kernel does not have, like those of modules which are not
+// loaded, are left out.
+func Scan(procDir string) (*scantron.Kernel, error) {
+	kernel := &scantron.Kernel{
+		Modules: []string{},
+		Sysctls: map[string]string{},
+	}
+
+	var err error
+	kernel.Release, err = readValue(filepath.Join(procDir, "sys", "kernel", "osrelease"))
+	if err != nil {
+		return nil, err
+	}
+
+	kernel.Version, err = readValue(filepath.Join(procDir, "sys", "kernel", "version"))
+	if err != nil {
+		return nil, err
+	}
+
+	kernel.BootParameters, err = readValue(filepath.Join(procDir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Kernels built without module support have no /proc/modules
+	modules, err := os.Open(filepath.Join(procDir, "modules"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer modules.Close()
+
+		scanner := bufio.NewScanner(modules)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 0 {
+				kernel.Modules = append(kernel.Modules, fields[0])
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range Sysctls {
+		value, err := readValue(filepath.Join(procDir, "sys", strings.Replace(name, ".", "/", -1)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		kernel.Sysctls[name] = value
+	}
+
+	return kernel, nil
+}
+
+// readValue reads a file with one value, as sysctl prints it, with tabs
+// between the numbers of parameters that have several.
+func readValue(path string) (string, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return NormalizeValue(string(bs)), nil
+}
+
+// NormalizeValue puts single spaces between the words of a value so that
+// values can be compared however they were written.
+func NormalizeValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,9 @@
+// +build !windows
+
+package kernel
+
+import "github.com/pivotal-cf/scantron"
+
+func Collect() (*scantron.Kernel, error) {
+	return Scan("/proc")
+}
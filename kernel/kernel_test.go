@@ -0,0 +1,79 @@
+package kernel_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/kernel"
+)
+
+var _ = Describe("Kernel", func() {
+	var procDir string
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(procDir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		procDir, err = ioutil.TempDir("", "kernel")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeFile("sys/kernel/osrelease", "5.15.0-91-generic\n")
+		writeFile("sys/kernel/version", "#101-Ubuntu SMP Tue Nov 14 13:30:08 UTC 2023\n")
+		writeFile("cmdline", "BOOT_IMAGE=/vmlinuz-5.15.0-91-generic root=UUID=abc ro apparmor=1\n")
+		writeFile("modules", "overlay 151552 1 - Live 0x0000000000000000\n"+
+			"br_netfilter 32768 0 - Live 0x0000000000000000\n")
+		writeFile("sys/net/ipv4/ip_forward", "1\n")
+		writeFile("sys/kernel/kptr_restrict", "1\n")
+		writeFile("sys/kernel/core_pattern", "|/usr/share/apport/apport  -p%p -s%s\n")
+		writeFile("sys/net/ipv4/conf/all/rp_filter", "2\n")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(procDir)
+	})
+
+	It("reads the kernel version, boot parameters and modules", func() {
+		k, err := kernel.Scan(procDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k.Release).To(Equal("5.15.0-91-generic"))
+		Expect(k.Version).To(Equal("#101-Ubuntu SMP Tue Nov 14 13:30:08 UTC 2023"))
+		Expect(k.BootParameters).To(Equal("BOOT_IMAGE=/vmlinuz-5.15.0-91-generic root=UUID=abc ro apparmor=1"))
+		Expect(k.Modules).To(Equal([]string{"overlay", "br_netfilter"}))
+	})
+
+	It("reads the sysctls the kernel has", func() {
+		k, err := kernel.Scan(procDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k.Sysctls).To(Equal(map[string]string{
+			"net.ipv4.ip_forward":         "1",
+			"net.ipv4.conf.all.rp_filter": "2",
+			"kernel.kptr_restrict":        "1",
+			"kernel.core_pattern":         "|/usr/share/apport/apport -p%p -s%s",
+		}))
+	})
+
+	It("records no modules when the kernel does not support them", func() {
+		Expect(os.Remove(filepath.Join(procDir, "modules"))).To(Succeed())
+
+		k, err := kernel.Scan(procDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k.Modules).To(BeEmpty())
+	})
+
+	It("fails when the kernel release cannot be read", func() {
+		Expect(os.Remove(filepath.Join(procDir, "sys/kernel/osrelease"))).To(Succeed())
+
+		_, err := kernel.Scan(procDir)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,10 @@
+// +build windows
+
+package kernel
+
+import "github.com/pivotal-cf/scantron"
+
+// Collect returns no kernel since Windows has no /proc.
+func Collect() (*scantron.Kernel, error) {
+	return nil, nil
+}
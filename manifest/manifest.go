@@ -7,6 +7,14 @@ type Manifest struct {
 type Spec struct {
 	Prefix    string `yaml:"prefix"`
 	Processes []Process
+	Kernel    *Kernel `yaml:"kernel,omitempty"`
+}
+
+// Kernel is the kernel settings hosts are expected to have. Sysctls are by
+// the name sysctl gives them, like net.ipv4.ip_forward.
+type Kernel struct {
+	Sysctls        map[string]string `yaml:"sysctls,omitempty"`
+	BootParameters []string          `yaml:"boot_parameters,omitempty"`
 }
 
 type Process struct {
@@ -2,9 +2,12 @@ package manifest
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 
 	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pivotal-cf/scantron/kernel"
 )
 
 func Parse(filePath string) (Manifest, error) {
@@ -44,6 +47,23 @@ func validate(m Manifest) error {
 				}
 			}
 		}
+
+		if spec.Kernel != nil {
+			for name := range spec.Kernel.Sysctls {
+				if !collectedSysctl(name) {
+					return fmt.Errorf("sysctl %s is not collected by scans", name)
+				}
+			}
+		}
 	}
 	return nil
 }
+
+func collectedSysctl(name string) bool {
+	for _, sysctl := range kernel.Sysctls {
+		if sysctl == name {
+			return true
+		}
+	}
+	return false
+}
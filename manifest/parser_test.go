@@ -38,6 +38,13 @@ var _ = Describe("Parser", func() {
 							Ports:   []manifest.Port{9876, 5432},
 						},
 					},
+					Kernel: &manifest.Kernel{
+						Sysctls: map[string]string{
+							"net.ipv4.ip_forward":  "0",
+							"kernel.kptr_restrict": "1",
+						},
+						BootParameters: []string{"apparmor=1"},
+					},
 				},
 			},
 		}))
@@ -76,6 +83,12 @@ var _ = Describe("Parser", func() {
 			Expect(err).To(MatchError("process info missing"))
 		})
 
+		It("returns an error when a sysctl is not one scans collect", func() {
+			_, err := manifest.Parse("semantic_err_sysctl.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError("sysctl net.ipv4.conf.all.log_martians is not collected by scans"))
+		})
+
 		It("returns an error when processes are undefined", func() {
 			_, err := manifest.Parse("semantic_err_processes.yml")
 			Expect(err).To(HaveOccurred())
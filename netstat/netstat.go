@@ -1,26 +1,24 @@
 // +build !windows
 
+// Package netstat reads the sockets on a machine from /proc rather than
+// running netstat, which is missing from newer stemcells.
 package netstat
 
 import (
 	"bufio"
-	"log"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/pivotal-cf/scantron"
 )
 
-type NetstatInfo struct {
-	CommandName    string
-	PID            string
-	LocalAddress   string
-	ForeignAddress string
-	State          string
-	Protocol       string
-}
-
 type NetstatPort struct {
 	PID  int
 	Port scantron.Port
@@ -28,103 +26,331 @@ type NetstatPort struct {
 
 type NetstatPorts []NetstatPort
 
-func ParseNetstatOutputForPort(output string) []NetstatPort {
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	result := []NetstatPort{}
+type NetstatUnixSocket struct {
+	PID    int
+	Socket UnixSocket
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		info, valid := parseNetstatLine(line)
+// Socket is a row of one of the TCP or UDP socket tables in /proc/net.
+type Socket struct {
+	Protocol      string
+	LocalAddress  string
+	LocalPort     int
+	RemoteAddress string
+	RemotePort    int
+	State         string
+	Inode         uint64
+}
+
+// UnixSocket is a row of /proc/net/unix.
+type UnixSocket struct {
+	Path      string
+	Type      string
+	Listening bool
+	Inode     uint64
+}
+
+var portTables = []string{"tcp", "tcp6", "udp", "udp6"}
+
+// The states are named the way netstat names them.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
 
-		if valid {
-			result = append(result, createNetstatPort(info))
+var unixSocketTypes = map[string]string{
+	"0001": "stream",
+	"0002": "dgram",
+	"0005": "seqpacket",
+}
+
+// The kernel's __SO_ACCEPTCON flag, set on sockets which are listening
+const unixAcceptConnections = 0x10000
+
+// ReadPorts finds the TCP and UDP ports in the network namespace of each of
+// pids, along with the process which owns each one. procRoot is usually
+// /proc. Sockets which no process owns, such as those in TIME_WAIT, are left
+// out.
+func ReadPorts(procRoot string, pids []string) (NetstatPorts, error) {
+	owners := SocketOwners(procRoot)
+	ports := NetstatPorts{}
+
+	for _, pid := range pids {
+		for _, table := range portTables {
+			sockets, err := readSockets(filepath.Join(procRoot, pid, "net", table), table)
+			if os.IsNotExist(err) {
+				// Kernels without IPv6 don't have the tables for it
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, socket := range sockets {
+				owner, found := owners[socket.Inode]
+				if !found {
+					continue
+				}
+
+				ports = append(ports, NetstatPort{
+					PID:  owner,
+					Port: socket.Port(),
+				})
+			}
 		}
 	}
 
-	return result
+	return ports, nil
 }
 
-func parseNetstatLine(line string) (NetstatInfo, bool) {
-	netstat := strings.Fields(line)
+// ReadUnixSockets finds the listening Unix domain sockets with a path in the
+// network namespace of each of pids, along with the process which owns each
+// one.
+func ReadUnixSockets(procRoot string, pids []string) ([]NetstatUnixSocket, error) {
+	owners := SocketOwners(procRoot)
+	unixSockets := []NetstatUnixSocket{}
+
+	for _, pid := range pids {
+		file, err := os.Open(filepath.Join(procRoot, pid, "net", "unix"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sockets, err := ParseUnixSockets(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, socket := range sockets {
+			if !socket.Listening || socket.Path == "" {
+				continue
+			}
 
-	var protocol, localAddress, foreignAddress, state, process string
+			owner, found := owners[socket.Inode]
+			if !found {
+				continue
+			}
 
-	if len(netstat) < 6 {
-		return NetstatInfo{}, false
+			unixSockets = append(unixSockets, NetstatUnixSocket{
+				PID:    owner,
+				Socket: socket,
+			})
+		}
 	}
 
-	protocol = netstat[0]
-	localAddress = netstat[3]
-	foreignAddress = netstat[4]
+	return unixSockets, nil
+}
+
+// SocketOwners maps the inode of every open socket to the process which has
+// it open.
+func SocketOwners(procRoot string) map[uint64]int {
+	owners := map[uint64]int{}
 
-	if len(netstat) == 6 {
-		state = ""
-		process = netstat[5]
-	} else {
-		state = netstat[5]
-		process = netstat[6]
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return owners
 	}
 
-	switch protocol {
-	case "tcp", "tcp6", "udp", "udp6":
-		break
-	default:
-		return NetstatInfo{}, false
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// The process has exited or we may not look at it
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			inode, ok := socketInode(link)
+			if !ok {
+				continue
+			}
+
+			if _, found := owners[inode]; !found {
+				owners[inode] = pid
+			}
+		}
 	}
 
-	processTokens := strings.Split(process, "/")
-	if len(processTokens) < 2 {
-		return NetstatInfo{}, false
+	return owners
+}
+
+func socketInode(link string) (uint64, bool) {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return 0, false
 	}
 
-	pid := processTokens[0]
-	cmd := processTokens[1]
+	inode, err := strconv.ParseUint(link[len("socket:["):len(link)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
 
-	return NetstatInfo{
-		CommandName:    cmd,
-		PID:            pid,
-		LocalAddress:   localAddress,
-		ForeignAddress: foreignAddress,
-		State:          state,
-		Protocol:       protocol,
-	}, true
+	return inode, true
 }
 
-func splitAddress(infoAddress string) (string, int) {
-	// XXX(cb): In the netstat output IPv6 addresses are shown as :::22 whereas
-	// Go parsing requires [::]:22. We try and fix this up here but this is
-	// undoubtedly imperfect.
-	conformAddr := strings.Replace(infoAddress, "::", "[::]", 1)
-	address, port, err := net.SplitHostPort(conformAddr)
+func readSockets(path string, protocol string) ([]Socket, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		log.Printf("failed to split address %q: %s", conformAddr, err)
+		return nil, err
 	}
-	number, err := strconv.Atoi(port)
-	if err != nil {
-		number = -1
+	defer file.Close()
+
+	return ParseSockets(file, protocol)
+}
+
+// ParseSockets parses a socket table such as /proc/net/tcp. protocol is the
+// name of the table.
+func ParseSockets(r io.Reader, protocol string) ([]Socket, error) {
+	sockets := []Socket{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+
+		localAddress, localPort, err := parseAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		remoteAddress, remotePort, err := parseAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed inode %q: %s", fields[9], err)
+		}
+
+		sockets = append(sockets, Socket{
+			Protocol:      protocol,
+			LocalAddress:  localAddress,
+			LocalPort:     localPort,
+			RemoteAddress: remoteAddress,
+			RemotePort:    remotePort,
+			State:         socketState(protocol, fields[3]),
+			Inode:         inode,
+		})
+	}
+
+	return sockets, scanner.Err()
+}
+
+// ParseUnixSockets parses /proc/net/unix.
+func ParseUnixSockets(r io.Reader) ([]UnixSocket, error) {
+	sockets := []UnixSocket{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 || fields[0] == "Num" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed flags %q: %s", fields[3], err)
+		}
+
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed inode %q: %s", fields[6], err)
+		}
+
+		socketType, found := unixSocketTypes[fields[4]]
+		if !found {
+			socketType = fields[4]
+		}
+
+		sockets = append(sockets, UnixSocket{
+			Path:      strings.Join(fields[7:], " "),
+			Type:      socketType,
+			Listening: flags&unixAcceptConnections != 0,
+			Inode:     inode,
+		})
 	}
-	return address, number
+
+	return sockets, scanner.Err()
 }
 
-func createPortFromAddress(localAddressAndPort string, foreignAddressAndPort string, protocol string, state string) scantron.Port {
-	localAddress, localNumber := splitAddress(localAddressAndPort)
-	foreignAddress, foreignNumber := splitAddress(foreignAddressAndPort)
+// Port describes the socket the same way netstat did, with -1 for the
+// foreign port of sockets which aren't connected.
+func (s Socket) Port() scantron.Port {
+	foreignNumber := s.RemotePort
+	if foreignNumber == 0 {
+		foreignNumber = -1
+	}
+
 	return scantron.Port{
-		Protocol:       protocol,
-		Address:        localAddress,
-		Number:         localNumber,
-		ForeignAddress: foreignAddress,
+		Protocol:       s.Protocol,
+		Address:        s.LocalAddress,
+		Number:         s.LocalPort,
+		ForeignAddress: s.RemoteAddress,
 		ForeignNumber:  foreignNumber,
-		State:          state,
+		State:          s.State,
+	}
+}
+
+func socketState(protocol, state string) string {
+	if strings.HasPrefix(protocol, "udp") {
+		// netstat only shows a state for connected UDP sockets
+		if state == "01" {
+			return "ESTABLISHED"
+		}
+		return ""
 	}
+
+	return tcpStates[state]
 }
 
-func createNetstatPort(info NetstatInfo) NetstatPort {
-	port := createPortFromAddress(info.LocalAddress, info.ForeignAddress, info.Protocol, info.State)
+// parseAddress parses an address such as 0100007F:1F90. The kernel writes
+// addresses as 32 bit words in host byte order, which is little endian on
+// every platform BOSH runs on. IPv4-mapped IPv6 addresses come out as IPv4
+// addresses so that they match checks for 127.0.0.1.
+func parseAddress(address string) (string, int, error) {
+	parts := strings.Split(address, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("malformed address %q", address)
+	}
+
+	ip, err := hex.DecodeString(parts[0])
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", 0, fmt.Errorf("malformed address %q", address)
+	}
 
-	id, _ := strconv.Atoi(info.PID)
-	return NetstatPort{
-		PID:  id,
-		Port: port,
+	for word := 0; word < len(ip); word += 4 {
+		ip[word], ip[word+1], ip[word+2], ip[word+3] = ip[word+3], ip[word+2], ip[word+1], ip[word]
 	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed port in address %q", address)
+	}
+
+	return net.IP(ip).String(), int(port), nil
 }
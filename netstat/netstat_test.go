@@ -1,111 +1,221 @@
 package netstat_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/netstat"
 )
 
-var _ = Describe("NetstatParser", func() {
-	It("parses and converts a single line correctly", func() {
-		input := `
-tcp        0      0 127.0.0.1:8080          0.0.0.0:*               LISTEN      1317/java
-		`
-		Expect(netstat.ParseNetstatOutputForPort(input)).To(Equal([]netstat.NetstatPort{
-			{
-				PID: 1317,
-				Port: scantron.Port{
-					Protocol:       "tcp",
-					Address:        "127.0.0.1",
-					Number:         8080,
-					ForeignAddress: "0.0.0.0",
-					ForeignNumber:  -1,
-					State:          "LISTEN",
-				},
-			},
-		}))
+const tcpHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+const tcp6Header = "  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+const unixHeader = "Num       RefCount Protocol Flags    Type St Inode Path\n"
+
+var _ = Describe("Netstat", func() {
+	DescribeTable("parsing socket tables",
+		func(protocol, table string, expected netstat.Socket) {
+			sockets, err := netstat.ParseSockets(strings.NewReader(table), protocol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sockets).To(Equal([]netstat.Socket{expected}))
+		},
+		Entry("listening IPv4 TCP", "tcp",
+			tcpHeader+"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 23456 1 0000000000000000 100 0 0 10 0\n",
+			netstat.Socket{Protocol: "tcp", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "0.0.0.0", RemotePort: 0, State: "LISTEN", Inode: 23456}),
+		Entry("established IPv4 TCP", "tcp",
+			tcpHeader+"   1: 0500000A:0016 0100000A:C822 01 00000000:00000000 02:000A7B2C 00000000     0        0 31337 4 0000000000000000 20 4 31 10 -1\n",
+			netstat.Socket{Protocol: "tcp", LocalAddress: "10.0.0.5", LocalPort: 22, RemoteAddress: "10.0.0.1", RemotePort: 51234, State: "ESTABLISHED", Inode: 31337}),
+		Entry("socket in TIME_WAIT without an inode", "tcp",
+			tcpHeader+"   2: 0500000A:0016 0100000A:C823 06 00000000:00000000 03:00000F7E 00000000     0        0 0 3 0000000000000000\n",
+			netstat.Socket{Protocol: "tcp", LocalAddress: "10.0.0.5", LocalPort: 22, RemoteAddress: "10.0.0.1", RemotePort: 51235, State: "TIME_WAIT", Inode: 0}),
+		Entry("listening on every IPv6 address", "tcp6",
+			tcp6Header+"   0: 00000000000000000000000000000000:C383 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 40112 1 0000000000000000 100 0 0 10 0\n",
+			netstat.Socket{Protocol: "tcp6", LocalAddress: "::", LocalPort: 50051, RemoteAddress: "::", RemotePort: 0, State: "LISTEN", Inode: 40112}),
+		Entry("IPv6 loopback", "tcp6",
+			tcp6Header+"   1: 00000000000000000000000001000000:0CEA 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 40113 1 0000000000000000 100 0 0 10 0\n",
+			netstat.Socket{Protocol: "tcp6", LocalAddress: "::1", LocalPort: 3306, RemoteAddress: "::", RemotePort: 0, State: "LISTEN", Inode: 40113}),
+		Entry("IPv4-mapped IPv6 address", "tcp6",
+			tcp6Header+"   2: 0000000000000000FFFF00000100007F:1F90 0000000000000000FFFF00000500000A:D431 01 00000000:00000000 00:00000000 00000000  1000        0 40114 1 0000000000000000 20 4 30 10 -1\n",
+			netstat.Socket{Protocol: "tcp6", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "10.0.0.5", RemotePort: 54321, State: "ESTABLISHED", Inode: 40114}),
+		Entry("global IPv6 address", "tcp6",
+			tcp6Header+"   3: B80D0120000000000000000001000000:01BB 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 40115 1 0000000000000000 100 0 0 10 0\n",
+			netstat.Socket{Protocol: "tcp6", LocalAddress: "2001:db8::1", LocalPort: 443, RemoteAddress: "::", RemotePort: 0, State: "LISTEN", Inode: 40115}),
+		Entry("bound IPv4 UDP", "udp",
+			"   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"+
+				"  127: 00000000:006F 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 15432 2 0000000000000000 0\n",
+			netstat.Socket{Protocol: "udp", LocalAddress: "0.0.0.0", LocalPort: 111, RemoteAddress: "0.0.0.0", RemotePort: 0, State: "", Inode: 15432}),
+		Entry("connected IPv4 UDP", "udp",
+			"   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"+
+				"  300: 0200007F:1F90 0300007F:20FB 01 00000000:00000000 00:00000000 00000000  1000        0 15433 2 0000000000000000 0\n",
+			netstat.Socket{Protocol: "udp", LocalAddress: "127.0.0.2", LocalPort: 8080, RemoteAddress: "127.0.0.3", RemotePort: 8443, State: "ESTABLISHED", Inode: 15433}),
+		Entry("bound IPv6 UDP", "udp6",
+			"  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"+
+				"  127: 00000000000000000000000000000000:006F 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 15434 2 0000000000000000 0\n",
+			netstat.Socket{Protocol: "udp6", LocalAddress: "::", LocalPort: 111, RemoteAddress: "::", RemotePort: 0, State: "", Inode: 15434}),
+	)
+
+	It("returns an error for malformed addresses", func() {
+		_, err := netstat.ParseSockets(strings.NewReader(tcpHeader+"   0: 0100007:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 23456\n"), "tcp")
+		Expect(err).To(MatchError(`malformed address "0100007:1F90"`))
 	})
 
-	It("parses and converts multiple lines correctly", func() {
-		input := `
-tcp        0      0 127.0.0.1:8080          0.0.0.0:*               LISTEN      1317/java
-udp        0      0 127.0.0.2:8080          127.0.0.3:8443          ESTABLISHED 1318/java
-		`
-		Expect(netstat.ParseNetstatOutputForPort(input)).To(Equal([]netstat.NetstatPort{
-			{
-				PID: 1317,
-				Port: scantron.Port{
-					Protocol:       "tcp",
-					Address:        "127.0.0.1",
-					Number:         8080,
-					ForeignAddress: "0.0.0.0",
-					ForeignNumber:  -1,
-					State:          "LISTEN",
-				},
-			},
-			{
-				PID: 1318,
-				Port: scantron.Port{
-					Protocol:       "udp",
-					Address:        "127.0.0.2",
-					Number:         8080,
-					ForeignAddress: "127.0.0.3",
-					ForeignNumber:  8443,
-					State:          "ESTABLISHED",
-				},
-			},
+	It("describes sockets the way netstat does", func() {
+		socket := netstat.Socket{Protocol: "tcp", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "0.0.0.0", State: "LISTEN"}
+		Expect(socket.Port()).To(Equal(scantron.Port{
+			Protocol:       "tcp",
+			Address:        "127.0.0.1",
+			Number:         8080,
+			ForeignAddress: "0.0.0.0",
+			ForeignNumber:  -1,
+			State:          "LISTEN",
 		}))
 	})
 
-	It("parses both IPv4 and IPv6", func() {
-		input := `
-		tcp6       0      0 :::50051                :::*                    LISTEN      5149/rolodexd
-		udp6       0      0 :::111                  :::*                                777/rpcbind`
-
-		Expect(netstat.ParseNetstatOutputForPort(input)).To(Equal([]netstat.NetstatPort{
-			{
-				PID: 5149,
-				Port: scantron.Port{
-					Protocol:       "tcp6",
-					Address:        "::",
-					Number:         50051,
-					ForeignAddress: "::",
-					ForeignNumber:  -1,
-					State:          "LISTEN",
-				},
-			},
-			{
-				PID: 777,
-				Port: scantron.Port{
-					Protocol:       "udp6",
-					Address:        "::",
-					Number:         111,
-					ForeignAddress: "::",
-					ForeignNumber:  -1,
-					State:          "",
-				},
-			},
+	It("parses unix sockets", func() {
+		table := unixHeader +
+			"0000000000000000: 00000002 00000000 00010000 0001 01 20615 /run/containerd/containerd.sock\n" +
+			"0000000000000000: 00000003 00000000 00000000 0001 03 20616 /run/containerd/containerd.sock\n" +
+			"0000000000000000: 00000002 00000000 00000000 0002 01 12001 /run/systemd/notify\n" +
+			"0000000000000000: 00000002 00000000 00010000 0001 01 12002 @/tmp/.X11-unix/X0\n" +
+			"0000000000000000: 00000002 00000000 00010000 0005 01 12003 /var/run/a socket with spaces\n" +
+			"0000000000000000: 00000003 00000000 00000000 0001 03 12004\n"
+
+		sockets, err := netstat.ParseUnixSockets(strings.NewReader(table))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sockets).To(Equal([]netstat.UnixSocket{
+			{Path: "/run/containerd/containerd.sock", Type: "stream", Listening: true, Inode: 20615},
+			{Path: "/run/containerd/containerd.sock", Type: "stream", Listening: false, Inode: 20616},
+			{Path: "/run/systemd/notify", Type: "dgram", Listening: false, Inode: 12001},
+			{Path: "@/tmp/.X11-unix/X0", Type: "stream", Listening: true, Inode: 12002},
+			{Path: "/var/run/a socket with spaces", Type: "seqpacket", Listening: true, Inode: 12003},
+			{Path: "", Type: "stream", Listening: false, Inode: 12004},
 		}))
 	})
 
-	Context("when the socket state is missing because it is a raw socket", func() {
-		It("still parses that", func() {
-			input := "udp        0      0 127.0.0.1:53            0.0.0.0:*                           4113/consul"
+	Context("reading ports from /proc", func() {
+		var procRoot string
+
+		writeFile := func(path, contents string) {
+			path = filepath.Join(procRoot, path)
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		}
+
+		openSocket := func(pid, fd, inode string) {
+			dir := filepath.Join(procRoot, pid, "fd")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(os.Symlink("socket:["+inode+"]", filepath.Join(dir, fd))).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			procRoot, err = ioutil.TempDir("", "proc")
+			Expect(err).NotTo(HaveOccurred())
+
+			writeFile("self/net/tcp", tcpHeader+
+				"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 23456 1 0000000000000000 100 0 0 10 0\n"+
+				"   1: 0500000A:0016 0100000A:C823 06 00000000:00000000 03:00000F7E 00000000     0        0 0 3 0000000000000000\n")
+			writeFile("self/net/udp6", "  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"+
+				"  127: 00000000000000000000000000000000:006F 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 15434 2 0000000000000000 0\n")
+			writeFile("4242/net/tcp", tcpHeader+
+				"   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 50001 1 0000000000000000 100 0 0 10 0\n")
+
+			writeFile("self/net/unix", unixHeader+
+				"0000000000000000: 00000002 00000000 00010000 0001 01 20615 /run/containerd/containerd.sock\n"+
+				"0000000000000000: 00000003 00000000 00000000 0001 03 20616 /run/containerd/containerd.sock\n"+
+				"0000000000000000: 00000002 00000000 00010000 0001 01 20617 /run/nobody-has-this.sock\n"+
+				"0000000000000000: 00000002 00000000 00010000 0001 01 20618\n")
+			writeFile("4242/net/unix", unixHeader+
+				"0000000000000000: 00000002 00000000 00010000 0001 01 50002 /tmp/app.sock\n")
+
+			openSocket("1317", "3", "23456")
+			openSocket("1317", "4", "9999")
+			openSocket("777", "7", "15434")
+			openSocket("4242", "5", "50001")
+			openSocket("88", "3", "20615")
+			openSocket("88", "4", "20616")
+			openSocket("88", "5", "20618")
+			openSocket("4242", "6", "50002")
+			Expect(os.MkdirAll(filepath.Join(procRoot, "4242", "fd", "0"), 0755)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(procRoot)).To(Succeed())
+		})
+
+		It("maps socket inodes to the processes which have them open", func() {
+			Expect(netstat.SocketOwners(procRoot)).To(Equal(map[uint64]int{
+				23456: 1317,
+				9999:  1317,
+				15434: 777,
+				50001: 4242,
+				20615: 88,
+				20616: 88,
+				20618: 88,
+				50002: 4242,
+			}))
+		})
+
+		It("finds the ports in each network namespace", func() {
+			ports, err := netstat.ReadPorts(procRoot, []string{"self", "4242"})
+			Expect(err).NotTo(HaveOccurred())
 
-			Expect(netstat.ParseNetstatOutputForPort(input)).To(Equal([]netstat.NetstatPort{
+			Expect(ports).To(Equal(netstat.NetstatPorts{
 				{
-					PID: 4113,
+					PID: 1317,
 					Port: scantron.Port{
-						Protocol:       "udp",
+						Protocol:       "tcp",
 						Address:        "127.0.0.1",
-						Number:         53,
+						Number:         8080,
 						ForeignAddress: "0.0.0.0",
 						ForeignNumber:  -1,
+						State:          "LISTEN",
+					},
+				},
+				{
+					PID: 777,
+					Port: scantron.Port{
+						Protocol:       "udp6",
+						Address:        "::",
+						Number:         111,
+						ForeignAddress: "::",
+						ForeignNumber:  -1,
 						State:          "",
 					},
 				},
+				{
+					PID: 4242,
+					Port: scantron.Port{
+						Protocol:       "tcp",
+						Address:        "0.0.0.0",
+						Number:         8080,
+						ForeignAddress: "0.0.0.0",
+						ForeignNumber:  -1,
+						State:          "LISTEN",
+					},
+				},
+			}))
+		})
+
+		It("finds the listening unix sockets in each network namespace", func() {
+			sockets, err := netstat.ReadUnixSockets(procRoot, []string{"self", "4242"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sockets).To(Equal([]netstat.NetstatUnixSocket{
+				{
+					PID:    88,
+					Socket: netstat.UnixSocket{Path: "/run/containerd/containerd.sock", Type: "stream", Listening: true, Inode: 20615},
+				},
+				{
+					PID:    4242,
+					Socket: netstat.UnixSocket{Path: "/tmp/app.sock", Type: "stream", Listening: true, Inode: 50002},
+				},
 			}))
 		})
 	})
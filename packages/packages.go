@@ -0,0 +1,216 @@
+// Package packages lists the OS packages installed on a machine and the Go
+// modules built into the programs of its listening processes.
+package packages
+
+import (
+	"bufio"
+	"debug/buildinfo"
+	"io"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+// ParseDpkgStatus reads the installed packages from a dpkg status file, like
+// /var/lib/dpkg/status.
+func ParseDpkgStatus(r io.Reader) ([]scantron.Package, error) {
+	pkgs := []scantron.Package{}
+
+	var (
+		pkg       scantron.Package
+		installed bool
+	)
+	record := func() {
+		if installed && pkg.Name != "" {
+			if pkg.Source == pkg.Name {
+				pkg.Source = ""
+			}
+			pkgs = append(pkgs, pkg)
+		}
+		pkg = scantron.Package{Ecosystem: scantron.DpkgEcosystem}
+		installed = false
+	}
+	record()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			record()
+			continue
+		}
+
+		// Continuation lines of long fields like Description
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+
+		switch parts[0] {
+		case "Package":
+			pkg.Name = value
+		case "Version":
+			pkg.Version = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		case "Source":
+			// The source may have its own version, as in "openssl (1.1.1f-1)"
+			source := strings.Fields(value)
+			if len(source) > 0 {
+				pkg.Source = source[0]
+			}
+		}
+	}
+	record()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pkgs, nil
+}
+
+// ParseOSRelease reads the distribution ID and VERSION_ID from an os-release
+// file, like /etc/os-release.
+func ParseOSRelease(r io.Reader) (scantron.OSRelease, error) {
+	release := scantron.OSRelease{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.Trim(parts[1], `"'`)
+
+		switch parts[0] {
+		case "ID":
+			release.ID = value
+		case "VERSION_ID":
+			release.VersionID = value
+		}
+	}
+
+	return release, scanner.Err()
+}
+
+// RPMQueryFormat is the --queryformat of rpm -qa whose output ParseRPM reads.
+const RPMQueryFormat = `%{NAME}\t%{EPOCH}\t%{VERSION}-%{RELEASE}\t%{SOURCERPM}\n`
+
+// ParseRPM reads the installed packages from the output of rpm -qa with
+// RPMQueryFormat.
+func ParseRPM(output string) []scantron.Package {
+	pkgs := []scantron.Package{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 4 || fields[0] == "" {
+			continue
+		}
+
+		pkg := scantron.Package{
+			Name:      fields[0],
+			Version:   fields[2],
+			Ecosystem: scantron.RPMEcosystem,
+		}
+		if fields[1] != "(none)" && fields[1] != "" {
+			pkg.Version = fields[1] + ":" + fields[2]
+		}
+
+		// The source RPM is named like openssl-1.1.1k-9.el8.src.rpm
+		source := strings.TrimSuffix(fields[3], ".src.rpm")
+		for i := 0; i < 2; i++ {
+			if j := strings.LastIndex(source, "-"); j > 0 {
+				source = source[:j]
+			}
+		}
+		if source != pkg.Name && source != "(none)" {
+			pkg.Source = source
+		}
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs
+}
+
+// GoModules returns the modules a Go program was built with, including the
+// standard library as stdlib. It fails for programs not written in Go.
+func GoModules(path string) ([]scantron.Package, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := []scantron.Package{{
+		Name:      "stdlib",
+		Version:   strings.TrimPrefix(info.GoVersion, "go"),
+		Ecosystem: scantron.GoEcosystem,
+	}}
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		pkgs = append(pkgs, scantron.Package{
+			Name:      info.Main.Path,
+			Version:   info.Main.Version,
+			Ecosystem: scantron.GoEcosystem,
+		})
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+
+		// Modules replaced by a local directory have no version
+		if dep.Version == "" {
+			continue
+		}
+
+		pkgs = append(pkgs, scantron.Package{
+			Name:      dep.Path,
+			Version:   dep.Version,
+			Ecosystem: scantron.GoEcosystem,
+		})
+	}
+
+	return pkgs, nil
+}
+
+// ListeningGoModules returns the Go modules of the programs of processes
+// listening on ports. Programs run by more than one process are only read
+// once.
+func ListeningGoModules(processes []scantron.Process, logger scanlog.Logger) []scantron.Package {
+	pkgs := []scantron.Package{}
+	seen := map[string]bool{}
+
+	for _, p := range processes {
+		if len(p.Ports) == 0 || p.Executable == "" {
+			continue
+		}
+
+		key := p.Executable + " " + p.ExecutableSHA256
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		modules, err := GoModules(programPath(p))
+		if err != nil {
+			logger.Debugf("Skipping Go modules of %s: %s", p.Executable, err)
+			continue
+		}
+
+		for _, module := range modules {
+			module.Executable = p.Executable
+			pkgs = append(pkgs, module)
+		}
+	}
+
+	return pkgs
+}
@@ -0,0 +1,73 @@
+// +build !windows
+
+package packages
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+const dpkgStatusPath = "/var/lib/dpkg/status"
+
+// Installed returns the packages installed with dpkg and rpm, whichever the
+// machine has.
+func Installed() ([]scantron.Package, error) {
+	pkgs := []scantron.Package{}
+
+	f, err := os.Open(dpkgStatusPath)
+	if err == nil {
+		defer f.Close()
+
+		dpkgPackages, err := ParseDpkgStatus(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", dpkgStatusPath, err)
+		}
+		pkgs = append(pkgs, dpkgPackages...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if _, err := exec.LookPath("rpm"); err == nil {
+		output, err := exec.Command("rpm", "-qa", "--queryformat", RPMQueryFormat).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rpm packages: %s", err)
+		}
+		pkgs = append(pkgs, ParseRPM(string(output))...)
+	}
+
+	return pkgs, nil
+}
+
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// Release returns the distribution the machine runs, or nil if it has no
+// os-release file.
+func Release() (*scantron.OSRelease, error) {
+	for _, path := range osReleasePaths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		release, err := ParseOSRelease(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", path, err)
+		}
+		return &release, nil
+	}
+
+	return nil, nil
+}
+
+// programPath reads the program through /proc so that it is found even when
+// it is in a container.
+func programPath(p scantron.Process) string {
+	return fmt.Sprintf("/proc/%d/exe", p.PID)
+}
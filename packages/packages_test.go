@@ -0,0 +1,126 @@
+package packages_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/packages"
+)
+
+const dpkgStatus = `Package: libssl1.1
+Status: install ok installed
+Priority: important
+Architecture: amd64
+Source: openssl (1.1.1f-1ubuntu2.16)
+Version: 1.1.1f-1ubuntu2.16
+Description: Secure Sockets Layer toolkit - shared libraries
+ This package is part of the OpenSSL project's implementation of the SSL
+ and TLS cryptographic protocols.
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0-1
+
+Package: bash
+Status: install ok installed
+Source: bash
+Version: 5.0-6ubuntu1.2
+`
+
+var _ = Describe("Packages", func() {
+	Describe("ParseDpkgStatus", func() {
+		It("returns the installed packages", func() {
+			pkgs, err := packages.ParseDpkgStatus(strings.NewReader(dpkgStatus))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pkgs).To(Equal([]scantron.Package{
+				{
+					Name:      "libssl1.1",
+					Version:   "1.1.1f-1ubuntu2.16",
+					Ecosystem: scantron.DpkgEcosystem,
+					Source:    "openssl",
+				},
+				{
+					Name:      "bash",
+					Version:   "5.0-6ubuntu1.2",
+					Ecosystem: scantron.DpkgEcosystem,
+				},
+			}))
+		})
+	})
+
+	Describe("ParseOSRelease", func() {
+		It("returns the distribution and its version", func() {
+			osRelease := `NAME="Ubuntu"
+VERSION="22.04.3 LTS (Jammy Jellyfish)"
+ID=ubuntu
+ID_LIKE=debian
+VERSION_ID="22.04"
+`
+
+			release, err := packages.ParseOSRelease(strings.NewReader(osRelease))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release).To(Equal(scantron.OSRelease{ID: "ubuntu", VersionID: "22.04"}))
+		})
+	})
+
+	Describe("ParseRPM", func() {
+		It("returns the packages with their epochs and source packages", func() {
+			output := "openssl-libs\t1\t1.1.1k-9.el8_7\topenssl-1.1.1k-9.el8_7.src.rpm\n" +
+				"bash\t(none)\t4.4.20-4.el8\tbash-4.4.20-4.el8.src.rpm\n" +
+				"gpg-pubkey\t(none)\tfd431d51-4ae0493b\t(none)\n"
+
+			Expect(packages.ParseRPM(output)).To(Equal([]scantron.Package{
+				{
+					Name:      "openssl-libs",
+					Version:   "1:1.1.1k-9.el8_7",
+					Ecosystem: scantron.RPMEcosystem,
+					Source:    "openssl",
+				},
+				{
+					Name:      "bash",
+					Version:   "4.4.20-4.el8",
+					Ecosystem: scantron.RPMEcosystem,
+				},
+				{
+					Name:      "gpg-pubkey",
+					Version:   "fd431d51-4ae0493b",
+					Ecosystem: scantron.RPMEcosystem,
+				},
+			}))
+		})
+	})
+
+	Describe("GoModules", func() {
+		It("returns the standard library of a Go program", func() {
+			pkgs, err := packages.GoModules(os.Args[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pkgs).To(ContainElement(scantron.Package{
+				Name:      "stdlib",
+				Version:   strings.TrimPrefix(runtime.Version(), "go"),
+				Ecosystem: scantron.GoEcosystem,
+			}))
+		})
+
+		It("fails for programs not written in Go", func() {
+			dir, err := ioutil.TempDir("", "packages")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			script := filepath.Join(dir, "script.sh")
+			err = ioutil.WriteFile(script, []byte("#!/bin/sh\necho hello\n"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = packages.GoModules(script)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,21 @@
+// +build windows
+
+package packages
+
+import (
+	"github.com/pivotal-cf/scantron"
+)
+
+// Installed returns no packages since Windows has neither dpkg nor rpm.
+func Installed() ([]scantron.Package, error) {
+	return []scantron.Package{}, nil
+}
+
+// Release returns nil since Windows has no os-release file.
+func Release() (*scantron.OSRelease, error) {
+	return nil, nil
+}
+
+func programPath(p scantron.Process) string {
+	return p.Executable
+}
@@ -0,0 +1,60 @@
+package process
+
+import (
+	"regexp"
+	"strings"
+)
+
+var containerIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Container runtimes managed by systemd name their scopes after the runtime,
+// e.g. docker-<id>.scope or cri-containerd-<id>.scope.
+var containerScopePrefixes = []string{"docker-", "cri-containerd-", "crio-", "libpod-"}
+
+// ParseCgroup takes the contents of /proc/<pid>/cgroup and returns the
+// process's cgroup along with the ID of the container it is in, which is
+// empty for processes on the host.
+func ParseCgroup(contents string) (string, string) {
+	var cgroup string
+
+	for _, line := range strings.Split(strings.TrimSpace(contents), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		path := fields[2]
+		if id := containerID(path); id != "" {
+			return path, id
+		}
+
+		// Prefer the unified hierarchy when there is no container to find
+		if cgroup == "" || fields[0] == "0" {
+			cgroup = path
+		}
+	}
+
+	return cgroup, ""
+}
+
+func containerID(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i := len(segments) - 1; i > 0; i-- {
+		segment := strings.TrimSuffix(segments[i], ".scope")
+		for _, prefix := range containerScopePrefixes {
+			segment = strings.TrimPrefix(segment, prefix)
+		}
+
+		if containerIDPattern.MatchString(segment) {
+			return segment
+		}
+
+		// Garden names containers after their handle rather than a hash
+		if segments[i-1] == "garden" && segment != "" {
+			return segment
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,51 @@
+package process_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/process"
+)
+
+var _ = Describe("ParseCgroup", func() {
+	const id = "3f4c2b1a9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b"
+
+	DescribeTable("finding containers",
+		func(contents, expectedCgroup, expectedID string) {
+			cgroup, containerID := process.ParseCgroup(contents)
+			Expect(cgroup).To(Equal(expectedCgroup))
+			Expect(containerID).To(Equal(expectedID))
+		},
+		Entry("host process on cgroup v1",
+			"12:memory:/system.slice/ssh.service\n11:pids:/system.slice/ssh.service\n1:name=systemd:/system.slice/ssh.service\n",
+			"/system.slice/ssh.service", ""),
+		Entry("host process on cgroup v2",
+			"0::/init.scope\n",
+			"/init.scope", ""),
+		Entry("hybrid hierarchy prefers the unified cgroup",
+			"12:memory:/\n1:name=systemd:/user.slice\n0::/user.slice/user-1000.slice\n",
+			"/user.slice/user-1000.slice", ""),
+		Entry("docker with cgroupfs",
+			"12:memory:/docker/"+id+"\n1:name=systemd:/docker/"+id+"\n",
+			"/docker/"+id, id),
+		Entry("docker with systemd",
+			"0::/system.slice/docker-"+id+".scope\n",
+			"/system.slice/docker-"+id+".scope", id),
+		Entry("kubernetes with cgroupfs",
+			"4:cpu,cpuacct:/kubepods/burstable/pod6b1bb8e5-7c2a-11e9-8f9e-2a86e4085a59/"+id+"\n",
+			"/kubepods/burstable/pod6b1bb8e5-7c2a-11e9-8f9e-2a86e4085a59/"+id, id),
+		Entry("kubernetes with containerd and systemd",
+			"0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod6b1bb8e5.slice/cri-containerd-"+id+".scope\n",
+			"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod6b1bb8e5.slice/cri-containerd-"+id+".scope", id),
+		Entry("garden",
+			"5:memory:/garden/5a0dd9ba-c8bd-4eb6-6a7d-4e1a\n",
+			"/garden/5a0dd9ba-c8bd-4eb6-6a7d-4e1a", "5a0dd9ba-c8bd-4eb6-6a7d-4e1a"),
+		Entry("garden itself",
+			"5:memory:/garden\n",
+			"/garden", ""),
+		Entry("nothing to read",
+			"",
+			"", ""),
+	)
+})
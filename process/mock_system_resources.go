@@ -47,13 +47,27 @@ func (mr *MockSystemResourcesMockRecorder) GetProcesses() *gomock.Call {
 }
 
 // GetPorts mocks base method
-func (m *MockSystemResources) GetPorts() ProcessPorts {
+func (m *MockSystemResources) GetPorts() (ProcessPorts, error) {
 	ret := m.ctrl.Call(m, "GetPorts")
 	ret0, _ := ret[0].(ProcessPorts)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetPorts indicates an expected call of GetPorts
 func (mr *MockSystemResourcesMockRecorder) GetPorts() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPorts", reflect.TypeOf((*MockSystemResources)(nil).GetPorts))
 }
+
+// GetUnixSockets mocks base method
+func (m *MockSystemResources) GetUnixSockets() (ProcessUnixSockets, error) {
+	ret := m.ctrl.Call(m, "GetUnixSockets")
+	ret0, _ := ret[0].(ProcessUnixSockets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnixSockets indicates an expected call of GetUnixSockets
+func (mr *MockSystemResourcesMockRecorder) GetUnixSockets() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnixSockets", reflect.TypeOf((*MockSystemResources)(nil).GetUnixSockets))
+}
@@ -3,14 +3,23 @@
 package process
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/keybase/go-ps"
+	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/capability"
+	"github.com/pivotal-cf/scantron/filesystem"
 	"github.com/pivotal-cf/scantron/netstat"
 )
 
@@ -23,30 +32,72 @@ func (s *SystemResourceImpl) GetProcesses() ([]scantron.Process, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	bootTime := getBootTime()
+	users := map[int]string{}
+	hashes := map[string]string{}
+
 	processes := []scantron.Process{}
+	failures := []string{}
 	for _, rawProcess := range rawProcesses {
 		pid := rawProcess.Pid()
+		cgroup, containerID := getCgroup(pid)
 		process := scantron.Process{
 			CommandName: rawProcess.Executable(),
 			PID:         pid,
-			User:        getUser(pid),
 			Cmdline:     getCmdline(pid),
 			Env:         getEnv(pid),
+			StartTime:   getStartTime(pid, bootTime),
+			ContainerID: containerID,
+			Cgroup:      cgroup,
+			Namespaces:  getNamespaces(pid),
+		}
+
+		status, err := getStatus(pid)
+		if err == nil {
+			process.PPID = status.PPID
+			process.UID = status.UID
+			process.EUID = status.EUID
+			process.GID = status.GID
+			process.EGID = status.EGID
+			process.User = lookupUser(status.EUID, users)
+			process.Capabilities = scantron.Capabilities{
+				Effective:   capability.Names(status.CapEffective),
+				Permitted:   capability.Names(status.CapPermitted),
+				Inheritable: capability.Names(status.CapInheritable),
+				Bounding:    capability.Names(status.CapBounding),
+				Ambient:     capability.Names(status.CapAmbient),
+			}
+			process.NoNewPrivs = status.NoNewPrivs
+			process.Seccomp = status.Seccomp
+		} else if !os.IsNotExist(err) {
+			// processes which exited while we were looking are not errors
+			failures = append(failures, fmt.Sprintf("failed to get status of process %d: %s", pid, err))
 		}
+
+		process.Executable, process.ExecutableSHA256 = getExecutable(pid, process.Namespaces.Mount, hashes)
+
 		processes = append(processes, process)
 	}
 
+	if len(failures) > 0 {
+		return processes, errors.New(strings.Join(failures, "; "))
+	}
+
 	return processes, nil
 }
 
-func (s *SystemResourceImpl) GetPorts() ProcessPorts {
-	bs, err := exec.Command("netstat", "-at", "-4", "-6", "--numeric-ports", "-u", "-p").Output()
+// GetPorts lists the sockets in every network namespace on the machine so
+// that ports opened inside containers are found as well as those on the host.
+func (s *SystemResourceImpl) GetPorts() (ProcessPorts, error) {
+	pids := append([]string{"self"}, otherNetworkNamespaces()...)
+
+	netstatPorts, err := netstat.ReadPorts("/proc", pids)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	netstatPorts := netstat.ParseNetstatOutputForPort(string(bs))
-	processPorts := []ProcessPort{}
+	processPorts := ProcessPorts{}
 	for _, np := range netstatPorts {
 		processPorts = append(processPorts, ProcessPort{
 			PID:  np.PID,
@@ -54,17 +105,215 @@ func (s *SystemResourceImpl) GetPorts() ProcessPorts {
 		})
 	}
 
-	return processPorts
+	return processPorts, nil
+}
+
+// GetUnixSockets lists the listening Unix domain sockets in every network
+// namespace. Their files are looked up through the root of the owning
+// process so that sockets inside containers are found too.
+func (s *SystemResourceImpl) GetUnixSockets() (ProcessUnixSockets, error) {
+	pids := append([]string{"self"}, otherNetworkNamespaces()...)
+
+	netstatSockets, err := netstat.ReadUnixSockets("/proc", pids)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := filesystem.GetFileMetadata()
+
+	processSockets := ProcessUnixSockets{}
+	for _, ns := range netstatSockets {
+		socket := scantron.UnixSocket{
+			Path: ns.Socket.Path,
+			Type: ns.Socket.Type,
+		}
+
+		if !strings.HasPrefix(socket.Path, "@") {
+			path := filepath.Join("/proc", strconv.Itoa(ns.PID), "root", socket.Path)
+			info, err := os.Stat(path)
+			if err == nil {
+				socket.Permissions = info.Mode().Perm()
+				socket.User, _ = metadata.GetUser(path, info)
+				socket.Group, _ = metadata.GetGroup(path, info)
+			}
+		}
+
+		processSockets = append(processSockets, ProcessUnixSocket{
+			PID:    ns.PID,
+			Socket: socket,
+		})
+	}
+
+	return processSockets, nil
+}
+
+// otherNetworkNamespaces returns a process in each network namespace apart
+// from our own.
+func otherNetworkNamespaces() []string {
+	own := namespaceInode("self", "net")
+	if own == 0 {
+		return nil
+	}
+
+	rawProcesses, err := ps.Processes()
+	if err != nil {
+		return nil
+	}
+
+	seen := map[uint64]bool{own: true}
+	pids := []string{}
+	for _, rawProcess := range rawProcesses {
+		pid := strconv.Itoa(rawProcess.Pid())
+		inode := namespaceInode(pid, "net")
+		if inode == 0 || seen[inode] {
+			continue
+		}
+
+		seen[inode] = true
+		pids = append(pids, pid)
+	}
+
+	return pids
+}
+
+func getNamespaces(pid int) scantron.Namespaces {
+	return scantron.Namespaces{
+		PID:     namespaceInode(strconv.Itoa(pid), "pid"),
+		Network: namespaceInode(strconv.Itoa(pid), "net"),
+		Mount:   namespaceInode(strconv.Itoa(pid), "mnt"),
+	}
+}
+
+// namespaceInode reads the inode number from a namespace link such as
+// net:[4026531992].
+func namespaceInode(pid string, namespace string) uint64 {
+	link, err := os.Readlink(fmt.Sprintf("/proc/%s/ns/%s", pid, namespace))
+	if err != nil {
+		return 0
+	}
+
+	start := strings.Index(link, "[")
+	end := strings.LastIndex(link, "]")
+	if start == -1 || end < start {
+		return 0
+	}
+
+	inode, err := strconv.ParseUint(link[start+1:end], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return inode
 }
 
-func getUser(pid int) string {
-	bs, err := exec.Command("ps", "-e", "-o", "uname:20=", "-f", strconv.Itoa(pid)).CombinedOutput()
+func getCgroup(pid int) (string, string) {
+	bs, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", ""
+	}
+
+	return ParseCgroup(string(bs))
+}
+
+// The kernel reports times in /proc in USER_HZ, which is 100 on every
+// architecture Linux supports
+const clockTicksPerSecond = 100
+
+func getStatus(pid int) (Status, error) {
+	bs, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return Status{}, err
+	}
+
+	return ParseStatus(string(bs))
+}
+
+// lookupUser names users the way ps does, falling back to the UID for users
+// which aren't in the passwd file, such as those in containers.
+func lookupUser(uid int, users map[int]string) string {
+	if name, found := users[uid]; found {
+		return name
+	}
+
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+
+	users[uid] = name
+	return name
+}
+
+func getBootTime() time.Time {
+	bs, err := ioutil.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+
+	for _, line := range strings.Split(string(bs), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "btime" {
+			seconds, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}
+			}
+			return time.Unix(seconds, 0).UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+func getStartTime(pid int, bootTime time.Time) time.Time {
+	if bootTime.IsZero() {
+		return time.Time{}
+	}
+
+	bs, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return time.Time{}
+	}
+
+	ticks, err := ParseStartTime(string(bs))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return bootTime.Add(time.Duration(ticks) * time.Second / clockTicksPerSecond)
+}
+
+// getExecutable returns the path of the program a process is running and its
+// SHA-256. The program is read through /proc so that it is found even when it
+// is in a container or has been deleted. Programs are only hashed once per
+// mount namespace.
+func getExecutable(pid int, mountNamespace uint64, hashes map[string]string) (string, string) {
+	exe := fmt.Sprintf("/proc/%d/exe", pid)
+
+	path, err := os.Readlink(exe)
+	if err != nil {
+		// Kernel threads have no executable
+		return "", ""
+	}
+
+	key := fmt.Sprintf("%d:%s", mountNamespace, path)
+	if hash, found := hashes[key]; found {
+		return path, hash
+	}
+
+	file, err := os.Open(exe)
+	if err != nil {
+		return path, ""
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error getting user:", err)
-		return ""
+		return path, ""
 	}
 
-	return strings.TrimSpace(string(bs))
+	hashes[key] = hex.EncodeToString(hash.Sum(nil))
+	return path, hashes[key]
 }
 
 func getCmdline(pid int) []string {
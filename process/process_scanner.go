@@ -1,6 +1,8 @@
 package process
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
@@ -17,28 +19,64 @@ type ProcessPort struct {
 
 type ProcessPorts []ProcessPort
 
+type ProcessUnixSocket struct {
+	PID    int
+	Socket scantron.UnixSocket
+}
+
+type ProcessUnixSockets []ProcessUnixSocket
+
 type ProcessScanner struct {
 	SysRes  SystemResources
 	TlsScan tlsscan.TlsScanner
+
+	// RevealSecrets keeps the values of secrets found in the environment and
+	// command line of processes instead of redacting them.
+	RevealSecrets bool
 }
 
 func (ps *ProcessScanner) ScanProcesses(logger scanlog.Logger) ([]scantron.Process, error) {
+	// The processes which were found are still returned when some of their
+	// details could not be read, along with an error describing what is missing
+	failures := []string{}
+
 	processes, err := ps.SysRes.GetProcesses()
-	if err != nil {
+	if processes == nil {
 		return nil, err
 	}
+	if err != nil {
+		failures = append(failures, err.Error())
+	}
 
-	ports := ps.SysRes.GetPorts()
+	ports, err := ps.SysRes.GetPorts()
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("failed to get ports: %s", err))
+	}
+
+	unixSockets, err := ps.SysRes.GetUnixSockets()
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("failed to get unix sockets: %s", err))
+	}
+
+	hostNetwork := hostNetworkNamespace(processes)
 	for i := range processes {
 		portsForPid := ports.LocalPortsForPID(processes[i].PID)
 
 		for j := range portsForPid {
+			portsForPid[j].ContainerID = processes[i].ContainerID
 
 			if strings.ToUpper(portsForPid[j].State) != "LISTEN" {
 				continue
 			}
 
-			if portsForPid[j].Protocol == "udp" {
+			if strings.HasPrefix(portsForPid[j].Protocol, "udp") {
+				continue
+			}
+
+			// localhost only reaches ports in our own network namespace
+			network := processes[i].Namespaces.Network
+			if hostNetwork != 0 && network != 0 && network != hostNetwork {
+				logger.Debugf("Skipping TLS scan of port %d in container %s", portsForPid[j].Number, processes[i].ContainerID)
 				continue
 			}
 
@@ -46,11 +84,29 @@ func (ps *ProcessScanner) ScanProcesses(logger scanlog.Logger) ([]scantron.Proce
 		}
 
 		processes[i].Ports = portsForPid
+		processes[i].UnixSockets = unixSockets.ForPID(processes[i].PID)
+		findSecrets(&processes[i], ps.RevealSecrets)
+	}
+
+	if len(failures) > 0 {
+		return processes, errors.New(strings.Join(failures, "; "))
 	}
 
 	return processes, nil
 }
 
+// hostNetworkNamespace is the network namespace of init, or 0 if it is not
+// known.
+func hostNetworkNamespace(processes []scantron.Process) uint64 {
+	for _, process := range processes {
+		if process.PID == 1 {
+			return process.Namespaces.Network
+		}
+	}
+
+	return 0
+}
+
 func (ps ProcessPorts) LocalPortsForPID(pid int) []scantron.Port {
 	result := []scantron.Port{}
 
@@ -63,6 +119,18 @@ func (ps ProcessPorts) LocalPortsForPID(pid int) []scantron.Port {
 	return result
 }
 
+func (sockets ProcessUnixSockets) ForPID(pid int) []scantron.UnixSocket {
+	result := []scantron.UnixSocket{}
+
+	for _, socket := range sockets {
+		if socket.PID == pid {
+			result = append(result, socket.Socket)
+		}
+	}
+
+	return result
+}
+
 func readFile(path string) ([]string, error) {
 	bs, err := ioutil.ReadFile(path)
 	if err != nil {
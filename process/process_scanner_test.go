@@ -68,27 +68,47 @@ var _ = Describe("ProcessScanner", func() {
 		}
 
 		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
-		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts, nil).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil, nil).Times(1)
 
 		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
 
 		Expect(err).Should(BeNil())
 		Expect(processes).Should(HaveLen(1))
 		Expect(processes[0]).Should(MatchAllFields(Fields{
-			"CommandName": Equal("command"),
-			"PID":         Equal(123),
-			"User":        Equal("user"),
-			"Cmdline":     Equal([]string{"cmd", "arg"}),
-			"Env":         Equal([]string{"foo=bar"}),
+			"CommandName":      Equal("command"),
+			"PID":              Equal(123),
+			"User":             Equal("user"),
+			"Cmdline":          Equal([]string{"cmd", "arg"}),
+			"Env":              Equal([]string{"foo=bar"}),
+			"PPID":             BeZero(),
+			"UID":              BeZero(),
+			"EUID":             BeZero(),
+			"GID":              BeZero(),
+			"EGID":             BeZero(),
+			"StartTime":        BeZero(),
+			"Executable":       BeEmpty(),
+			"ExecutableSHA256": BeEmpty(),
+			"Capabilities":     BeZero(),
+			"NoNewPrivs":       BeFalse(),
+			"Seccomp":          BeEmpty(),
+			"ContainerID":      BeEmpty(),
+			"Cgroup":           BeEmpty(),
+			"Namespaces":       BeZero(),
+			"UnixSockets":      BeEmpty(),
+			"Secrets":          BeEmpty(),
 			"Ports": MatchAllElements(portIdFn, Elements{
 				"4567": MatchAllFields(Fields{
-					"Protocol":       Equal("tcp"),
-					"Address":        Equal("1.2.3.4"),
-					"Number":         Equal(4567),
-					"ForeignAddress": Equal("2.3.4.5"),
-					"ForeignNumber":  Equal(6789),
-					"State":          Equal("Established"),
-					"TLSInformation": BeNil(),
+					"Protocol":        Equal("tcp"),
+					"Address":         Equal("1.2.3.4"),
+					"Number":          Equal(4567),
+					"ForeignAddress":  Equal("2.3.4.5"),
+					"ForeignNumber":   Equal(6789),
+					"State":           Equal("Established"),
+					"ContainerID":     BeEmpty(),
+					"TLSInformation":  BeNil(),
+					"FirewallBlocked": BeFalse(),
+					"FirewallRule":    BeEmpty(),
 				}),
 			}),
 		}))
@@ -126,7 +146,8 @@ var _ = Describe("ProcessScanner", func() {
 		}
 
 		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
-		mockSystemResources.EXPECT().GetPorts().Return(systemPorts).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(systemPorts, nil).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(nil, nil).Times(1)
 
 		cipherInformation := scantron.CipherInformation{
 			"VersionSSL30": []string{"cipher"},
@@ -153,11 +174,27 @@ var _ = Describe("ProcessScanner", func() {
 		Expect(err).Should(BeNil())
 		Expect(processes).Should(HaveLen(1))
 		Expect(processes[0]).Should(MatchAllFields(Fields{
-			"CommandName": Equal("command"),
-			"PID":         Equal(123),
-			"User":        Equal("user"),
-			"Cmdline":     Equal([]string{"cmd", "arg"}),
-			"Env":         Equal([]string{"foo=bar"}),
+			"CommandName":      Equal("command"),
+			"PID":              Equal(123),
+			"User":             Equal("user"),
+			"Cmdline":          Equal([]string{"cmd", "arg"}),
+			"Env":              Equal([]string{"foo=bar"}),
+			"PPID":             BeZero(),
+			"UID":              BeZero(),
+			"EUID":             BeZero(),
+			"GID":              BeZero(),
+			"EGID":             BeZero(),
+			"StartTime":        BeZero(),
+			"Executable":       BeEmpty(),
+			"ExecutableSHA256": BeEmpty(),
+			"Capabilities":     BeZero(),
+			"NoNewPrivs":       BeFalse(),
+			"Seccomp":          BeEmpty(),
+			"ContainerID":      BeEmpty(),
+			"Cgroup":           BeEmpty(),
+			"Namespaces":       BeZero(),
+			"UnixSockets":      BeEmpty(),
+			"Secrets":          BeEmpty(),
 			"Ports": MatchAllElements(portIdFn, Elements{
 				"4567": MatchAllFields(Fields{
 					"Protocol":       Equal("tcp"),
@@ -166,14 +203,210 @@ var _ = Describe("ProcessScanner", func() {
 					"ForeignAddress": Equal("0.0.0.0"),
 					"ForeignNumber":  Equal(-1),
 					"State":          Equal("Listen"),
+					"ContainerID":    BeEmpty(),
 					"TLSInformation": PointTo(MatchAllFields(Fields{
 						"Certificate":       Equal(certificate),
 						"CipherInformation": Equal(cipherInformation),
 						"Mutual":            BeFalse(),
 						"ScanError":         BeNil(),
 					})),
+					"FirewallBlocked": BeFalse(),
+					"FirewallRule":    BeEmpty(),
 				}),
 			}),
 		}))
 	})
+
+	Context("when a process is in a container", func() {
+		var systemProcesses []scantron.Process
+
+		BeforeEach(func() {
+			systemProcesses = []scantron.Process{
+				{
+					CommandName: "init",
+					PID:         1,
+					User:        "root",
+					Namespaces:  scantron.Namespaces{Network: 4026531992},
+				},
+				{
+					CommandName: "nginx",
+					PID:         123,
+					User:        "root",
+					ContainerID: "3f4c2b1a9e8d",
+					Namespaces:  scantron.Namespaces{Network: 4026532451},
+				},
+			}
+
+			systemPorts := []process.ProcessPort{
+				{
+					PID: 123,
+					Port: scantron.Port{
+						Protocol: "tcp",
+						Address:  "0.0.0.0",
+						Number:   8080,
+						State:    "LISTEN",
+					},
+				},
+			}
+
+			mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+			mockSystemResources.EXPECT().GetPorts().Return(systemPorts, nil).Times(1)
+			mockSystemResources.EXPECT().GetUnixSockets().Return(nil, nil).Times(1)
+		})
+
+		It("records the container on its ports", func() {
+			processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(processes[1].Ports).To(HaveLen(1))
+			Expect(processes[1].Ports[0].ContainerID).To(Equal("3f4c2b1a9e8d"))
+		})
+
+		It("does not scan TLS on ports in another network namespace", func() {
+			mockTlsScanner.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(processes[1].Ports[0].TLSInformation).To(BeNil())
+		})
+
+		It("scans TLS on containers sharing the host network", func() {
+			systemProcesses[1].Namespaces.Network = 4026531992
+			mockTlsScanner.EXPECT().Scan(gomock.Any(), "localhost", "8080").Return(scantron.CipherInformation{}, nil).Times(1)
+
+			_, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("associates unix sockets with processes", func() {
+		systemProcesses := []scantron.Process{
+			{CommandName: "containerd", PID: 88, User: "root"},
+			{CommandName: "bash", PID: 99, User: "vcap"},
+		}
+
+		socket := scantron.UnixSocket{
+			Path:        "/run/containerd/containerd.sock",
+			Type:        "stream",
+			Permissions: 0660,
+			User:        "root",
+			Group:       "root",
+		}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(nil, nil).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(process.ProcessUnixSockets{
+			{PID: 88, Socket: socket},
+		}, nil).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(processes[0].UnixSockets).To(Equal([]scantron.UnixSocket{socket}))
+		Expect(processes[1].UnixSockets).To(BeEmpty())
+	})
+
+	It("returns the processes it found along with the details it could not read", func() {
+		systemProcesses := []scantron.Process{
+			{CommandName: "sshd", PID: 88, User: "root"},
+		}
+
+		socket := scantron.UnixSocket{Path: "/run/sshd.sock", Type: "stream"}
+
+		mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, fmt.Errorf("failed to get status of process 88: permission denied")).Times(1)
+		mockSystemResources.EXPECT().GetPorts().Return(nil, fmt.Errorf("no such file or directory")).Times(1)
+		mockSystemResources.EXPECT().GetUnixSockets().Return(process.ProcessUnixSockets{
+			{PID: 88, Socket: socket},
+		}, nil).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed to get status of process 88: permission denied"))
+		Expect(err.Error()).To(ContainSubstring("failed to get ports: no such file or directory"))
+
+		Expect(processes).To(HaveLen(1))
+		Expect(processes[0].CommandName).To(Equal("sshd"))
+		Expect(processes[0].Ports).To(BeEmpty())
+		Expect(processes[0].UnixSockets).To(Equal([]scantron.UnixSocket{socket}))
+	})
+
+	It("returns an error when the processes cannot be listed", func() {
+		mockSystemResources.EXPECT().GetProcesses().Return(nil, fmt.Errorf("no /proc")).Times(1)
+
+		processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+		Expect(err).To(MatchError("no /proc"))
+		Expect(processes).To(BeNil())
+	})
+
+	Context("when processes are given secrets", func() {
+		BeforeEach(func() {
+			systemProcesses := []scantron.Process{
+				{
+					CommandName: "server",
+					PID:         42,
+					User:        "vcap",
+					Cmdline: []string{
+						"/var/vcap/packages/server/bin/server",
+						"--password=hunter2",
+						"--token", "abc123",
+						"--db", "postgres://admin:s3cret@db:5432/ccdb",
+						"--port", "8080",
+						"wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+					},
+					Env: []string{
+						"PATH=/usr/bin:/bin",
+						"DB_PASSWORD=hunter2",
+						"PASSWORD_FILE=/var/vcap/jobs/server/config/password",
+					},
+				},
+			}
+
+			mockSystemResources.EXPECT().GetProcesses().Return(systemProcesses, nil).Times(1)
+			mockSystemResources.EXPECT().GetPorts().Return(nil, nil).Times(1)
+			mockSystemResources.EXPECT().GetUnixSockets().Return(nil, nil).Times(1)
+		})
+
+		It("records where the secrets are", func() {
+			processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(processes[0].Secrets).To(Equal([]scantron.Secret{
+				{Source: "env", Name: "DB_PASSWORD", Kind: "password"},
+				{Source: "cmdline", Name: "--password", Kind: "password"},
+				{Source: "cmdline", Name: "--token", Kind: "token"},
+				{Source: "cmdline", Name: "--db", Kind: "password in URL"},
+				{Source: "cmdline", Name: "argv[8]", Kind: "high entropy"},
+			}))
+		})
+
+		It("redacts them", func() {
+			processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(processes[0].Cmdline).To(Equal([]string{
+				"/var/vcap/packages/server/bin/server",
+				"--password=[REDACTED]",
+				"--token", "[REDACTED]",
+				"--db", "[REDACTED]",
+				"--port", "8080",
+				"[REDACTED]",
+			}))
+			Expect(processes[0].Env).To(Equal([]string{
+				"PATH=/usr/bin:/bin",
+				"DB_PASSWORD=[REDACTED]",
+				"PASSWORD_FILE=/var/vcap/jobs/server/config/password",
+			}))
+		})
+
+		It("keeps them when asked to reveal secrets", func() {
+			subject.RevealSecrets = true
+
+			processes, err := subject.ScanProcesses(scanlog.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(processes[0].Secrets).To(HaveLen(5))
+			Expect(processes[0].Cmdline[1]).To(Equal("--password=hunter2"))
+			Expect(processes[0].Env[1]).To(Equal("DB_PASSWORD=hunter2"))
+		})
+	})
 })
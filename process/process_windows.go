@@ -64,31 +64,31 @@ func (s *SystemResourceImpl) GetProcesses() ([]scantron.Process, error) {
 	return processes, nil
 }
 
-func (s *SystemResourceImpl) GetPorts() ProcessPorts {
+func (s *SystemResourceImpl) GetPorts() (ProcessPorts, error) {
 	cmd := exec.Command("powershell", "get-netudpendpoint | select localaddress,localport,owningprocess| convertto-json")
 	out, e := cmd.Output()
 	if e != nil {
-		return nil
+		return nil, e
 	}
 
 	var rawUdp = []WinPort{}
 	err := json.Unmarshal(out, &rawUdp)
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	cmd = exec.Command("powershell", "get-nettcpconnection | select @{Name='state';Expression={$_.State.ToString()}},localaddress,localport,remoteaddress,remoteport,owningprocess | convertto-json")
 	out, e = cmd.Output()
 	if e != nil {
-		return nil
+		return nil, e
 	}
 
 	var rawTcp = []WinPort{}
 	err = json.Unmarshal(out, &rawTcp)
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	ports := []ProcessPort{}
@@ -116,7 +116,12 @@ func (s *SystemResourceImpl) GetPorts() ProcessPorts {
 		})
 	}
 
-	return ports
+	return ports, nil
+}
+
+// GetUnixSockets does not look for Unix domain sockets on Windows
+func (s *SystemResourceImpl) GetUnixSockets() (ProcessUnixSockets, error) {
+	return nil, nil
 }
 
 func getEnv(pid int) []string {
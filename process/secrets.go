@@ -0,0 +1,83 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/secrets"
+)
+
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*=`)
+
+// findSecrets records the environment variables and command line arguments of
+// process that look secret and, unless reveal is set, replaces their values
+// with secrets.Redacted.
+func findSecrets(process *scantron.Process, reveal bool) {
+	process.Secrets = []scantron.Secret{}
+
+	for i, variable := range process.Env {
+		name, value := splitAssignment(variable)
+		kind := secrets.Classify(name, value)
+		if kind == "" {
+			continue
+		}
+
+		process.Secrets = append(process.Secrets, scantron.Secret{Source: scantron.SecretInEnv, Name: name, Kind: kind})
+		if !reveal {
+			process.Env[i] = name + "=" + secrets.Redacted
+		}
+	}
+
+	for i := 1; i < len(process.Cmdline); i++ {
+		arg := process.Cmdline[i]
+
+		// --password=hunter2, -Dpassword=hunter2 and PASSWORD=hunter2
+		if strings.HasPrefix(arg, "-") && strings.Contains(arg, "=") || assignment.MatchString(arg) {
+			name, value := splitAssignment(arg)
+			kind := secrets.Classify(name, value)
+			if kind == "" {
+				continue
+			}
+
+			process.Secrets = append(process.Secrets, scantron.Secret{Source: scantron.SecretInCmdline, Name: name, Kind: kind})
+			if !reveal {
+				process.Cmdline[i] = name + "=" + secrets.Redacted
+			}
+			continue
+		}
+
+		// --password hunter2
+		if strings.HasPrefix(arg, "-") && i+1 < len(process.Cmdline) && !strings.HasPrefix(process.Cmdline[i+1], "-") {
+			kind := secrets.Classify(arg, process.Cmdline[i+1])
+			if kind != "" && kind != secrets.HighEntropy {
+				process.Secrets = append(process.Secrets, scantron.Secret{Source: scantron.SecretInCmdline, Name: arg, Kind: kind})
+				if !reveal {
+					process.Cmdline[i+1] = secrets.Redacted
+				}
+				i++
+				continue
+			}
+		}
+
+		kind := secrets.Classify("", arg)
+		if kind == "" {
+			continue
+		}
+
+		process.Secrets = append(process.Secrets, scantron.Secret{Source: scantron.SecretInCmdline, Name: fmt.Sprintf("argv[%d]", i), Kind: kind})
+		if !reveal {
+			process.Cmdline[i] = secrets.Redacted
+		}
+	}
+}
+
+func splitAssignment(variable string) (string, string) {
+	parts := strings.SplitN(variable, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
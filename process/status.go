@@ -0,0 +1,119 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Status holds the fields of /proc/<pid>/status scantron records.
+type Status struct {
+	PPID int
+
+	UID  int
+	EUID int
+	GID  int
+	EGID int
+
+	CapInheritable uint64
+	CapPermitted   uint64
+	CapEffective   uint64
+	CapBounding    uint64
+	CapAmbient     uint64
+
+	NoNewPrivs bool
+	Seccomp    string
+}
+
+var seccompModes = map[string]string{
+	"0": "disabled",
+	"1": "strict",
+	"2": "filter",
+}
+
+// ParseStatus parses the contents of /proc/<pid>/status.
+func ParseStatus(contents string) (Status, error) {
+	var status Status
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := parts[0]
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "PPid":
+			status.PPID, err = strconv.Atoi(fields[0])
+		case "Uid":
+			status.UID, status.EUID, err = realAndEffective(fields)
+		case "Gid":
+			status.GID, status.EGID, err = realAndEffective(fields)
+		case "CapInh":
+			status.CapInheritable, err = strconv.ParseUint(fields[0], 16, 64)
+		case "CapPrm":
+			status.CapPermitted, err = strconv.ParseUint(fields[0], 16, 64)
+		case "CapEff":
+			status.CapEffective, err = strconv.ParseUint(fields[0], 16, 64)
+		case "CapBnd":
+			status.CapBounding, err = strconv.ParseUint(fields[0], 16, 64)
+		case "CapAmb":
+			status.CapAmbient, err = strconv.ParseUint(fields[0], 16, 64)
+		case "NoNewPrivs":
+			status.NoNewPrivs = fields[0] == "1"
+		case "Seccomp":
+			status.Seccomp = seccompModes[fields[0]]
+		}
+
+		if err != nil {
+			return Status{}, fmt.Errorf("malformed %s in process status: %s", key, err)
+		}
+	}
+
+	return status, scanner.Err()
+}
+
+func realAndEffective(fields []string) (int, int, error) {
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("expected real and effective IDs, got %q", strings.Join(fields, " "))
+	}
+
+	real, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	effective, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return real, effective, nil
+}
+
+// ParseStartTime returns when a process started, in clock ticks after boot,
+// from the contents of /proc/<pid>/stat.
+func ParseStartTime(stat string) (uint64, error) {
+	// The command name is in parentheses and may itself contain spaces or
+	// parentheses, so the fields are counted from the last one.
+	end := strings.LastIndex(stat, ")")
+	if end == -1 {
+		return 0, fmt.Errorf("malformed process stat %q", stat)
+	}
+
+	// starttime is field 22 and the fields after the name start at field 3
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed process stat %q", stat)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
@@ -0,0 +1,68 @@
+package process_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/process"
+)
+
+var _ = Describe("Process status", func() {
+	Describe("ParseStatus", func() {
+		It("reads the IDs, capabilities and security settings", func() {
+			status, err := process.ParseStatus(`Name:	nginx
+Umask:	0022
+State:	S (sleeping)
+Tgid:	4113
+Pid:	4113
+PPid:	4001
+TracerPid:	0
+Uid:	1000	0	0	0
+Gid:	1000	1000	1000	1000
+Groups:	1000
+CapInh:	0000000000000000
+CapPrm:	0000000000003000
+CapEff:	0000000000000400
+CapBnd:	000001ffffffffff
+CapAmb:	0000000000000400
+NoNewPrivs:	1
+Seccomp:	2
+Seccomp_filters:	1
+`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(status).To(Equal(process.Status{
+				PPID:           4001,
+				UID:            1000,
+				EUID:           0,
+				GID:            1000,
+				EGID:           1000,
+				CapInheritable: 0,
+				CapPermitted:   0x3000,
+				CapEffective:   0x400,
+				CapBounding:    0x1ffffffffff,
+				CapAmbient:     0x400,
+				NoNewPrivs:     true,
+				Seccomp:        "filter",
+			}))
+		})
+
+		It("returns an error for malformed fields", func() {
+			_, err := process.ParseStatus("Uid:\t1000\n")
+			Expect(err).To(MatchError(ContainSubstring("malformed Uid in process status")))
+		})
+	})
+
+	Describe("ParseStartTime", func() {
+		It("reads the start time after the command name", func() {
+			ticks, err := process.ParseStartTime("4113 (my (odd) proc) S 4001 4113 4113 0 -1 4194560 1182 0 0 0 2 1 0 0 20 0 1 0 123456 8585216 1024 18446744073709551615\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ticks).To(Equal(uint64(123456)))
+		})
+
+		It("returns an error when the stat is cut short", func() {
+			_, err := process.ParseStartTime("4113 (nginx) S 4001")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -4,5 +4,6 @@ import "github.com/pivotal-cf/scantron"
 
 type SystemResources interface {
 	GetProcesses() ([]scantron.Process, error)
-	GetPorts() ProcessPorts
+	GetPorts() (ProcessPorts, error)
+	GetUnixSockets() (ProcessUnixSockets, error)
 }
@@ -93,22 +93,47 @@ func (mr *MockRemoteMachineMockRecorder) UploadFile(localPath, remotePath interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockRemoteMachine)(nil).UploadFile), localPath, remotePath)
 }
 
-// DeleteFile mocks base method
-func (m *MockRemoteMachine) DeleteFile(remotePath string) error {
-	ret := m.ctrl.Call(m, "DeleteFile", remotePath)
+// CreateDirectory mocks base method
+func (m *MockRemoteMachine) CreateDirectory(remotePath string) error {
+	ret := m.ctrl.Call(m, "CreateDirectory", remotePath)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteFile indicates an expected call of DeleteFile
-func (mr *MockRemoteMachineMockRecorder) DeleteFile(remotePath interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockRemoteMachine)(nil).DeleteFile), remotePath)
+// CreateDirectory indicates an expected call of CreateDirectory
+func (mr *MockRemoteMachineMockRecorder) CreateDirectory(remotePath interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDirectory", reflect.TypeOf((*MockRemoteMachine)(nil).CreateDirectory), remotePath)
+}
+
+// ListDirectory mocks base method
+func (m *MockRemoteMachine) ListDirectory(remotePath string) ([]string, error) {
+	ret := m.ctrl.Call(m, "ListDirectory", remotePath)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDirectory indicates an expected call of ListDirectory
+func (mr *MockRemoteMachineMockRecorder) ListDirectory(remotePath interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDirectory", reflect.TypeOf((*MockRemoteMachine)(nil).ListDirectory), remotePath)
+}
+
+// DeleteDirectory mocks base method
+func (m *MockRemoteMachine) DeleteDirectory(remotePath string) error {
+	ret := m.ctrl.Call(m, "DeleteDirectory", remotePath)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDirectory indicates an expected call of DeleteDirectory
+func (mr *MockRemoteMachineMockRecorder) DeleteDirectory(remotePath interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDirectory", reflect.TypeOf((*MockRemoteMachine)(nil).DeleteDirectory), remotePath)
 }
 
 // RunCommand mocks base method
-func (m *MockRemoteMachine) RunCommand(arg0 string) (io.Reader, error) {
+func (m *MockRemoteMachine) RunCommand(arg0 string) (io.ReadCloser, error) {
 	ret := m.ctrl.Call(m, "RunCommand", arg0)
-	ret0, _ := ret[0].(io.Reader)
+	ret0, _ := ret[0].(io.ReadCloser)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -118,6 +143,19 @@ func (mr *MockRemoteMachineMockRecorder) RunCommand(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommand", reflect.TypeOf((*MockRemoteMachine)(nil).RunCommand), arg0)
 }
 
+// RunPrivilegedCommand mocks base method
+func (m *MockRemoteMachine) RunPrivilegedCommand(arg0 string) (io.ReadCloser, error) {
+	ret := m.ctrl.Call(m, "RunPrivilegedCommand", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunPrivilegedCommand indicates an expected call of RunPrivilegedCommand
+func (mr *MockRemoteMachineMockRecorder) RunPrivilegedCommand(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunPrivilegedCommand", reflect.TypeOf((*MockRemoteMachine)(nil).RunPrivilegedCommand), arg0)
+}
+
 // Close mocks base method
 func (m *MockRemoteMachine) Close() error {
 	ret := m.ctrl.Call(m, "Close")
@@ -0,0 +1,63 @@
+package remotemachine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	Linux   = "linux"
+	Windows = "windows"
+)
+
+var linuxOSNames = []string{"linux", "ubuntu", "trusty", "xenial", "bionic", "jammy", "noble", "centos", "rhel"}
+
+// OSFamily works out which build of proc_scan can run on a machine from
+// either a stemcell OS name (ubuntu-xenial, windows2019) or a family name.
+func OSFamily(osName string) (string, error) {
+	name := strings.ToLower(osName)
+
+	if strings.Contains(name, Windows) {
+		return Windows, nil
+	}
+
+	for _, linuxName := range linuxOSNames {
+		if strings.Contains(name, linuxName) {
+			return Linux, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported OS '%s'", osName)
+}
+
+// DetectOSName asks the machine what it is running for when the OS cannot be
+// worked out from its stemcell.
+func DetectOSName(machine RemoteMachine) (string, error) {
+	uname, err := readCommand(machine, "uname -s")
+	if err == nil && strings.TrimSpace(uname) == "Linux" {
+		return Linux, nil
+	}
+
+	ver, err := readCommand(machine, "ver")
+	if err == nil && strings.Contains(ver, "Windows") {
+		return Windows, nil
+	}
+
+	return "", fmt.Errorf("could not detect the OS of %s", machine.Host())
+}
+
+func readCommand(machine RemoteMachine, command string) (string, error) {
+	output, err := machine.RunCommand(command)
+	if err != nil {
+		return "", err
+	}
+
+	bs, err := ioutil.ReadAll(output)
+	if err != nil {
+		output.Close()
+		return "", err
+	}
+
+	return string(bs), output.Close()
+}
@@ -0,0 +1,73 @@
+package remotemachine_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/remotemachine"
+)
+
+var _ = Describe("OSFamily", func() {
+	It("recognises stemcell OS names", func() {
+		Expect(remotemachine.OSFamily("ubuntu-xenial")).To(Equal(remotemachine.Linux))
+		Expect(remotemachine.OSFamily("trusty")).To(Equal(remotemachine.Linux))
+		Expect(remotemachine.OSFamily("windows2016")).To(Equal(remotemachine.Windows))
+		Expect(remotemachine.OSFamily("linux")).To(Equal(remotemachine.Linux))
+	})
+
+	It("returns an error for OSes proc_scan cannot run on", func() {
+		_, err := remotemachine.OSFamily("plan9")
+		Expect(err).To(MatchError("unsupported OS 'plan9'"))
+
+		_, err = remotemachine.OSFamily("")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func output(s string) io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewBufferString(s))
+}
+
+var _ = Describe("DetectOSName", func() {
+	var (
+		mockCtrl *gomock.Controller
+		machine  *remotemachine.MockRemoteMachine
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		machine = remotemachine.NewMockRemoteMachine(mockCtrl)
+		machine.EXPECT().Host().Return("10.0.0.1").AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("detects linux with uname", func() {
+		machine.EXPECT().RunCommand("uname -s").Return(output("Linux\n"), nil)
+
+		Expect(remotemachine.DetectOSName(machine)).To(Equal(remotemachine.Linux))
+	})
+
+	It("detects windows with ver", func() {
+		machine.EXPECT().RunCommand("uname -s").Return(nil, errors.New("'uname' is not recognized"))
+		machine.EXPECT().RunCommand("ver").Return(output("\r\nMicrosoft Windows [Version 10.0.14393]\r\n"), nil)
+
+		Expect(remotemachine.DetectOSName(machine)).To(Equal(remotemachine.Windows))
+	})
+
+	It("returns an error when neither command works", func() {
+		machine.EXPECT().RunCommand("uname -s").Return(output("Darwin\n"), nil)
+		machine.EXPECT().RunCommand("ver").Return(nil, errors.New("command not found"))
+
+		_, err := remotemachine.DetectOSName(machine)
+		Expect(err).To(MatchError("could not detect the OS of 10.0.0.1"))
+	})
+})
@@ -0,0 +1,88 @@
+package remotemachine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	EscalateSudo             = "sudo"
+	EscalatePasswordlessSudo = "sudo-nopasswd"
+	EscalateDoas             = "doas"
+	EscalateNone             = "none"
+)
+
+// PrivilegedCommand wraps a command so that it runs as root. sudo reads the
+// password from stdin without a prompt so that it never has to appear on
+// the command line.
+func PrivilegedCommand(escalation, command string) (string, error) {
+	switch escalation {
+	case EscalateSudo, "":
+		return "sudo -S -p '' -- " + command, nil
+	case EscalatePasswordlessSudo:
+		return "sudo -n -- " + command, nil
+	case EscalateDoas:
+		return "doas -n -- " + command, nil
+	case EscalateNone:
+		return command, nil
+	}
+
+	return "", fmt.Errorf("unknown privilege escalation '%s'", escalation)
+}
+
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// ShellQuote quotes an argument for a POSIX shell. Arguments which the shell
+// would not change are left alone to keep commands readable in logs.
+func ShellQuote(arg string) string {
+	if safeShellWord.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// WindowsQuote quotes an argument so that it survives cmd.exe, which Windows
+// OpenSSH runs commands with, and then the command line parsing done by
+// Windows programs, including those written in Go. cmd.exe does not treat \"
+// as an escape, so every character it would act on is escaped with ^ too.
+func WindowsQuote(arg string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+
+	backslashes := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			quoted.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			quoted.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		quoted.WriteRune(c)
+	}
+
+	quoted.WriteString(strings.Repeat(`\`, backslashes*2))
+	quoted.WriteByte('"')
+
+	return cmdEscape(quoted.String())
+}
+
+// cmdEscape puts ^ before the characters cmd.exe would otherwise act on. A
+// ^ before % also stops cmd.exe expanding variables, since it is still part
+// of the name when it looks them up.
+func cmdEscape(s string) string {
+	var escaped strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`()%!^"<>&|`, c) {
+			escaped.WriteByte('^')
+		}
+		escaped.WriteRune(c)
+	}
+
+	return escaped.String()
+}
@@ -0,0 +1,148 @@
+package remotemachine_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/remotemachine"
+)
+
+var _ = Describe("PrivilegedCommand", func() {
+	It("reads the sudo password from stdin without a prompt", func() {
+		Expect(remotemachine.PrivilegedCommand(remotemachine.EscalateSudo, "./proc_scan")).To(Equal("sudo -S -p '' -- ./proc_scan"))
+	})
+
+	It("supports passwordless sudo, doas, and root users", func() {
+		Expect(remotemachine.PrivilegedCommand(remotemachine.EscalatePasswordlessSudo, "./proc_scan")).To(Equal("sudo -n -- ./proc_scan"))
+		Expect(remotemachine.PrivilegedCommand(remotemachine.EscalateDoas, "./proc_scan")).To(Equal("doas -n -- ./proc_scan"))
+		Expect(remotemachine.PrivilegedCommand(remotemachine.EscalateNone, "./proc_scan")).To(Equal("./proc_scan"))
+	})
+
+	It("returns an error for unknown escalations", func() {
+		_, err := remotemachine.PrivilegedCommand("su", "./proc_scan")
+		Expect(err).To(MatchError("unknown privilege escalation 'su'"))
+	})
+})
+
+var _ = Describe("ShellQuote", func() {
+	It("leaves plain words alone", func() {
+		Expect(remotemachine.ShellQuote("10.0.0.1")).To(Equal("10.0.0.1"))
+		Expect(remotemachine.ShellQuote("/var/vcap/jobs")).To(Equal("/var/vcap/jobs"))
+	})
+
+	It("quotes anything the shell would interpret", func() {
+		Expect(remotemachine.ShellQuote("a b")).To(Equal("'a b'"))
+		Expect(remotemachine.ShellQuote("$(reboot)")).To(Equal("'$(reboot)'"))
+		Expect(remotemachine.ShellQuote("it's")).To(Equal(`'it'\''s'`))
+		Expect(remotemachine.ShellQuote("")).To(Equal("''"))
+	})
+})
+
+// runByCmd is the argument a Windows program gets when cmd.exe runs it with
+// arg on its command line, or an error if cmd.exe would run something else.
+func runByCmd(arg string) (string, error) {
+	env := map[string]string{"PATH": `C:\Windows`}
+
+	// Variables are expanded first
+	var expanded strings.Builder
+	for i := 0; i < len(arg); i++ {
+		if arg[i] == '%' {
+			if end := strings.IndexByte(arg[i+1:], '%'); end >= 0 {
+				if value, ok := env[arg[i+1:i+1+end]]; ok {
+					expanded.WriteString(value)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		expanded.WriteByte(arg[i])
+	}
+
+	// Then carets escape the next character outside of quotes
+	var line strings.Builder
+	inQuotes := false
+	s := expanded.String()
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '^' && !inQuotes:
+			i++
+			c = s[i]
+		case c == '"':
+			inQuotes = !inQuotes
+		case strings.IndexByte("&|<>", c) >= 0 && !inQuotes:
+			return "", fmt.Errorf("cmd.exe would act on %q", c)
+		}
+		line.WriteByte(c)
+	}
+
+	// Then the program splits its command line into arguments
+	var parsed strings.Builder
+	s = line.String()
+	inQuotes = false
+	for i := 0; i < len(s); i++ {
+		backslashes := 0
+		for i < len(s) && s[i] == '\\' {
+			backslashes++
+			i++
+		}
+		if i < len(s) && s[i] == '"' {
+			parsed.WriteString(strings.Repeat(`\`, backslashes/2))
+			if backslashes%2 == 1 {
+				parsed.WriteByte('"')
+			} else {
+				inQuotes = !inQuotes
+			}
+			continue
+		}
+		parsed.WriteString(strings.Repeat(`\`, backslashes))
+		if i < len(s) {
+			if s[i] == ' ' && !inQuotes {
+				return "", fmt.Errorf("split into more than one argument")
+			}
+			parsed.WriteByte(s[i])
+		}
+	}
+
+	return parsed.String(), nil
+}
+
+var _ = Describe("WindowsQuote", func() {
+	It("wraps arguments in double quotes that cmd.exe leaves alone", func() {
+		Expect(remotemachine.WindowsQuote(`C:\var vcap`)).To(Equal(`^"C:\var vcap^"`))
+	})
+
+	It("escapes double quotes and the backslashes before them", func() {
+		Expect(remotemachine.WindowsQuote(`say "hi"`)).To(Equal(`^"say \^"hi\^"^"`))
+		Expect(remotemachine.WindowsQuote(`a\"b`)).To(Equal(`^"a\\\^"b^"`))
+		Expect(remotemachine.WindowsQuote(`trailing\`)).To(Equal(`^"trailing\\^"`))
+	})
+
+	It("escapes the characters cmd.exe acts on", func() {
+		Expect(remotemachine.WindowsQuote(`a & b`)).To(Equal(`^"a ^& b^"`))
+		Expect(remotemachine.WindowsQuote(`%PATH%`)).To(Equal(`^"^%PATH^%^"`))
+	})
+
+	It("gives programs run by cmd.exe the argument unchanged", func() {
+		for _, arg := range []string{
+			`C:\var vcap`,
+			`say "hi"`,
+			`a\"b`,
+			`trailing\`,
+			`trailing space\ `,
+			`" & calc & "`,
+			`password" | more > out.txt & "`,
+			`%PATH%`,
+			`"%PATH%"`,
+			`(x|y)^!<z>`,
+			``,
+		} {
+			parsed, err := runByCmd(remotemachine.WindowsQuote(arg))
+			Expect(err).NotTo(HaveOccurred(), arg)
+			Expect(parsed).To(Equal(arg))
+		}
+	})
+})
@@ -1,12 +1,18 @@
 package remotemachine
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
@@ -18,22 +24,27 @@ type RemoteMachine interface {
 	Password() string
 
 	UploadFile(localPath, remotePath string) error
-	DeleteFile(remotePath string) error
+	CreateDirectory(remotePath string) error
+	ListDirectory(remotePath string) ([]string, error)
+	DeleteDirectory(remotePath string) error
 
-	RunCommand(string) (io.Reader, error)
+	RunCommand(string) (io.ReadCloser, error)
+	RunPrivilegedCommand(string) (io.ReadCloser, error)
 
 	Close() error
 }
 
 type remoteMachine struct {
 	machine scantron.Machine
+	logger  scanlog.Logger
 
 	conn *ssh.Client
 }
 
-func NewRemoteMachine(machine scantron.Machine) RemoteMachine {
+func NewRemoteMachine(machine scantron.Machine, logger scanlog.Logger) RemoteMachine {
 	return &remoteMachine{
 		machine: machine,
+		logger:  logger.With("host", machine.Address),
 	}
 }
 
@@ -53,52 +64,152 @@ func (r *remoteMachine) Password() string {
 	return r.machine.Password
 }
 
+// UploadFile copies the file to the machine and then reads it back to check
+// that what arrived is exactly what was sent before anything executes it.
 func (r *remoteMachine) UploadFile(localPath, remotePath string) error {
-	conn, err := r.sshConn()
+	client, err := r.sftpClient()
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	sftp, err := sftp.NewClient(conn)
+	srcFile, err := os.Open(localPath)
 	if err != nil {
 		return err
 	}
-	defer sftp.Close()
+	defer srcFile.Close()
 
-	srcFile, err := os.Open(localPath)
+	localHash := sha256.New()
+	dstFile, err := client.Create(remotePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = dstFile.ReadFrom(io.TeeReader(srcFile, localHash))
+	if err != nil {
+		dstFile.Close()
+		return err
+	}
+
+	err = dstFile.Close()
+	if err != nil {
+		return err
+	}
+
+	err = client.Chmod(remotePath, 0700)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
 
-	dstFile, err := sftp.Create(remotePath)
+	uploadedFile, err := client.Open(remotePath)
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
+	defer uploadedFile.Close()
 
-	dstFile.ReadFrom(srcFile)
-	sftp.Chmod(remotePath, 0700)
+	remoteHash := sha256.New()
+	_, err = io.Copy(remoteHash, uploadedFile)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(localHash.Sum(nil), remoteHash.Sum(nil)) {
+		return fmt.Errorf("checksum of uploaded file %s does not match %s", remotePath, localPath)
+	}
 
 	return nil
 }
 
-func (r *remoteMachine) DeleteFile(remotePath string) error {
-	conn, err := r.sshConn()
+func (r *remoteMachine) CreateDirectory(remotePath string) error {
+	client, err := r.sftpClient()
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	sftp, err := sftp.NewClient(conn)
+	err = client.Mkdir(remotePath)
 	if err != nil {
 		return err
 	}
-	defer sftp.Close()
 
-	return sftp.Remove(remotePath)
+	return client.Chmod(remotePath, 0700)
 }
 
-func (r *remoteMachine) RunCommand(command string) (io.Reader, error) {
+func (r *remoteMachine) ListDirectory(remotePath string) ([]string, error) {
+	client, err := r.sftpClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	infos, err := client.ReadDir(remotePath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+
+	return names, nil
+}
+
+func (r *remoteMachine) DeleteDirectory(remotePath string) error {
+	client, err := r.sftpClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return removeAll(client, remotePath)
+}
+
+func removeAll(client *sftp.Client, remotePath string) error {
+	infos, err := client.ReadDir(remotePath)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		child := path.Join(remotePath, info.Name())
+
+		if info.IsDir() {
+			err = removeAll(client, child)
+		} else {
+			err = client.Remove(child)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return client.RemoveDirectory(remotePath)
+}
+
+func (r *remoteMachine) RunCommand(command string) (io.ReadCloser, error) {
+	return r.runCommand(command, nil)
+}
+
+func (r *remoteMachine) RunPrivilegedCommand(command string) (io.ReadCloser, error) {
+	privilegedCommand, err := PrivilegedCommand(r.machine.Escalation, command)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdin io.Reader
+	if r.machine.Escalation == EscalateSudo || r.machine.Escalation == "" {
+		stdin = strings.NewReader(r.machine.Password + "\n")
+	}
+
+	return r.runCommand(privilegedCommand, stdin)
+}
+
+// runCommand starts the command and streams its output. The command has
+// finished once the output has been closed, and the error from Close is the
+// command's exit status.
+func (r *remoteMachine) runCommand(command string, stdin io.Reader) (io.ReadCloser, error) {
 	conn, err := r.sshConn()
 	if err != nil {
 		return nil, err
@@ -109,18 +220,58 @@ func (r *remoteMachine) RunCommand(command string) (io.Reader, error) {
 		return nil, err
 	}
 
+	session.Stdin = stdin
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
 	stderr, err := session.StderrPipe()
 	if err != nil {
+		session.Close()
 		return nil, err
 	}
-	go io.Copy(os.Stderr, stderr)
 
-	bs, err := session.Output(command)
+	err = session.Start(command)
 	if err != nil {
+		session.Close()
 		return nil, err
 	}
 
-	return bytes.NewBuffer(bs), nil
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+
+		lines := bufio.NewScanner(stderr)
+		for lines.Scan() {
+			r.logger.Infof("%s", lines.Text())
+		}
+	}()
+
+	return &commandOutput{
+		Reader:     stdout,
+		session:    session,
+		stderrDone: stderrDone,
+	}, nil
+}
+
+type commandOutput struct {
+	io.Reader
+
+	session    *ssh.Session
+	stderrDone chan struct{}
+}
+
+func (o *commandOutput) Close() error {
+	defer o.session.Close()
+
+	// The command can't exit while it is blocked writing output nobody reads
+	io.Copy(ioutil.Discard, o.Reader)
+	<-o.stderrDone
+
+	return o.session.Wait()
 }
 
 func (r *remoteMachine) auth() []ssh.AuthMethod {
@@ -135,6 +286,15 @@ func (r *remoteMachine) auth() []ssh.AuthMethod {
 	}
 }
 
+func (r *remoteMachine) sftpClient() (*sftp.Client, error) {
+	conn, err := r.sshConn()
+	if err != nil {
+		return nil, err
+	}
+
+	return sftp.NewClient(conn)
+}
+
 func (r *remoteMachine) sshConn() (*ssh.Client, error) {
 	if r.conn != nil {
 		return r.conn, nil
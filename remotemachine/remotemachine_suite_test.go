@@ -0,0 +1,13 @@
+package remotemachine_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRemoteMachine(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Remote Machine Suite")
+}
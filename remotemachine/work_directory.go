@@ -0,0 +1,47 @@
+package remotemachine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// WorkDirectoryPrefix starts the name of every directory scantron creates on
+// a machine so that the ones left behind by interrupted scans can be found.
+const WorkDirectoryPrefix = "scantron-"
+
+func NewWorkDirectoryName() (string, error) {
+	id := make([]byte, 8)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	return WorkDirectoryPrefix + hex.EncodeToString(id), nil
+}
+
+// CleanupWorkDirectories removes every scantron directory from the home
+// directory of the user and returns the ones it removed.
+func CleanupWorkDirectories(machine RemoteMachine) ([]string, error) {
+	names, err := machine.ListDirectory(".")
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, WorkDirectoryPrefix) {
+			continue
+		}
+
+		err = machine.DeleteDirectory(name)
+		if err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, name)
+	}
+
+	return removed, nil
+}
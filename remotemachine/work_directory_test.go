@@ -0,0 +1,56 @@
+package remotemachine_test
+
+import (
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/remotemachine"
+)
+
+var _ = Describe("Work directories", func() {
+	var (
+		mockCtrl *gomock.Controller
+		machine  *remotemachine.MockRemoteMachine
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		machine = remotemachine.NewMockRemoteMachine(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("names each one uniquely", func() {
+		first, err := remotemachine.NewWorkDirectoryName()
+		Expect(err).NotTo(HaveOccurred())
+		second, err := remotemachine.NewWorkDirectoryName()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(first).To(MatchRegexp("^scantron-[0-9a-f]{16}$"))
+		Expect(first).NotTo(Equal(second))
+	})
+
+	It("removes the ones left behind in the home directory", func() {
+		machine.EXPECT().ListDirectory(".").Return([]string{".bashrc", "scantron-0123456789abcdef", "notes", "scantron-fedcba9876543210"}, nil)
+		machine.EXPECT().DeleteDirectory("scantron-0123456789abcdef").Return(nil)
+		machine.EXPECT().DeleteDirectory("scantron-fedcba9876543210").Return(nil)
+
+		removed, err := remotemachine.CleanupWorkDirectories(machine)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(Equal([]string{"scantron-0123456789abcdef", "scantron-fedcba9876543210"}))
+	})
+
+	It("stops at the first one it cannot remove", func() {
+		machine.EXPECT().ListDirectory(".").Return([]string{"scantron-0123456789abcdef", "scantron-fedcba9876543210"}, nil)
+		machine.EXPECT().DeleteDirectory("scantron-0123456789abcdef").Return(errors.New("permission denied"))
+
+		removed, err := remotemachine.CleanupWorkDirectories(machine)
+		Expect(err).To(MatchError("permission denied"))
+		Expect(removed).To(BeEmpty())
+	})
+})
@@ -0,0 +1,25 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Container images and volumes have their own users, setuid binaries and
+// temporary directories, so they are left out of the file reports.
+var containerStoragePaths = []string{
+	"/var/vcap/data/grootfs/",
+	"/var/vcap/data/garden/",
+	"/var/vcap/data/docker/",
+	"/var/lib/docker/",
+	"/var/lib/containerd/",
+}
+
+func notInContainerStorage(column string) string {
+	conditions := []string{}
+	for _, path := range containerStoragePaths {
+		conditions = append(conditions, fmt.Sprintf("%s NOT LIKE '%s%%'", column, path))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
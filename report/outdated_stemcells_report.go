@@ -0,0 +1,117 @@
+package report
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildOutdatedStemcellsReport(database *db.Database) (Report, error) {
+	latest, err := latestStemcellVersions(database)
+	if err != nil {
+		return Report{}, err
+	}
+
+	rows, err := database.DB().Query(`
+    SELECT DISTINCT h.name, b.az, s.name, s.version
+    FROM bosh_instances b
+      JOIN hosts h
+        ON b.host_id = h.id
+      JOIN stemcells s
+        ON b.stemcell_id = s.id
+    ORDER BY h.name
+    `)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "VMs running an outdated stemcell:",
+		Header: []string{"Identity", "AZ", "Stemcell", "Version", "Latest Version"},
+		Footnote: "The latest version is the newest version of the stemcell " +
+			"found in any deployment in the database.",
+	}
+
+	for rows.Next() {
+		var hostname, az, stemcell, version string
+
+		err := rows.Scan(&hostname, &az, &stemcell, &version)
+		if err != nil {
+			return Report{}, err
+		}
+
+		if compareVersions(version, latest[stemcell]) >= 0 {
+			continue
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			az,
+			stemcell,
+			version,
+			latest[stemcell],
+		})
+	}
+
+	return report, nil
+}
+
+func latestStemcellVersions(database *db.Database) (map[string]string, error) {
+	rows, err := database.DB().Query(`SELECT DISTINCT name, version FROM stemcells`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	latest := map[string]string{}
+	for rows.Next() {
+		var name, version string
+
+		err := rows.Scan(&name, &version)
+		if err != nil {
+			return nil, err
+		}
+
+		if compareVersions(version, latest[name]) > 0 {
+			latest[name] = version
+		}
+	}
+
+	return latest, nil
+}
+
+// compareVersions compares dotted stemcell versions segment by segment so
+// that 97.10 is newer than 97.9.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+
+		switch {
+		case xErr == nil && yErr == nil && xn != yn:
+			if xn < yn {
+				return -1
+			}
+			return 1
+		case (xErr != nil || yErr != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,78 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+	"github.com/pivotal-cf/scantron/scanner"
+)
+
+var _ = Describe("BuildOutdatedStemcellsReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	instance := func(group, az, version string) scanner.JobResult {
+		return scanner.JobResult{
+			Job: group + "/" + az,
+			IP:  "10.0.0.1",
+			BoshInstance: &scanner.BoshInstance{
+				InstanceGroup:   group,
+				AZ:              az,
+				StemcellName:    "bosh-aws-xen-hvm-ubuntu-xenial-go_agent",
+				StemcellVersion: version,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = database.SaveReport("cf2", scanner.ScanResult{
+			JobResults: []scanner.JobResult{
+				instance("router", "z1", "97.9"),
+				instance("diego_cell", "z2", "97.10"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = database.SaveReport("mysql", scanner.ScanResult{
+			JobResults: []scanner.JobResult{
+				instance("mysql", "z1", "97.10"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows VMs running an older stemcell than the newest one scanned", func() {
+		r, err := report.BuildOutdatedStemcellsReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("VMs running an outdated stemcell:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "AZ", "Stemcell", "Version", "Latest Version"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"router/z1", "z1", "bosh-aws-xen-hvm-ubuntu-xenial-go_agent", "97.9", "97.10"},
+		}))
+	})
+})
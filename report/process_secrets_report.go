@@ -0,0 +1,50 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+func BuildProcessSecretsReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, pr.name, ps.source, ps.name, ps.kind
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN process_secrets ps
+        ON ps.process_id = pr.id
+    ORDER BY h.name, pr.name, ps.source, ps.name
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "Processes given secrets in their environment or command line:",
+		Header: []string{"Identity", "Process Name", "Source", "Name", "Kind"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			processName string
+			source      string
+			name        string
+			kind        string
+		)
+
+		err := rows.Scan(&hostname, &processName, &source, &name, &kind)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			processName,
+			source,
+			name,
+			kind,
+		})
+	}
+
+	return report, nil
+}
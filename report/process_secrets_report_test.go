@@ -0,0 +1,50 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildProcessSecretsReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows processes given secrets", func() {
+		r, err := report.BuildProcessSecretsReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Processes given secrets in their environment or command line:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Process Name", "Source", "Name", "Kind"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host2", "command2", "cmdline", "--token", "token"},
+			{"host2", "command2", "env", "DB_PASSWORD", "password"},
+		}))
+	})
+})
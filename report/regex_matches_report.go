@@ -0,0 +1,63 @@
+package report
+
+import (
+	"strconv"
+
+	"github.com/pivotal-cf/scantron/db"
+)
+
+func BuildRegexMatchesReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT cr.regex, fr.rule, fr.severity, h.name, f.path, fr.line_number, fr.match_count, fr.excerpt
+    FROM file_to_regex fr
+      JOIN regexes cr
+        ON cr.id = fr.content_regex_id
+      JOIN files f
+        ON f.id = fr.file_id
+      JOIN hosts h
+        ON h.id = f.host_id
+    GROUP BY cr.regex, fr.rule, h.name, f.path
+    ORDER BY cr.regex, h.name, f.path
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "Files with content matching a regex:",
+		Header: []string{"Content Regex", "Rule", "Severity", "Identity", "Path", "Line", "Matches", "Excerpt"},
+	}
+
+	for rows.Next() {
+		var (
+			regex      string
+			rule       string
+			severity   string
+			hostname   string
+			filepath   string
+			lineNumber int
+			matchCount int
+			excerpt    string
+		)
+
+		err := rows.Scan(&regex, &rule, &severity, &hostname, &filepath, &lineNumber, &matchCount, &excerpt)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			regex,
+			rule,
+			severity,
+			hostname,
+			filepath,
+			strconv.Itoa(lineNumber),
+			strconv.Itoa(matchCount),
+			excerpt,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildRegexMatchesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows where each regex matched on each host", func() {
+		r, err := report.BuildRegexMatchesReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Files with content matching a regex:"))
+		Expect(r.Header).To(Equal([]string{"Content Regex", "Rule", "Severity", "Identity", "Path", "Line", "Matches", "Excerpt"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"-----BEGIN ((RSA|DSA|EC|OPENSSH|ENCRYPTED) )?PRIVATE KEY-----", "secrets/private-key", "critical", "host2", "/var/vcap/jobs/app/config/app.yml", "20", "1", "-----BEGI*****************-----"},
+			{"password: \\S+", "", "", "host2", "/var/vcap/jobs/app/config/app.yml", "12", "2", "  pass**************"},
+			{"password: \\S+", "", "", "host3", "/var/vcap/jobs/db/config/db.conf", "3", "1", "pass**************"},
+		}))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"os"
 	"testing"
 
 	"github.com/pivotal-cf/scantron"
@@ -34,6 +35,13 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 						Path:        "/var/vcap/data/jobs/world-executable",
 						Permissions: 0001,
 					},
+					{
+						Path:        "/var/vcap/jobs/db/config/db.conf",
+						Permissions: 0600,
+						RegexMatches: []scantron.RegexMatch{
+							{ContentRegex: `password: \S+`, LineNumber: 3, ByteOffset: 41, MatchCount: 1, Excerpt: "pass**************"},
+						},
+					},
 				},
 				SSHKeys: []scantron.SSHKey{
 					{
@@ -52,6 +60,9 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								Number:         7890,
 								ForeignAddress: "0.0.0.0",
 								ForeignNumber:  -1,
+
+								FirewallBlocked: true,
+								FirewallRule:    "iptables INPUT: policy drop",
 								TLSInformation: &scantron.TLSInformation{
 									Certificate: &scantron.Certificate{},
 									CipherInformation: scantron.CipherInformation{
@@ -170,12 +181,42 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 			},
 			{
 				Job: "host2",
+				Files: []scantron.File{
+					{Path: "/usr/bin/sudo", Permissions: os.ModeSetuid | 0755, User: "root", Group: "root"},
+					{Path: "/var/vcap/packages/helper/bin/helper", Permissions: os.ModeSetuid | os.ModeSetgid | 0755, User: "root", Group: "vcap"},
+					{Path: "/usr/bin/ssh-agent", Permissions: os.ModeSetgid | 0755, User: "root", Group: "ssh"},
+					{Path: "/var/vcap/data/grootfs/store/volumes/abc/bin/su", Permissions: os.ModeSetuid | 0755, User: "root", Group: "root"},
+					{Path: "/tmp", Permissions: os.ModeDir | os.ModeSticky | 0777, User: "root", Group: "root"},
+					{Path: "/tmp/scratch", Permissions: 0666, User: "vcap", Group: "vcap"},
+					{Path: "/var/vcap/data/shared", Permissions: os.ModeDir | 0777, User: "vcap", Group: "vcap"},
+					{Path: "/var/vcap/data/upload", Permissions: os.ModeDir | os.ModeSticky | 0777, User: "vcap", Group: "vcap"},
+					{Path: "/var/vcap/data/jobs/some-job", Permissions: os.ModeDir | 0755, User: "root", Group: "1003", OrphanGID: true},
+					{Path: "/var/vcap/store/orphan", Permissions: 0640, User: "1001", Group: "vcap", OrphanUID: true},
+					{Path: "/var/vcap/data/numbered", Permissions: 0644, User: "1002", Group: "1002"},
+					{Path: "/var/vcap/data/grootfs/store/volumes/abc/etc/passwd", Permissions: 0644, User: "4294967294", Group: "4294967294"},
+					{
+						Path:        "/var/vcap/jobs/app/config/app.yml",
+						Permissions: 0600,
+						User:        "vcap",
+						Group:       "vcap",
+						RegexMatches: []scantron.RegexMatch{
+							{PathRegex: "config", ContentRegex: `password: \S+`, LineNumber: 12, ByteOffset: 230, MatchCount: 2, Excerpt: "  pass**************"},
+							{ContentRegex: "-----BEGIN ((RSA|DSA|EC|OPENSSH|ENCRYPTED) )?PRIVATE KEY-----", LineNumber: 20, ByteOffset: 415, MatchCount: 1, Excerpt: "-----BEGI*****************-----", Rule: "secrets/private-key", Severity: "critical"},
+						},
+					},
+				},
 				SSHKeys: []scantron.SSHKey{
 					{
 						Type: "ssh-rsa",
 						Key:  "SSH KEY 2",
 					},
 				},
+				Packages: []scantron.Package{
+					{Name: "libssl1.1", Version: "1.1.1f-1ubuntu2.16", Ecosystem: scantron.DpkgEcosystem, Source: "openssl"},
+					{Name: "bash", Version: "5.0-6ubuntu1.2", Ecosystem: scantron.DpkgEcosystem},
+					{Name: "golang.org/x/net", Version: "v0.7.0", Ecosystem: scantron.GoEcosystem, Executable: "/var/vcap/packages/app/bin/app"},
+				},
+				OSRelease: &scantron.OSRelease{ID: "ubuntu", VersionID: "20.04"},
 				Services: []scantron.Process{
 					{
 						CommandName: "command2",
@@ -195,6 +236,20 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								},
 							},
 						},
+						UnixSockets: []scantron.UnixSocket{
+							{
+								Path:        "/var/vcap/sys/run/command2/admin.sock",
+								Permissions: 0777,
+							},
+							{
+								Path:        "/var/vcap/sys/run/command2/private.sock",
+								Permissions: 0660,
+							},
+						},
+						Secrets: []scantron.Secret{
+							{Source: "env", Name: "DB_PASSWORD", Kind: "password"},
+							{Source: "cmdline", Name: "--token", Kind: "token"},
+						},
 					},
 					{
 						CommandName: "some-non-root-process",
@@ -208,6 +263,12 @@ func createTestDatabase(databasePath string) (*db.Database, error) {
 								ForeignNumber:  -1,
 							},
 						},
+						UnixSockets: []scantron.UnixSocket{
+							{
+								Path:        "/var/vcap/sys/run/some-non-root-process/app.sock",
+								Permissions: 0666,
+							},
+						},
 					},
 				},
 			},
@@ -7,7 +7,7 @@ import (
 
 func BuildRootProcessesReport(database *db.Database) (Report, error) {
 	rows, err := database.DB().Query(`
-	SELECT DISTINCT h.name, po.number, pr.name
+	SELECT h.name, po.number, pr.name, min(coalesce(po.firewall_blocked, 0))
     FROM hosts h
       JOIN processes pr
         ON h.id = pr.host_id
@@ -19,6 +19,7 @@ func BuildRootProcessesReport(database *db.Database) (Report, error) {
     AND po.address NOT LIKE "169.%"
     AND (pr.user = "root" OR pr.user = "SYSTEM")
     AND pr.name NOT IN ('sshd', 'rpcbind')
+    GROUP BY h.name, po.number, pr.name
     ORDER BY h.name, po.number
 	`)
 	if err != nil {
@@ -29,7 +30,7 @@ func BuildRootProcessesReport(database *db.Database) (Report, error) {
 
 	report := Report{
 		Title:  "Externally-accessible processes running as root:",
-		Header: []string{"Identity", "Port", "Process Name"},
+		Header: []string{"Identity", "Port", "Process Name", "Firewall"},
 	}
 
 	for rows.Next() {
@@ -37,17 +38,24 @@ func BuildRootProcessesReport(database *db.Database) (Report, error) {
 			hostname    string
 			processName string
 			portNumber  int
+			blocked     bool
 		)
 
-		err := rows.Scan(&hostname, &portNumber, &processName)
+		err := rows.Scan(&hostname, &portNumber, &processName, &blocked)
 		if err != nil {
 			return Report{}, err
 		}
 
+		firewall := ""
+		if blocked {
+			firewall = "blocked by host firewall"
+		}
+
 		report.Rows = append(report.Rows, []string{
 			hostname,
 			fmt.Sprintf("%d", portNumber),
 			processName,
+			firewall,
 		})
 	}
 
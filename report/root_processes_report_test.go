@@ -41,15 +41,15 @@ var _ = Describe("BuildRootProcessesReport", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(r.Title).To(Equal("Externally-accessible processes running as root:"))
-		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name"}))
+		Expect(r.Header).To(Equal([]string{"Identity", "Port", "Process Name", "Firewall"}))
 		Expect(r.Rows).To(HaveLen(6))
 		Expect(r.Rows).To(Equal([][]string{
-			{"host1", "7890", "command1"},
-			{"host1", "19999", "command2"},
-			{"host2", "19999", "command2"},
-			{"host3", "7890", "command1"},
-			{"winhost1", "19998", "command.exe"},
-			{"winhost1", "19999", "command2.exe"},
+			{"host1", "7890", "command1", ""},
+			{"host1", "19999", "command2", ""},
+			{"host2", "19999", "command2", ""},
+			{"host3", "7890", "command1", "blocked by host firewall"},
+			{"winhost1", "19998", "command.exe", ""},
+			{"winhost1", "19999", "command2.exe", ""},
 		}))
 	})
 })
@@ -0,0 +1,105 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+// ExpectedSUIDFiles are the setuid and setgid executables that come with
+// Ubuntu stemcells.
+var ExpectedSUIDFiles = map[string]bool{
+	"/bin/fusermount":              true,
+	"/bin/mount":                   true,
+	"/bin/ping":                    true,
+	"/bin/ping6":                   true,
+	"/bin/su":                      true,
+	"/bin/umount":                  true,
+	"/sbin/pam_extrausers_chkpwd":  true,
+	"/sbin/unix_chkpwd":            true,
+	"/usr/bin/at":                  true,
+	"/usr/bin/bsd-write":           true,
+	"/usr/bin/chage":               true,
+	"/usr/bin/chfn":                true,
+	"/usr/bin/chsh":                true,
+	"/usr/bin/crontab":             true,
+	"/usr/bin/expiry":              true,
+	"/usr/bin/fusermount":          true,
+	"/usr/bin/gpasswd":             true,
+	"/usr/bin/mlocate":             true,
+	"/usr/bin/mount":               true,
+	"/usr/bin/newgidmap":           true,
+	"/usr/bin/newgrp":              true,
+	"/usr/bin/newuidmap":           true,
+	"/usr/bin/passwd":              true,
+	"/usr/bin/pkexec":              true,
+	"/usr/bin/ssh-agent":           true,
+	"/usr/bin/su":                  true,
+	"/usr/bin/sudo":                true,
+	"/usr/bin/traceroute6.iputils": true,
+	"/usr/bin/umount":              true,
+	"/usr/bin/wall":                true,
+	"/usr/lib/dbus-1.0/dbus-daemon-launch-helper": true,
+	"/usr/lib/eject/dmcrypt-get-device":           true,
+	"/usr/lib/openssh/ssh-keysign":                true,
+	"/usr/lib/policykit-1/polkit-agent-helper-1":  true,
+	"/usr/lib/x86_64-linux-gnu/utempter/utempter": true,
+	"/usr/sbin/pam_extrausers_chkpwd":             true,
+	"/usr/sbin/unix_chkpwd":                       true,
+}
+
+func BuildUnexpectedSUIDFilesReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, f.path, f.user, f.file_group, f.setuid, f.setgid
+    FROM hosts h
+      JOIN files f
+        ON h.id = f.host_id
+    WHERE (f.setuid OR f.setgid)
+      AND NOT f.directory
+      AND ` + notInContainerStorage("f.path") + `
+    ORDER BY h.name, f.path
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "Unexpected setuid and setgid executables:",
+		Header: []string{"Identity", "Path", "User", "Group", "Bits"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname string
+			filepath string
+			user     string
+			group    string
+			setuid   bool
+			setgid   bool
+		)
+
+		err := rows.Scan(&hostname, &filepath, &user, &group, &setuid, &setgid)
+		if err != nil {
+			return Report{}, err
+		}
+
+		if ExpectedSUIDFiles[filepath] {
+			continue
+		}
+
+		bits := "setuid"
+		if setuid && setgid {
+			bits = "setuid, setgid"
+		} else if setgid {
+			bits = "setgid"
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			filepath,
+			user,
+			group,
+			bits,
+		})
+	}
+
+	return report, nil
+}
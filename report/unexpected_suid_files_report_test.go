@@ -0,0 +1,49 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildUnexpectedSUIDFilesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows setuid and setgid executables that do not come with the stemcell", func() {
+		r, err := report.BuildUnexpectedSUIDFilesReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Unexpected setuid and setgid executables:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Path", "User", "Group", "Bits"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host2", "/var/vcap/packages/helper/bin/helper", "root", "vcap", "setuid, setgid"},
+		}))
+	})
+})
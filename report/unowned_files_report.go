@@ -0,0 +1,48 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+func BuildUnownedFilesReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, f.path, f.user, f.file_group
+    FROM hosts h
+      JOIN files f
+        ON h.id = f.host_id
+    WHERE (f.orphan_uid OR f.orphan_gid)
+      AND ` + notInContainerStorage("f.path") + `
+    ORDER BY h.name, f.path
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "Files owned by users or groups that do not exist:",
+		Header: []string{"Identity", "Path", "User", "Group"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname string
+			filepath string
+			user     string
+			group    string
+		)
+
+		err := rows.Scan(&hostname, &filepath, &user, &group)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			filepath,
+			user,
+			group,
+		})
+	}
+
+	return report, nil
+}
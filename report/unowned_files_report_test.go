@@ -0,0 +1,50 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildUnownedFilesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows files owned by users or groups that do not exist", func() {
+		r, err := report.BuildUnownedFilesReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Files owned by users or groups that do not exist:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Path", "User", "Group"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host2", "/var/vcap/data/jobs/some-job", "root", "1003"},
+			{"host2", "/var/vcap/store/orphan", "1001", "vcap"},
+		}))
+	})
+})
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/vulns"
+)
+
+func BuildVulnerablePackagesReport(database *db.Database, feed vulns.Feed) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT h.name, p.name, p.version, p.ecosystem, p.source, p.executable,
+           coalesce(r.os_id, ''), coalesce(r.version_id, '')
+    FROM packages p
+      JOIN hosts h
+        ON h.id = p.host_id
+      LEFT JOIN os_releases r
+        ON r.host_id = h.id
+    ORDER BY h.name, p.ecosystem, p.name, p.executable
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "Packages with known vulnerabilities:",
+		Header: []string{"ID", "Aliases", "Severity", "Identity", "Package", "Version", "Fixed", "Executable", "Summary"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname string
+			pkg      scantron.Package
+			release  scantron.OSRelease
+		)
+
+		err := rows.Scan(&hostname, &pkg.Name, &pkg.Version, &pkg.Ecosystem, &pkg.Source, &pkg.Executable, &release.ID, &release.VersionID)
+		if err != nil {
+			return Report{}, err
+		}
+
+		for _, match := range feed.Match(pkg, release) {
+			report.Rows = append(report.Rows, []string{
+				match.ID,
+				strings.Join(match.Aliases, ", "),
+				match.Severity,
+				hostname,
+				pkg.Name,
+				pkg.Version,
+				match.Fixed,
+				pkg.Executable,
+				match.Summary,
+			})
+		}
+	}
+
+	return report, rows.Err()
+}
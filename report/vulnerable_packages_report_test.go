@@ -0,0 +1,87 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+	"github.com/pivotal-cf/scantron/vulns"
+)
+
+const vulnerabilityFeed = `[
+  {
+    "id": "UBUNTU-CVE-2023-0286",
+    "summary": "X.400 address type confusion in X.509 GeneralName",
+    "severity": [{"type": "Ubuntu", "score": "high"}],
+    "affected": [{
+      "package": {"ecosystem": "Ubuntu:20.04:LTS", "name": "openssl"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.1.1f-1ubuntu2.17"}]}]
+    }]
+  },
+  {
+    "id": "DSA-5343-1",
+    "summary": "openssl security update",
+    "affected": [{
+      "package": {"ecosystem": "Debian:11", "name": "openssl"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.1.1n-0+deb11u4"}]}]
+    }]
+  },
+  {
+    "id": "GO-2023-1571",
+    "aliases": ["CVE-2022-41723", "GHSA-vvpx-j8f3-3w6h"],
+    "summary": "Denial of service via crafted HTTP/2 stream in net/http and golang.org/x/net",
+    "affected": [{
+      "package": {"ecosystem": "Go", "name": "golang.org/x/net"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.7.0"}]}]
+    }]
+  }
+]`
+
+var _ = Describe("BuildVulnerablePackagesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+		feed                 vulns.Feed
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		feedPath := filepath.Join(tmpdir, "feed.json")
+		err = ioutil.WriteFile(feedPath, []byte(vulnerabilityFeed), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		feed, err = vulns.LoadFeed(feedPath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows packages affected by vulnerabilities in the feed", func() {
+		r, err := report.BuildVulnerablePackagesReport(database, feed)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("Packages with known vulnerabilities:"))
+		Expect(r.Header).To(Equal([]string{"ID", "Aliases", "Severity", "Identity", "Package", "Version", "Fixed", "Executable", "Summary"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"UBUNTU-CVE-2023-0286", "", "high", "host2", "libssl1.1", "1.1.1f-1ubuntu2.16", "1.1.1f-1ubuntu2.17", "", "X.400 address type confusion in X.509 GeneralName"},
+		}))
+	})
+})
@@ -10,6 +10,7 @@ func BuildWorldReadableFilesReport(database *db.Database) (Report, error) {
         ON h.id = f.host_id
     WHERE f.path LIKE "/var/vcap/data/jobs/%"
       AND f.permissions & 04 != 0
+      AND NOT f.directory
     ORDER BY h.name, f.path
 	`)
 	if err != nil {
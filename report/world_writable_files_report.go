@@ -0,0 +1,56 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+func BuildWorldWritableFilesReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, f.path, f.directory, f.sticky
+    FROM hosts h
+      JOIN files f
+        ON h.id = f.host_id
+    WHERE f.permissions & 02 != 0
+      AND f.path != "/tmp" AND f.path NOT LIKE "/tmp/%"
+      AND f.path != "/var/tmp" AND f.path NOT LIKE "/var/tmp/%"
+      AND ` + notInContainerStorage("f.path") + `
+    ORDER BY h.name, f.path
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "World-writable files and directories outside /tmp:",
+		Header: []string{"Identity", "Path", "Type"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname  string
+			filepath  string
+			directory bool
+			sticky    bool
+		)
+
+		err := rows.Scan(&hostname, &filepath, &directory, &sticky)
+		if err != nil {
+			return Report{}, err
+		}
+
+		fileType := "file"
+		if directory && sticky {
+			fileType = "sticky directory"
+		} else if directory {
+			fileType = "directory"
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			filepath,
+			fileType,
+		})
+	}
+
+	return report, nil
+}
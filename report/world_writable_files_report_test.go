@@ -0,0 +1,53 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron/db"
+	"github.com/pivotal-cf/scantron/report"
+)
+
+var _ = Describe("BuildWorldWritableFilesReport", func() {
+	var (
+		databasePath, tmpdir string
+		database             *db.Database
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "report-test")
+		Expect(err).NotTo(HaveOccurred())
+		databasePath = filepath.Join(tmpdir, "db.db")
+
+		database, err = createTestDatabase(databasePath)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := database.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tmpdir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("shows world-writable files and directories outside /tmp", func() {
+		r, err := report.BuildWorldWritableFilesReport(database)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Title).To(Equal("World-writable files and directories outside /tmp:"))
+		Expect(r.Header).To(Equal([]string{"Identity", "Path", "Type"}))
+		Expect(r.Rows).To(Equal([][]string{
+			{"host1", "/root/world-everything", "file"},
+			{"host1", "/var/vcap/data/jobs/world-everything", "file"},
+			{"host2", "/var/vcap/data/shared", "directory"},
+			{"host2", "/var/vcap/data/upload", "sticky directory"},
+			{"host3", "/var/vcap/data/jobs/world-writable", "file"},
+		}))
+	})
+})
@@ -0,0 +1,48 @@
+package report
+
+import "github.com/pivotal-cf/scantron/db"
+
+func BuildWorldWritableSocketsReport(database *db.Database) (Report, error) {
+	rows, err := database.DB().Query(`
+	SELECT DISTINCT h.name, us.path, pr.name
+    FROM hosts h
+      JOIN processes pr
+        ON h.id = pr.host_id
+      JOIN unix_sockets us
+        ON us.process_id = pr.id
+    WHERE us.permissions & 02 != 0
+    AND (pr.user = "root" OR pr.user = "SYSTEM")
+    ORDER BY h.name, us.path
+	`)
+	if err != nil {
+		return Report{}, err
+	}
+
+	defer rows.Close()
+
+	report := Report{
+		Title:  "World-writable Unix sockets of root processes:",
+		Header: []string{"Identity", "Path", "Process Name"},
+	}
+
+	for rows.Next() {
+		var (
+			hostname    string
+			path        string
+			processName string
+		)
+
+		err := rows.Scan(&hostname, &path, &processName)
+		if err != nil {
+			return Report{}, err
+		}
+
+		report.Rows = append(report.Rows, []string{
+			hostname,
+			path,
+			processName,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,165 @@
+// Package resultfile reads and writes the results of a scan which was run
+// on the machine itself rather than over SSH.
+package resultfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// SignatureExtension is added to the path of a result file to get the path
+// of its signature.
+const SignatureExtension = ".sig"
+
+// Write saves systemInfo to path, compressing it if asked to. When signer is
+// given the file is signed and the signature saved next to it.
+func Write(path string, systemInfo scantron.SystemInfo, compress bool, signer ssh.Signer) error {
+	buf := &bytes.Buffer{}
+
+	var output io.Writer = buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(buf)
+		output = gz
+	}
+
+	err := json.NewEncoder(output).Encode(systemInfo)
+	if err != nil {
+		return err
+	}
+
+	if gz != nil {
+		err = gz.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	data := buf.Bytes()
+
+	err = writeFile(path, data)
+	if err != nil {
+		return err
+	}
+
+	if signer == nil {
+		return nil
+	}
+
+	signature, err := signer.Sign(rand.Reader, data)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(ssh.Marshal(signature)) + "\n"
+
+	return writeFile(path+SignatureExtension, []byte(encoded))
+}
+
+// Read loads a result file written by Write. When key is given the file must
+// have a signature made by the matching private key.
+func Read(path string, key ssh.PublicKey) (scantron.SystemInfo, error) {
+	var systemInfo scantron.SystemInfo
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return systemInfo, err
+	}
+
+	if key != nil {
+		err = verify(path, data, key)
+		if err != nil {
+			return systemInfo, err
+		}
+	}
+
+	var input io.Reader = bytes.NewReader(data)
+	if isGzip(data) {
+		gz, err := gzip.NewReader(input)
+		if err != nil {
+			return systemInfo, err
+		}
+		defer gz.Close()
+
+		input = gz
+	}
+
+	err = json.NewDecoder(input).Decode(&systemInfo)
+	if err != nil {
+		return systemInfo, fmt.Errorf("%s is not a scan result: %s", path, err)
+	}
+
+	return systemInfo, nil
+}
+
+func verify(path string, data []byte, key ssh.PublicKey) error {
+	encoded, err := ioutil.ReadFile(path + SignatureExtension)
+	if err != nil {
+		return fmt.Errorf("%s is not signed: %s", path, err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encoded)))
+	if err != nil {
+		return fmt.Errorf("signature of %s is malformed: %s", path, err)
+	}
+
+	var signature ssh.Signature
+	err = ssh.Unmarshal(raw, &signature)
+	if err != nil {
+		return fmt.Errorf("signature of %s is malformed: %s", path, err)
+	}
+
+	err = key.Verify(data, &signature)
+	if err != nil {
+		return fmt.Errorf("signature of %s does not match: %s", path, err)
+	}
+
+	return nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// writeFile writes to a temporary file first so that a scan which is
+// interrupted never leaves a partial result behind. The results can contain
+// secrets so the file is only readable by its owner.
+func writeFile(path string, data []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = tmpFile.Write(data)
+	if err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	err = os.Rename(tmpFile.Name(), path)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return nil
+}
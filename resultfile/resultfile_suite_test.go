@@ -0,0 +1,13 @@
+package resultfile_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestResultfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Result File Suite")
+}
@@ -0,0 +1,118 @@
+package resultfile_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/resultfile"
+)
+
+var _ = Describe("Result files", func() {
+	var (
+		dir        string
+		path       string
+		systemInfo scantron.SystemInfo
+		signer     ssh.Signer
+	)
+
+	newSigner := func() ssh.Signer {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		signer, err := ssh.NewSignerFromKey(key)
+		Expect(err).NotTo(HaveOccurred())
+
+		return signer
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "resultfile")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(dir, "10.0.0.1.json")
+
+		systemInfo = scantron.SystemInfo{
+			Processes: []scantron.Process{{CommandName: "java", PID: 183, User: "vcap"}},
+			Files:     []scantron.File{{Path: "/var/vcap/jobs/app/config.yml"}},
+		}
+
+		signer = newSigner()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("reads back what was written", func() {
+		Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+		read, err := resultfile.Read(path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(systemInfo))
+	})
+
+	It("reads back compressed results", func() {
+		Expect(resultfile.Write(path, systemInfo, true, nil)).To(Succeed())
+
+		read, err := resultfile.Read(path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(systemInfo))
+	})
+
+	It("only lets the owner read the results", func() {
+		Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	Context("when the results are signed", func() {
+		BeforeEach(func() {
+			Expect(resultfile.Write(path, systemInfo, true, signer)).To(Succeed())
+		})
+
+		It("verifies the signature", func() {
+			read, err := resultfile.Read(path, signer.PublicKey())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(Equal(systemInfo))
+		})
+
+		It("rejects results signed by a different key", func() {
+			_, err := resultfile.Read(path, newSigner().PublicKey())
+			Expect(err).To(MatchError(ContainSubstring("signature of " + path + " does not match")))
+		})
+
+		It("rejects results which have been changed", func() {
+			Expect(resultfile.Write(path, scantron.SystemInfo{}, true, nil)).To(Succeed())
+
+			_, err := resultfile.Read(path, signer.PublicKey())
+			Expect(err).To(MatchError(ContainSubstring("does not match")))
+		})
+	})
+
+	It("rejects unsigned results when a key is given", func() {
+		Expect(resultfile.Write(path, systemInfo, false, nil)).To(Succeed())
+
+		_, err := resultfile.Read(path, signer.PublicKey())
+		Expect(err).To(MatchError(ContainSubstring(path + " is not signed")))
+	})
+
+	It("returns an error for files which are not results", func() {
+		Expect(ioutil.WriteFile(path, []byte("not json"), 0600)).To(Succeed())
+
+		_, err := resultfile.Read(path, nil)
+		Expect(err).To(MatchError(ContainSubstring(path + " is not a scan result")))
+	})
+})
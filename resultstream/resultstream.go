@@ -0,0 +1,229 @@
+// Package resultstream writes and reads the results of proc_scan one process
+// and one file at a time, so that neither end has to hold all of their JSON,
+// which can be hundreds of megabytes, in memory at once.
+package resultstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// Sink receives the processes and files of the results as they are decoded.
+type Sink interface {
+	Process(process scantron.Process) error
+	File(file scantron.File) error
+}
+
+// Encode writes systemInfo as a JSON object like json.Marshal does, but
+// encodes its processes and files one at a time.
+func Encode(w io.Writer, systemInfo scantron.SystemInfo) error {
+	rest, err := otherFields(systemInfo)
+	if err != nil {
+		return err
+	}
+
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.write("{")
+	e.key("processes")
+	e.array(systemInfo.Processes == nil, len(systemInfo.Processes), func(i int) interface{} {
+		return systemInfo.Processes[i]
+	})
+	e.write(",")
+	e.key("files")
+	e.array(systemInfo.Files == nil, len(systemInfo.Files), func(i int) interface{} {
+		return systemInfo.Files[i]
+	})
+	for name, value := range rest {
+		e.write(",")
+		e.key(name)
+		e.raw(value)
+	}
+	e.write("}\n")
+
+	if e.err != nil {
+		return e.err
+	}
+
+	return e.w.Flush()
+}
+
+// Decode reads results written by Encode or by encoding a SystemInfo in any
+// other way. The processes and files are handed to sink as soon as each of
+// them has been read and are left out of the SystemInfo which is returned.
+func Decode(r io.Reader, sink Sink) (scantron.SystemInfo, error) {
+	var systemInfo scantron.SystemInfo
+
+	dec := json.NewDecoder(r)
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return systemInfo, err
+	}
+
+	rest := map[string]json.RawMessage{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return systemInfo, err
+		}
+		name := token.(string)
+
+		switch name {
+		case "processes":
+			err = decodeArray(dec, func() error {
+				var process scantron.Process
+				err := dec.Decode(&process)
+				if err != nil {
+					return err
+				}
+				return sink.Process(process)
+			})
+		case "files":
+			err = decodeArray(dec, func() error {
+				var file scantron.File
+				err := dec.Decode(&file)
+				if err != nil {
+					return err
+				}
+				return sink.File(file)
+			})
+		default:
+			var value json.RawMessage
+			err = dec.Decode(&value)
+			rest[name] = value
+		}
+		if err != nil {
+			return systemInfo, err
+		}
+	}
+
+	err = expectDelim(dec, '}')
+	if err != nil {
+		return systemInfo, err
+	}
+
+	// The other fields are small so they are decoded together
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return systemInfo, err
+	}
+
+	err = json.Unmarshal(data, &systemInfo)
+	return systemInfo, err
+}
+
+// otherFields are the encoded fields of systemInfo apart from its processes
+// and files.
+func otherFields(systemInfo scantron.SystemInfo) (map[string]json.RawMessage, error) {
+	systemInfo.Processes = nil
+	systemInfo.Files = nil
+
+	data, err := json.Marshal(systemInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(fields, "processes")
+	delete(fields, "files")
+
+	return fields, nil
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) write(s string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(s)
+}
+
+func (e *encoder) raw(data []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(data)
+}
+
+func (e *encoder) value(v interface{}) {
+	if e.err != nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	e.raw(data)
+}
+
+func (e *encoder) key(name string) {
+	e.value(name)
+	e.write(":")
+}
+
+// array writes null for nil slices like json.Marshal does.
+func (e *encoder) array(isNil bool, n int, item func(int) interface{}) {
+	if isNil {
+		e.write("null")
+		return
+	}
+
+	e.write("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			e.write(",")
+		}
+		e.value(item(i))
+	}
+	e.write("]")
+}
+
+func decodeArray(dec *json.Decoder, item func() error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected an array but found %v", token)
+	}
+
+	for dec.More() {
+		err = item()
+		if err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s but found %v", delim, token)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package resultstream_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestResultstream(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Result Stream Suite")
+}
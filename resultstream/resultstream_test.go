@@ -0,0 +1,152 @@
+package resultstream_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/resultstream"
+)
+
+type sliceSink struct {
+	processes []scantron.Process
+	files     []scantron.File
+	err       error
+}
+
+func (s *sliceSink) Process(process scantron.Process) error {
+	s.processes = append(s.processes, process)
+	return s.err
+}
+
+func (s *sliceSink) File(file scantron.File) error {
+	s.files = append(s.files, file)
+	return s.err
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+var _ = Describe("Result streams", func() {
+	var systemInfo scantron.SystemInfo
+
+	BeforeEach(func() {
+		systemInfo = scantron.SystemInfo{
+			Processes: []scantron.Process{
+				{CommandName: "sshd", PID: 1, User: "root", Cmdline: []string{"sshd", "-D"}},
+				{CommandName: "bash", PID: 2, User: "vcap"},
+			},
+			Files: []scantron.File{
+				{Path: "/etc/passwd", Permissions: 0644, User: "root", Group: "root", Size: 1024},
+				{Path: "/var/vcap/jobs/app/config/secret.yml", Permissions: 0600, RegexMatches: []scantron.RegexMatch{
+					{ContentRegex: "password", LineNumber: 3},
+				}},
+			},
+			SSHKeys:   []scantron.SSHKey{{Type: "ssh-ed25519", Key: "AAAA"}},
+			Packages:  []scantron.Package{{Name: "openssl", Version: "3.0.2", Ecosystem: "Debian"}},
+			OSRelease: &scantron.OSRelease{ID: "ubuntu", VersionID: "22.04"},
+			Kernel:    &scantron.Kernel{Release: "5.15.0"},
+			FirewallRulesets: []scantron.FirewallRuleset{
+				{Tool: "iptables-save", Rules: "*filter\nCOMMIT\n"},
+			},
+			Errors: []scantron.CollectionError{
+				{Subsystem: scantron.SSHSubsystem, Message: "connection refused"},
+			},
+		}
+	})
+
+	decode := func(data []byte) (scantron.SystemInfo, error) {
+		sink := &sliceSink{}
+		decoded, err := resultstream.Decode(bytes.NewReader(data), sink)
+		decoded.Processes = sink.processes
+		decoded.Files = sink.files
+		return decoded, err
+	}
+
+	It("writes the same results json.Marshal does", func() {
+		buf := &bytes.Buffer{}
+		Expect(resultstream.Encode(buf, systemInfo)).To(Succeed())
+
+		expected, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(MatchJSON(expected))
+	})
+
+	It("writes null for missing processes and files like json.Marshal does", func() {
+		systemInfo.Processes = nil
+		systemInfo.Files = nil
+
+		buf := &bytes.Buffer{}
+		Expect(resultstream.Encode(buf, systemInfo)).To(Succeed())
+
+		expected, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(MatchJSON(expected))
+	})
+
+	It("reads back what it writes", func() {
+		buf := &bytes.Buffer{}
+		Expect(resultstream.Encode(buf, systemInfo)).To(Succeed())
+
+		decoded, err := decode(buf.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+
+		expected, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+		actual, err := json.Marshal(decoded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(MatchJSON(expected))
+	})
+
+	It("reads results encoded all at once", func() {
+		data, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded, err := decode(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.Processes).To(HaveLen(2))
+		Expect(decoded.Files).To(HaveLen(2))
+		Expect(decoded.OSRelease).To(Equal(&scantron.OSRelease{ID: "ubuntu", VersionID: "22.04"}))
+		Expect(decoded.Errors).To(Equal(systemInfo.Errors))
+	})
+
+	It("hands each file to the sink as soon as it has been read", func() {
+		data, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+
+		secondFile := strings.Index(string(data), `{"path":"/var/vcap`)
+		Expect(secondFile).To(BeNumerically(">", 0))
+
+		sink := &sliceSink{}
+		_, err = resultstream.Decode(io.MultiReader(bytes.NewReader(data[:secondFile]), failingReader{}), sink)
+		Expect(err).To(MatchError("connection reset"))
+
+		Expect(sink.processes).To(HaveLen(2))
+		Expect(sink.files).To(HaveLen(1))
+		Expect(sink.files[0].Path).To(Equal("/etc/passwd"))
+	})
+
+	It("stops when the sink fails", func() {
+		data, err := json.Marshal(systemInfo)
+		Expect(err).NotTo(HaveOccurred())
+
+		sink := &sliceSink{err: errors.New("disk full")}
+		_, err = resultstream.Decode(bytes.NewReader(data), sink)
+		Expect(err).To(MatchError("disk full"))
+		Expect(sink.processes).To(HaveLen(1))
+	})
+
+	It("rejects results which are not an object", func() {
+		_, err := resultstream.Decode(strings.NewReader(`[]`), &sliceSink{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,130 @@
+// Package rules loads rule packs, which are named and versioned sets of
+// content checks for files, like the built-in secrets pack.
+package rules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rakyll/statik/fs"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/scanlog"
+	_ "github.com/pivotal-cf/scantron/statik"
+)
+
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+type Pack struct {
+	Name    string          `yaml:"name"`
+	Version string          `yaml:"version"`
+	Rules   []scantron.Rule `yaml:"rules"`
+}
+
+// IsBuiltin is whether name is one of the packs that come with scantron
+// rather than a file.
+func IsBuiltin(name string) bool {
+	if strings.ContainsAny(name, `/\.`) {
+		return false
+	}
+
+	_, err := readBuiltin(name)
+	return err == nil
+}
+
+func readBuiltin(name string) ([]byte, error) {
+	statikFS, err := fs.New()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.ReadFile(statikFS, "/assets/rules/"+name+".yml")
+}
+
+// LoadPack loads a built-in pack by name or a pack from a YAML file.
+func LoadPack(nameOrPath string) (Pack, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if IsBuiltin(nameOrPath) {
+		data, err = readBuiltin(nameOrPath)
+	} else {
+		data, err = ioutil.ReadFile(nameOrPath)
+		if os.IsNotExist(err) && !strings.ContainsAny(nameOrPath, `/\.`) {
+			return Pack{}, fmt.Errorf("unknown rule pack %q", nameOrPath)
+		}
+	}
+	if err != nil {
+		return Pack{}, err
+	}
+
+	var pack Pack
+	err = yaml.UnmarshalStrict(data, &pack)
+	if err != nil {
+		return Pack{}, fmt.Errorf("rule pack %s is malformed: %s", nameOrPath, err)
+	}
+
+	err = pack.validate()
+	if err != nil {
+		return Pack{}, fmt.Errorf("rule pack %s is invalid: %s", nameOrPath, err)
+	}
+
+	return pack, nil
+}
+
+func (p Pack) validate() error {
+	if p.Name == "" || p.Version == "" {
+		return fmt.Errorf("name and version are required")
+	}
+
+	for _, rule := range p.Rules {
+		if rule.Name == "" || rule.ContentRegex == "" {
+			return fmt.Errorf("rules need a name and content regex")
+		}
+
+		switch rule.Severity {
+		case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		default:
+			return fmt.Errorf("rule %s has unknown severity %q", rule.Name, rule.Severity)
+		}
+
+		for _, r := range []string{rule.PathRegex, rule.ContentRegex} {
+			_, err := regexp.Compile(r)
+			if err != nil {
+				return fmt.Errorf("rule %s: %s", rule.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Load returns the rules of each pack, named after the pack they are from.
+func Load(namesOrPaths []string, logger scanlog.Logger) ([]scantron.Rule, error) {
+	rules := []scantron.Rule{}
+	for _, nameOrPath := range namesOrPaths {
+		pack, err := LoadPack(nameOrPath)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Infof("Loaded %d rules from rule pack %s version %s", len(pack.Rules), pack.Name, pack.Version)
+
+		for _, rule := range pack.Rules {
+			rule.Name = pack.Name + "/" + rule.Name
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
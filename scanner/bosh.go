@@ -6,17 +6,21 @@ import (
 	"strconv"
 	"sync"
 
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+
 	"github.com/pivotal-cf/scantron/bosh"
 	"github.com/pivotal-cf/scantron/scanlog"
 )
 
 type boshScanner struct {
 	deployment bosh.TargetDeployment
+	options    Options
 }
 
-func Bosh(deployment bosh.TargetDeployment) Scanner {
+func Bosh(deployment bosh.TargetDeployment, options Options) Scanner {
 	return &boshScanner{
 		deployment: deployment,
+		options:    options,
 	}
 }
 
@@ -49,17 +53,23 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 				"address", ip,
 			)
 
-			remoteMachine := s.deployment.ConnectTo(vm)
+			remoteMachine, err := s.deployment.ConnectTo(vm)
+			if err != nil {
+				machineLogger.Errorf("Failed to connect to machine: %s", err)
+				return
+			}
 			defer remoteMachine.Close()
 
-			systemInfo, err := scanMachine(fileRegexes, machineLogger, remoteMachine)
+			systemInfo, err := scanMachine(fileRegexes, s.options, machineLogger, remoteMachine)
 			if err != nil {
 				machineLogger.Errorf("Failed to scan machine: %s", err)
 				return
 			}
 
 			boshName := fmt.Sprintf("%s/%s", vm.JobName, vm.ID)
-			hosts <- buildJobResult(systemInfo, boshName, ip)
+			jobResult := BuildJobResult(systemInfo, boshName, ip)
+			jobResult.BoshInstance = buildBoshInstance(vm, s.deployment.InstanceStemcell(vm))
+			hosts <- jobResult
 		}()
 	}
 
@@ -79,12 +89,43 @@ func (s *boshScanner) Scan(fileRegexes *scantron.FileMatch, logger scanlog.Logge
 		releaseResults = append(releaseResults, ReleaseResult{Name: release.Name(), Version: release.Version().String()})
 	}
 
+	stemcellResults := []StemcellResult{}
+	for _, stemcell := range s.deployment.Stemcells() {
+		stemcellResults = append(stemcellResults, StemcellResult{
+			Name:    stemcell.Name(),
+			OS:      stemcell.OSName(),
+			Version: stemcell.Version().String(),
+		})
+	}
+
 	return ScanResult{
-		JobResults:     scannedHosts,
-		ReleaseResults: releaseResults,
+		Director:        s.deployment.Director(),
+		JobResults:      scannedHosts,
+		ReleaseResults:  releaseResults,
+		StemcellResults: stemcellResults,
 	}, nil
 }
 
+func buildBoshInstance(vm boshdir.VMInfo, stemcell boshdir.Stemcell) *BoshInstance {
+	instance := &BoshInstance{
+		InstanceGroup: vm.JobName,
+		ID:            vm.ID,
+		Index:         vm.Index,
+		AZ:            vm.AZ,
+		VMCID:         vm.VMID,
+		Bootstrap:     vm.Bootstrap,
+		ProcessState:  vm.ProcessState,
+		IPs:           vm.IPs,
+	}
+
+	if stemcell != nil {
+		instance.StemcellName = stemcell.Name()
+		instance.StemcellVersion = stemcell.Version().String()
+	}
+
+	return instance
+}
+
 func index(index *int) string {
 	if index == nil {
 		return "?"
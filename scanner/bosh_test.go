@@ -7,6 +7,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pivotal-cf/scantron/bosh"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"io/ioutil"
 
 	"github.com/cppforlife/go-semi-semantic/version"
 	. "github.com/onsi/ginkgo"
@@ -33,6 +34,9 @@ var _ = Describe("Bosh Scanning", func() {
 		release1, release2 *directorfakes.FakeRelease
 		releaseInfo        []boshdirector.Release
 
+		stemcell     *directorfakes.FakeStemcell
+		stemcellInfo []boshdirector.Stemcell
+
 		scanResult scanner.ScanResult
 		scanErr    error
 		logger     scanlog.Logger
@@ -73,19 +77,24 @@ var _ = Describe("Bosh Scanning", func() {
 		machine.EXPECT().Address().Return("10.0.0.1:22").AnyTimes()
 		machine.EXPECT().Host().Return("10.0.0.1").AnyTimes()
 		machine.EXPECT().OSName().Return("trusty").AnyTimes()
-		machine.EXPECT().Password().Return("password").AnyTimes()
+		machine.EXPECT().CreateDirectory(matchWorkDir("WORKDIR")).Return(nil).Times(1)
+		machine.EXPECT().DeleteDirectory(matchWorkDir("WORKDIR")).Return(nil).Times(1)
 		machine.EXPECT().Close().Return(nil).Times(1)
 
 		targetDeployment = bosh.NewMockTargetDeployment(mockCtrl)
 
 		vmInfo = []boshdirector.VMInfo{
 			{
-				JobName: "service",
-				ID:      "id",
-				IPs:     []string{"10.0.0.1"},
+				JobName:      "service",
+				ID:           "id",
+				IPs:          []string{"10.0.0.1"},
+				AZ:           "z1",
+				VMID:         "vm-cid",
+				Bootstrap:    true,
+				ProcessState: "running",
 			},
 		}
-		targetDeployment.EXPECT().ConnectTo(vmInfo[0]).Return(machine).Times(1)
+		targetDeployment.EXPECT().ConnectTo(vmInfo[0]).Return(machine, nil).Times(1)
 
 		release1 = &directorfakes.FakeRelease{}
 		release1.NameReturns("release-1")
@@ -101,22 +110,33 @@ var _ = Describe("Bosh Scanning", func() {
 
 		releaseInfo = []boshdirector.Release{release1, release2}
 
-		boshScan = scanner.Bosh(targetDeployment)
+		stemcell = &directorfakes.FakeStemcell{}
+		stemcell.NameReturns("bosh-warden-boshlite-ubuntu-trusty-go_agent")
+		stemcell.OSNameReturns("ubuntu-trusty")
+		stemcellVersion, err := version.NewVersionFromString("3586.40")
+		Expect(err).NotTo(HaveOccurred())
+		stemcell.VersionReturns(stemcellVersion)
+
+		stemcellInfo = []boshdirector.Stemcell{stemcell}
+
+		boshScan = scanner.Bosh(targetDeployment, scanner.Options{})
 	})
 
 	JustBeforeEach(func() {
 		setupCall := targetDeployment.EXPECT().Setup().Times(1)
 		targetDeployment.EXPECT().Name().Return("vm").AnyTimes()
+		targetDeployment.EXPECT().Director().Return("https://10.0.0.6:25555").AnyTimes()
 		targetDeployment.EXPECT().VMs().Return(vmInfo).Times(1)
 		targetDeployment.EXPECT().Releases().Return(releaseInfo).Times(1)
+		targetDeployment.EXPECT().Stemcells().Return(stemcellInfo).Times(1)
+		targetDeployment.EXPECT().InstanceStemcell(gomock.Any()).Return(stemcell).AnyTimes()
 		targetDeployment.EXPECT().Cleanup().Times(1).After(setupCall)
 
 	})
 	Context("when no regex specified", func() {
 		It("cleans up the proc_scan binary after the scanning is done", func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
 		})
 	})
@@ -128,20 +148,19 @@ var _ = Describe("Bosh Scanning", func() {
 		})
 
 		It("uploads and cleans the proc_scan binary to the remote machine", func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --path \"interesting\" --content \"valuable\"").Return(buffer, nil).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --path interesting --content valuable")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 			scanResult, scanErr = boshScan.Scan(fileMatch, logger)
 		})
 	})
 
 	It("returns a report from the deployment", func() {
 
-		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-		machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
-		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+		machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+		machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 		scanResult, scanErr = boshScan.Scan(fileMatch, logger)
 		Expect(scanResult).To(Equal(scanner.ScanResult{
+			Director: "https://10.0.0.6:25555",
 			ReleaseResults: []scanner.ReleaseResult{
 				{
 					Name:    "release-1",
@@ -152,10 +171,28 @@ var _ = Describe("Bosh Scanning", func() {
 					Version: "2.2.2",
 				},
 			},
+			StemcellResults: []scanner.StemcellResult{
+				{
+					Name:    "bosh-warden-boshlite-ubuntu-trusty-go_agent",
+					OS:      "ubuntu-trusty",
+					Version: "3586.40",
+				},
+			},
 			JobResults: []scanner.JobResult{
 				{
-					IP:       "10.0.0.1",
-					Job:      "service/id",
+					IP:  "10.0.0.1",
+					Job: "service/id",
+					BoshInstance: &scanner.BoshInstance{
+						InstanceGroup:   "service",
+						ID:              "id",
+						AZ:              "z1",
+						VMCID:           "vm-cid",
+						Bootstrap:       true,
+						ProcessState:    "running",
+						IPs:             []string{"10.0.0.1"},
+						StemcellName:    "bosh-warden-boshlite-ubuntu-trusty-go_agent",
+						StemcellVersion: "3586.40",
+					},
 					Services: systemInfo.Processes,
 					Files:    systemInfo.Files,
 				},
@@ -166,9 +203,8 @@ var _ = Describe("Bosh Scanning", func() {
 	Context("when the vm index is nil", func() {
 		BeforeEach(func() {
 			vmInfo[0].Index = nil
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 		})
 
 		It("all still works", func() {
@@ -179,7 +215,7 @@ var _ = Describe("Bosh Scanning", func() {
 
 	Context("when uploading the scanning binary fails", func() {
 		BeforeEach(func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(errors.New("disaster")).Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(errors.New("disaster")).Times(1)
 		})
 
 		It("keeps going", func() {
@@ -190,9 +226,8 @@ var _ = Describe("Bosh Scanning", func() {
 
 	Context("when running the scanning binary fails", func() {
 		BeforeEach(func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(nil, errors.New("disaster")).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(nil, errors.New("disaster")).Times(1)
 		})
 
 		It("keeps going", func() {
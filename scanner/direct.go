@@ -10,11 +10,13 @@ import (
 
 type direct struct {
 	machine remotemachine.RemoteMachine
+	options Options
 }
 
-func Direct(machine remotemachine.RemoteMachine) Scanner {
+func Direct(machine remotemachine.RemoteMachine, options Options) Scanner {
 	return &direct{
 		machine: machine,
+		options: options,
 	}
 }
 
@@ -23,7 +25,7 @@ func (d *direct) Scan(match *scantron.FileMatch, logger scanlog.Logger) (ScanRes
 		"host", d.machine.Address(),
 	)
 
-	systemInfo, err := scanMachine(match, hostLogger, d.machine)
+	systemInfo, err := scanMachine(match, d.options, hostLogger, d.machine)
 	if err != nil {
 		hostLogger.Errorf("Failed to scan machine: %s", err)
 		return ScanResult{}, err
@@ -35,7 +37,7 @@ func (d *direct) Scan(match *scantron.FileMatch, logger scanlog.Logger) (ScanRes
 		return ScanResult{}, err
 	}
 
-	scannedHost := buildJobResult(systemInfo, hostname, hostname)
+	scannedHost := BuildJobResult(systemInfo, hostname, hostname)
 
 	return ScanResult{JobResults: []JobResult{scannedHost}}, nil
 }
@@ -2,10 +2,14 @@ package scanner_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"io"
+	"io/ioutil"
+	"os"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -60,9 +64,10 @@ var _ = Describe("Direct Scanning", func() {
 		machine.EXPECT().Address().Return("10.0.0.1:22").AnyTimes()
 		machine.EXPECT().Host().Return("10.0.0.1").AnyTimes()
 		machine.EXPECT().OSName().Return("trusty").AnyTimes()
-		machine.EXPECT().Password().Return("password").AnyTimes()
+		machine.EXPECT().CreateDirectory(matchWorkDir("WORKDIR")).Return(nil).Times(1)
+		machine.EXPECT().DeleteDirectory(matchWorkDir("WORKDIR")).Return(nil).Times(1)
 
-		directScan = scanner.Direct(machine)
+		directScan = scanner.Direct(machine, scanner.Options{})
 	})
 
 	AfterEach(func() {
@@ -71,9 +76,8 @@ var _ = Describe("Direct Scanning", func() {
 
 	Context("when no regex specified", func() {
 		It("uploads and cleans the proc_scan binary to the remote machine", func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 			scanResults, scanErr = directScan.Scan(fileMatch, logger)
 		})
 	})
@@ -85,17 +89,108 @@ var _ = Describe("Direct Scanning", func() {
 		})
 
 		It("uploads and cleans the proc_scan binary to the remote machine", func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000 --path \"interesting\" --content \"valuable\"").Return(buffer, nil).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --path interesting --content valuable")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 			scanResults, scanErr = directScan.Scan(fileMatch, logger)
 		})
 	})
 
+	Context("when excerpts of matching content should not be masked", func() {
+		BeforeEach(func() {
+			fileMatch.ContentRegexes = []string{"valuable"}
+			fileMatch.ExcerptMask = "none"
+		})
+
+		It("passes the masking to the scanner", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --content valuable --excerpt-mask none")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when rule packs are used", func() {
+		var rulesPath string
+
+		BeforeEach(func() {
+			f, err := ioutil.TempFile("", "rules")
+			Expect(err).NotTo(HaveOccurred())
+			f.Close()
+			rulesPath = f.Name()
+
+			fileMatch.RulePacks = []string{"secrets", rulesPath}
+		})
+
+		AfterEach(func() {
+			os.Remove(rulesPath)
+		})
+
+		It("uploads rule files and passes them and the built-in packs to the scanner", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().UploadFile(rulesPath, matchWorkDir("WORKDIR/rules-1.yml")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --rules secrets --rules WORKDIR/rules-1.yml")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+
+		It("uploads and passes packs given more than once only once", func() {
+			fileMatch.RulePacks = []string{"secrets", rulesPath, rulesPath, "secrets"}
+
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().UploadFile(rulesPath, matchWorkDir("WORKDIR/rules-1.yml")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --rules secrets --rules WORKDIR/rules-1.yml")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when archives should be checked", func() {
+		BeforeEach(func() {
+			fileMatch.ContentRegexes = []string{"valuable"}
+			fileMatch.Archives = true
+			fileMatch.MaxArchiveSize = 5000
+			fileMatch.MaxArchiveDepth = 3
+			fileMatch.MaxArchiveMemory = 20000
+		})
+
+		It("passes the limits to the scanner", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --content valuable --archives --max-archive 5000 --max-archive-depth 3 --max-archive-memory 20000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when files should be hashed", func() {
+		BeforeEach(func() {
+			fileMatch.HashPaths = []string{"/var/vcap/packages", "/var/vcap/jobs"}
+		})
+
+		It("passes the paths to the scanner", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --hash /var/vcap/packages --hash /var/vcap/jobs")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when regexes contain shell metacharacters", func() {
+		BeforeEach(func() {
+			fileMatch.PathRegexes = []string{`/etc/.*\.conf$`}
+			fileMatch.ContentRegexes = []string{`pass(word)? *= *'[^']+'`}
+		})
+
+		It("quotes them for the remote shell", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir(`./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --path '/etc/.*\.conf$' --content 'pass(word)? *= *'\''[^'\'']+'\'''`)).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
 	It("returns a report from the machine", func() {
-		machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-		machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(buffer, nil).Times(1)
-		machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+		machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+		machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
 		scanResults, scanErr = directScan.Scan(fileMatch, logger)
 		Expect(scanResults.JobResults).To(Equal([]scanner.JobResult{
 			{
@@ -107,9 +202,77 @@ var _ = Describe("Direct Scanning", func() {
 		}))
 	})
 
+	Context("when the results are compressed", func() {
+		BeforeEach(func() {
+			compressed := &bytes.Buffer{}
+			gz := gzip.NewWriter(compressed)
+			_, err := io.Copy(gz, buffer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gz.Close()).To(Succeed())
+			buffer = compressed
+
+			directScan = scanner.Direct(machine, scanner.Options{Gzip: true})
+		})
+
+		It("decompresses them", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --gzip --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+			Expect(scanResults.JobResults[0].Files).To(Equal(systemInfo.Files))
+		})
+	})
+
+	Context("when the files to scan are limited", func() {
+		BeforeEach(func() {
+			directScan = scanner.Direct(machine, scanner.Options{
+				FileScope: scantron.FileScope{
+					Roots:                   []string{"/var/vcap"},
+					ExcludedGlobs:           []string{"*.log"},
+					ExcludedRegexes:         []string{`^/var/vcap/data/(garden|grootfs)`},
+					ExcludedFilesystemTypes: []string{"nfs"},
+					OneFilesystem:           true,
+					MaxDepth:                8,
+				},
+			})
+		})
+
+		It("passes the scope to the scanner", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir(`./WORKDIR/proc_scan --context 10.0.0.1 --max 1000 --root /var/vcap --exclude '*.log' --exclude-regex '^/var/vcap/data/(garden|grootfs)' --exclude-fs-type nfs --one-file-system --max-depth 8`)).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when secrets should be revealed", func() {
+		BeforeEach(func() {
+			directScan = scanner.Direct(machine, scanner.Options{RevealSecrets: true})
+		})
+
+		It("tells the scanner not to redact them", func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --reveal-secrets --context 10.0.0.1 --max 1000")).Return(ioutil.NopCloser(buffer), nil).Times(1)
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the scanning binary exits with an error", func() {
+		BeforeEach(func() {
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(&failingOutput{Reader: buffer}, nil).Times(1)
+		})
+
+		It("fails to scan", func() {
+			scanResults, scanErr = directScan.Scan(fileMatch, logger)
+			Expect(scanErr).To(MatchError("Process exited with status 1"))
+		})
+	})
+
 	Context("when uploading the scanning binary fails", func() {
 		BeforeEach(func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(errors.New("disaster")).Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(errors.New("disaster")).Times(1)
 		})
 
 		It("fails to scan", func() {
@@ -120,9 +283,8 @@ var _ = Describe("Direct Scanning", func() {
 
 	Context("when running the scanning binary fails", func() {
 		BeforeEach(func() {
-			machine.EXPECT().UploadFile(gomock.Any(), "./proc_scan").Return(nil).Times(1)
-			machine.EXPECT().RunCommand("echo password | sudo -S -- ./proc_scan --context 10.0.0.1 --max 1000").Return(nil, errors.New("disaster")).Times(1)
-			machine.EXPECT().DeleteFile("./proc_scan").Times(1)
+			machine.EXPECT().UploadFile(gomock.Any(), matchWorkDir("WORKDIR/proc_scan")).Return(nil).Times(1)
+			machine.EXPECT().RunPrivilegedCommand(matchWorkDir("./WORKDIR/proc_scan --context 10.0.0.1 --max 1000")).Return(nil, errors.New("disaster")).Times(1)
 		})
 
 		It("fails to scan", func() {
@@ -131,3 +293,11 @@ var _ = Describe("Direct Scanning", func() {
 		})
 	})
 })
+
+type failingOutput struct {
+	io.Reader
+}
+
+func (o *failingOutput) Close() error {
+	return errors.New("Process exited with status 1")
+}
@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/collector"
+	"github.com/pivotal-cf/scantron/scanlog"
+)
+
+type local struct {
+	hostname string
+	options  Options
+}
+
+// Local scans the machine scantron is running on in-process, so it needs
+// neither SSH nor a copy of proc_scan.
+func Local(hostname string, options Options) Scanner {
+	return &local{
+		hostname: hostname,
+		options:  options,
+	}
+}
+
+func (l *local) Scan(match *scantron.FileMatch, logger scanlog.Logger) (ScanResult, error) {
+	hostLogger := logger.With(
+		"host", l.hostname,
+	)
+
+	hostLogger.Infof("Starting local scan")
+	defer hostLogger.Infof("Local scan complete")
+
+	options := collector.Options{
+		FileRegexes:   *match,
+		FileScope:     l.options.FileScope,
+		SSHOptional:   true,
+		RevealSecrets: l.options.RevealSecrets,
+	}
+
+	systemInfo, err := collector.Collect(options, hostLogger)
+	if err != nil {
+		hostLogger.Errorf("Failed to scan machine: %s", err)
+		return ScanResult{}, err
+	}
+
+	scannedHost := BuildJobResult(systemInfo, l.hostname, l.hostname)
+
+	return ScanResult{JobResults: []JobResult{scannedHost}}, nil
+}
@@ -1,9 +1,10 @@
 package scanner
 
 import (
-	"encoding/json"
+	"compress/gzip"
 	"fmt"
 	"github.com/rakyll/statik/fs"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/pivotal-cf/scantron"
 	"github.com/pivotal-cf/scantron/remotemachine"
+	"github.com/pivotal-cf/scantron/resultstream"
+	"github.com/pivotal-cf/scantron/rules"
 	"github.com/pivotal-cf/scantron/scanlog"
 	_ "github.com/pivotal-cf/scantron/statik"
 )
@@ -19,18 +22,40 @@ type Scanner interface {
 	Scan(*scantron.FileMatch, scanlog.Logger) (ScanResult, error)
 }
 
+type Options struct {
+	// Gzip compresses the scan results on the machine before they are sent
+	// back, which is worth it for machines with a lot of files.
+	Gzip bool
+
+	// RevealSecrets keeps the values of secrets given to processes in the
+	// results instead of redacting them.
+	RevealSecrets bool
+
+	FileScope scantron.FileScope
+}
+
 type ScanResult struct {
-	JobResults     []JobResult
-	ReleaseResults []ReleaseResult
+	Director        string
+	JobResults      []JobResult
+	ReleaseResults  []ReleaseResult
+	StemcellResults []StemcellResult
 }
 
 type JobResult struct {
 	IP  string
 	Job string
 
+	BoshInstance *BoshInstance
+
 	Services []scantron.Process
 	Files    []scantron.File
 	SSHKeys  []scantron.SSHKey
+	Packages []scantron.Package
+	Kernel   *scantron.Kernel
+	Errors   []scantron.CollectionError
+
+	OSRelease        *scantron.OSRelease
+	FirewallRulesets []scantron.FirewallRuleset
 }
 
 type ReleaseResult struct {
@@ -38,19 +63,47 @@ type ReleaseResult struct {
 	Version string
 }
 
-func buildJobResult(host scantron.SystemInfo, jobName, address string) JobResult {
+type StemcellResult struct {
+	Name    string
+	OS      string
+	Version string
+}
+
+type BoshInstance struct {
+	InstanceGroup string
+	ID            string
+	Index         *int
+	AZ            string
+	VMCID         string
+	Bootstrap     bool
+	ProcessState  string
+	IPs           []string
+
+	StemcellName    string
+	StemcellVersion string
+}
+
+// BuildJobResult records the results collected from a machine as the host
+// with the given job name and address.
+func BuildJobResult(host scantron.SystemInfo, jobName, address string) JobResult {
 	return JobResult{
 		Job:      jobName,
 		IP:       address,
 		Services: host.Processes,
 		Files:    host.Files,
 		SSHKeys:  host.SSHKeys,
+		Packages: host.Packages,
+		Kernel:   host.Kernel,
+		Errors:   host.Errors,
+
+		OSRelease:        host.OSRelease,
+		FirewallRulesets: host.FirewallRulesets,
 	}
 }
 
-func writeProcScanToTempFile(osName string) (string, error) {
+func writeProcScanToTempFile(osFamily string) (string, error) {
 	data_path := "/proc_scan/proc_scan_linux"
-	if strings.Contains(osName, "windows") {
+	if osFamily == remotemachine.Windows {
 		data_path = "/proc_scan/proc_scan_windows"
 	}
 	statikFS, err := fs.New()
@@ -68,15 +121,23 @@ func writeProcScanToTempFile(osName string) (string, error) {
 		return "", err
 	}
 
-	err = ioutil.WriteFile(tmpFile.Name(), data, 0644)
+	_, err = tmpFile.Write(data)
 	if err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
 		return "", err
 	}
 
 	return tmpFile.Name(), nil
 }
 
-func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteMachine remotemachine.RemoteMachine) (scantron.SystemInfo, error) {
+func scanMachine(fileRegexes *scantron.FileMatch, options Options, logger scanlog.Logger, remoteMachine remotemachine.RemoteMachine) (scantron.SystemInfo, error) {
 	var systemInfo scantron.SystemInfo
 
 	logger.Infof("Starting VM scan")
@@ -85,41 +146,106 @@ func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteM
 	osName := remoteMachine.OSName()
 	logger.Debugf("Deployment stemcell is %s", osName)
 
-	srcFilePath, err := writeProcScanToTempFile(osName)
+	osFamily, err := remotemachine.OSFamily(osName)
+	if err != nil {
+		logger.Errorf("Cannot scan machine: %s", err)
+		return systemInfo, err
+	}
+
+	srcFilePath, err := writeProcScanToTempFile(osFamily)
 	if err != nil {
 		return systemInfo, err
 	}
 	defer os.Remove(srcFilePath)
 
-	dstFilePath := "./proc_scan"
-	command := fmt.Sprintf("echo %s | sudo -S -- %s", remoteMachine.Password(), dstFilePath)
-	if strings.Contains(osName, "windows") {
-		dstFilePath = ".\\proc_scan.exe"
-		command = ".\\proc_scan.exe"
+	workDir, err := remotemachine.NewWorkDirectoryName()
+	if err != nil {
+		return systemInfo, err
 	}
 
-	if scantron.Debug {
-		command = strings.Join([]string{command, "--debug"}, " ")
+	dstFilePath := workDir + "/proc_scan"
+	execPath := "./" + dstFilePath
+	quote := remotemachine.ShellQuote
+	if osFamily == remotemachine.Windows {
+		dstFilePath = workDir + "/proc_scan.exe"
+		execPath = ".\\" + workDir + "\\proc_scan.exe"
+		quote = remotemachine.WindowsQuote
 	}
-	command = strings.Join([]string{
-		command,
-		"--context", remoteMachine.Host(),
-		"--max", strconv.FormatInt(fileRegexes.MaxRegexFileSize, 10),
-	}, " ")
 
-	// Use escaped " since ' doesn't handle whitespace on windows
+	args := []string{execPath}
+	if scantron.Debug {
+		args = append(args, "--debug")
+	}
+	if options.Gzip {
+		args = append(args, "--gzip")
+	}
+	if options.RevealSecrets {
+		args = append(args, "--reveal-secrets")
+	}
+	args = append(args,
+		"--context", quote(remoteMachine.Host()),
+		"--max", quote(strconv.FormatInt(fileRegexes.MaxRegexFileSize, 10)),
+	)
 	for _, r := range fileRegexes.PathRegexes {
-		command = strings.Join([]string{
-			command,
-			"--path", fmt.Sprintf("\"%s\"", r),
-		}, " ")
+		args = append(args, "--path", quote(r))
 	}
 	for _, r := range fileRegexes.ContentRegexes {
-		command = strings.Join([]string{
-			command,
-			"--content", fmt.Sprintf("\"%s\"", r),
-		}, " ")
+		args = append(args, "--content", quote(r))
+	}
+	if fileRegexes.Archives {
+		args = append(args,
+			"--archives",
+			"--max-archive", strconv.FormatInt(fileRegexes.MaxArchiveSize, 10),
+			"--max-archive-depth", strconv.Itoa(fileRegexes.MaxArchiveDepth),
+			"--max-archive-memory", strconv.FormatInt(fileRegexes.MaxArchiveMemory, 10),
+		)
+	}
+	if fileRegexes.ExcerptMask != "" {
+		args = append(args, "--excerpt-mask", quote(fileRegexes.ExcerptMask))
 	}
+	for _, path := range fileRegexes.HashPaths {
+		args = append(args, "--hash", quote(path))
+	}
+
+	// Built-in rule packs are part of proc_scan but rule files have to be
+	// uploaded alongside it. Packs given more than once are only used once.
+	ruleFiles := map[string]string{}
+	seenPacks := map[string]bool{}
+	for i, pack := range fileRegexes.RulePacks {
+		if seenPacks[pack] {
+			continue
+		}
+		seenPacks[pack] = true
+
+		if rules.IsBuiltin(pack) {
+			args = append(args, "--rules", quote(pack))
+			continue
+		}
+
+		name := fmt.Sprintf("rules-%d.yml", i)
+		ruleFiles[pack] = workDir + "/" + name
+		if osFamily == remotemachine.Windows {
+			args = append(args, "--rules", quote(workDir+"\\"+name))
+		} else {
+			args = append(args, "--rules", quote(workDir+"/"+name))
+		}
+	}
+
+	args = append(args, fileScopeArgs(options.FileScope, quote)...)
+	command := strings.Join(args, " ")
+
+	err = remoteMachine.CreateDirectory(workDir)
+	if err != nil {
+		logger.Errorf("Failed to create working directory on remote machine: %s", err)
+		return systemInfo, err
+	}
+
+	defer func() {
+		err := remoteMachine.DeleteDirectory(workDir)
+		if err != nil {
+			logger.Errorf("Failed to remove working directory %s from remote machine: %s", workDir, err)
+		}
+	}()
 
 	err = remoteMachine.UploadFile(srcFilePath, dstFilePath)
 	if err != nil {
@@ -127,18 +253,104 @@ func scanMachine(fileRegexes *scantron.FileMatch, logger scanlog.Logger, remoteM
 		return systemInfo, err
 	}
 
-	defer remoteMachine.DeleteFile(dstFilePath)
-	output, err := remoteMachine.RunCommand(command)
+	for pack, dst := range ruleFiles {
+		err = remoteMachine.UploadFile(pack, dst)
+		if err != nil {
+			logger.Errorf("Failed to upload rule pack %s to remote machine: %s", pack, err)
+			return systemInfo, err
+		}
+	}
+
+	// There is no sudo on Windows so proc_scan runs as the SSH user
+	run := remoteMachine.RunPrivilegedCommand
+	if osFamily == remotemachine.Windows {
+		run = remoteMachine.RunCommand
+	}
+
+	output, err := run(command)
 	if err != nil {
 		logger.Errorf("Failed to run scanner on remote machine: %s", err)
 		return systemInfo, err
 	}
 
-	err = json.NewDecoder(output).Decode(&systemInfo)
+	err = decodeSystemInfo(output, options.Gzip, &systemInfo)
+	closeErr := output.Close()
 	if err != nil {
 		logger.Errorf("Scanner results were malformed: %s", err)
 		return systemInfo, err
 	}
 
+	if closeErr != nil {
+		logger.Errorf("Scanner failed on remote machine: %s", closeErr)
+		return systemInfo, closeErr
+	}
+
 	return systemInfo, nil
 }
+
+func fileScopeArgs(scope scantron.FileScope, quote func(string) string) []string {
+	args := []string{}
+
+	for _, root := range scope.Roots {
+		args = append(args, "--root", quote(root))
+	}
+	for _, glob := range scope.ExcludedGlobs {
+		args = append(args, "--exclude", quote(glob))
+	}
+	for _, r := range scope.ExcludedRegexes {
+		args = append(args, "--exclude-regex", quote(r))
+	}
+	for _, fsType := range scope.ExcludedFilesystemTypes {
+		args = append(args, "--exclude-fs-type", quote(fsType))
+	}
+	if scope.OneFilesystem {
+		args = append(args, "--one-file-system")
+	}
+	if scope.MaxDepth > 0 {
+		args = append(args, "--max-depth", strconv.Itoa(scope.MaxDepth))
+	}
+
+	return args
+}
+
+// decodeSystemInfo reads the processes and files one at a time so that the
+// JSON of the results is never held in memory whole. The decoded results
+// are still kept until they are saved.
+func decodeSystemInfo(output io.Reader, compressed bool, systemInfo *scantron.SystemInfo) error {
+	if compressed {
+		gz, err := gzip.NewReader(output)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		output = gz
+	}
+
+	sink := &systemInfoSink{}
+	decoded, err := resultstream.Decode(output, sink)
+	if err != nil {
+		return err
+	}
+
+	decoded.Processes = sink.processes
+	decoded.Files = sink.files
+	*systemInfo = decoded
+
+	return nil
+}
+
+type systemInfoSink struct {
+	processes []scantron.Process
+	files     []scantron.File
+}
+
+func (s *systemInfoSink) Process(process scantron.Process) error {
+	s.processes = append(s.processes, process)
+	return nil
+}
+
+func (s *systemInfoSink) File(file scantron.File) error {
+	s.files = append(s.files, file)
+	return nil
+}
@@ -1,8 +1,13 @@
 package scanner_test
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -13,3 +18,31 @@ func TestScanner(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Scanner Suite")
 }
+
+// matchWorkDir matches a path or command with the random working directory
+// created for the scan in place of WORKDIR.
+func matchWorkDir(expected string) gomock.Matcher {
+	pieces := strings.Split(expected, "WORKDIR")
+	for i, piece := range pieces {
+		pieces[i] = regexp.QuoteMeta(piece)
+	}
+
+	return workDirMatcher{
+		expected: expected,
+		pattern:  regexp.MustCompile("^" + strings.Join(pieces, "scantron-[0-9a-f]{16}") + "$"),
+	}
+}
+
+type workDirMatcher struct {
+	expected string
+	pattern  *regexp.Regexp
+}
+
+func (m workDirMatcher) Matches(x interface{}) bool {
+	s, ok := x.(string)
+	return ok && m.pattern.MatchString(s)
+}
+
+func (m workDirMatcher) String() string {
+	return fmt.Sprintf("matches %q", m.expected)
+}
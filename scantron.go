@@ -14,11 +14,12 @@ type Inventory struct {
 }
 
 type Machine struct {
-	Address  string
-	Username string
-	Password string
-	Key      ssh.Signer
-	OSName   string
+	Address    string
+	Username   string
+	Password   string
+	Key        ssh.Signer
+	OSName     string
+	Escalation string
 }
 
 var Debug bool
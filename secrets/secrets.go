@@ -0,0 +1,112 @@
+// Package secrets recognises values that look like passwords, tokens and
+// private keys so that they can be reported and kept out of scan results.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Kinds of secret found by Classify.
+const (
+	Password    = "password"
+	Token       = "token"
+	PrivateKey  = "private key"
+	URLPassword = "password in URL"
+	HighEntropy = "high entropy"
+)
+
+// Redacted replaces the values of secrets in scan results.
+const Redacted = "[REDACTED]"
+
+const minHighEntropyLength = 20
+
+var (
+	passwordName = regexp.MustCompile(`(?i)(password|passwd|passphrase|(^|[^a-z])(pass|pwd)($|[^a-z]))`)
+	tokenName    = regexp.MustCompile(`(?i)(token|secret|api_?key|access_?key|credential|auth_?key)`)
+
+	// Names that contain the words above but are not secrets.
+	notSecretName = regexp.MustCompile(`(?i)[_.-](file|path|dir|url|host|user(name)?|length|size|ttl|timeout|expiry)$`)
+
+	privateKeyValue  = regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY-----`)
+	urlPasswordValue = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]*:[^/\s@]+@`)
+
+	base64Value = regexp.MustCompile(`^[A-Za-z0-9+/_=-]+$`)
+)
+
+// Classify returns the kind of secret value looks like, or "" if it does not
+// look secret. name is the environment variable or flag the value was given
+// in, and may be empty.
+func Classify(name, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if privateKeyValue.MatchString(value) {
+		return PrivateKey
+	}
+
+	if urlPasswordValue.MatchString(value) {
+		return URLPassword
+	}
+
+	name = strings.TrimLeft(name, "-")
+	if name != "" && !notSecretName.MatchString(name) && !looksLikePath(value) {
+		if passwordName.MatchString(name) {
+			return Password
+		}
+
+		if tokenName.MatchString(name) {
+			return Token
+		}
+	}
+
+	if isHighEntropy(value) {
+		return HighEntropy
+	}
+
+	return ""
+}
+
+// looksLikePath is true for values that point at a secret rather than
+// holding it.
+func looksLikePath(value string) bool {
+	return strings.HasPrefix(value, "/") || strings.HasPrefix(value, "./")
+}
+
+// isHighEntropy is true for long random-looking strings, like generated
+// passwords and API keys. Hex strings are left out since container IDs and
+// checksums are everywhere in command lines.
+func isHighEntropy(value string) bool {
+	if len(value) < minHighEntropyLength || looksLikePath(value) {
+		return false
+	}
+
+	return base64Value.MatchString(value) && hasMixedCharacters(value) && entropy(value) >= 4.0
+}
+
+// hasMixedCharacters is true when value has upper and lower case letters and
+// digits, which keeps out long names like bosh-dns-nameserverconfig-123456.
+func hasMixedCharacters(value string) bool {
+	return strings.ContainsAny(value, "0123456789") &&
+		strings.ContainsAny(value, "abcdefghijklmnopqrstuvwxyz") &&
+		strings.ContainsAny(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+}
+
+// entropy is the Shannon entropy of value in bits per character.
+func entropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, r := range value {
+		counts[r]++
+	}
+
+	length := float64(len(value))
+	bits := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		bits -= p * math.Log2(p)
+	}
+
+	return bits
+}
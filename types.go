@@ -14,11 +14,43 @@ type File struct {
 	ModifiedTime time.Time    `json:"modified_time"`
 	Size         int64        `json:"size"`
 	RegexMatches []RegexMatch `json:"regex_matches"`
+
+	// OrphanUID and OrphanGID are set when the user or group owning the file
+	// does not exist on the machine. User and Group then hold the numeric ID.
+	OrphanUID bool `json:"orphan_uid,omitempty"`
+	OrphanGID bool `json:"orphan_gid,omitempty"`
+
+	// SHA256 is the hex digest of the content of files under the paths
+	// chosen for hashing.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Capabilities is set for executables given capabilities with setcap.
+	Capabilities *FileCapabilities `json:"capabilities,omitempty"`
+
+	// Error is why the file or directory could not be fully read.
+	Error string `json:"error,omitempty"`
+}
+
+// FileCapabilities are the capabilities an executable is given when it runs.
+type FileCapabilities struct {
+	Permitted   []string `json:"permitted"`
+	Inheritable []string `json:"inheritable"`
+	Effective   bool     `json:"effective"`
 }
 
 type RegexMatch struct {
 	PathRegex    string `json:"path_regex"`
 	ContentRegex string `json:"content_regex"`
+
+	// LineNumber, ByteOffset and Excerpt are of the first match in the file.
+	LineNumber int    `json:"line_number"`
+	ByteOffset int64  `json:"byte_offset"`
+	MatchCount int    `json:"match_count"`
+	Excerpt    string `json:"excerpt"`
+
+	// Rule and Severity are set for matches of rules from a rule pack.
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
 }
 
 type Port struct {
@@ -29,7 +61,24 @@ type Port struct {
 	ForeignNumber  int    `json:"foreignNumber"`
 	State          string `json:"state"`
 
+	// ContainerID is the container of the process listening on the port, or
+	// empty for ports on the host.
+	ContainerID string `json:"container_id,omitempty"`
+
 	TLSInformation *TLSInformation `json:"tls_information"`
+
+	// FirewallBlocked is whether the host firewall drops new connections to
+	// the port from other machines, and FirewallRule is the rule or chain
+	// policy that does it.
+	FirewallBlocked bool   `json:"firewall_blocked"`
+	FirewallRule    string `json:"firewall_rule,omitempty"`
+}
+
+// FirewallRuleset is the rules of iptables, ip6tables or nftables as
+// iptables-save, ip6tables-save or nft list ruleset print them.
+type FirewallRuleset struct {
+	Tool  string `json:"tool"`
+	Rules string `json:"rules"`
 }
 
 type TLSInformation struct {
@@ -44,6 +93,36 @@ type FileMatch struct {
 	PathRegexes      []string `long:"path" description:"Regexes for file paths"`
 	ContentRegexes   []string `long:"content" description:"Regexes for file content"`
 	MaxRegexFileSize int64    `long:"max" description:"Max file size to check content against regexes" default:"1048576"` // default 1 MB
+	HashPaths        []string `long:"hash" description:"Record the SHA-256 of files under this directory, can be repeated" value-name:"PATH"`
+	ExcerptMask      string   `long:"excerpt-mask" description:"How much of the matching text to hide in excerpts of file content" choice:"all" choice:"partial" choice:"none" default:"partial"`
+	Archives         bool     `long:"archives" description:"Also check the content of files in archives and compressed files, like .tgz, .jar and .gz"`
+	MaxArchiveSize   int64    `long:"max-archive" description:"Max size of an archive to check, both before and after decompression" default:"104857600"` // default 100 MB
+	MaxArchiveDepth  int      `long:"max-archive-depth" description:"How many archives deep to check archives inside archives" default:"2"`
+	MaxArchiveMemory int64    `long:"max-archive-memory" description:"Max memory used at once for zips inside archives, which have to be read into memory to be checked" default:"268435456"` // default 256 MB
+	RulePacks        []string `long:"rules" description:"Check file content with a built-in rule pack, like secrets, or a YAML file of rules, can be repeated" value-name:"NAME|PATH"`
+
+	// Rules are loaded from the rule packs before files are walked.
+	Rules []Rule `no-flag:"true"`
+}
+
+// Rule is a named content check from a rule pack. It checks files whose path
+// matches PathRegex, or every file when it is empty.
+type Rule struct {
+	Name         string `yaml:"name"`
+	Description  string `yaml:"description"`
+	Severity     string `yaml:"severity"`
+	PathRegex    string `yaml:"path"`
+	ContentRegex string `yaml:"content"`
+}
+
+// FileScope selects the parts of the filesystem whose files are recorded.
+type FileScope struct {
+	Roots                   []string `long:"root" description:"Only scan files under this directory, can be repeated" value-name:"PATH"`
+	ExcludedGlobs           []string `long:"exclude" description:"Skip files and directories matching this glob, against the name unless it has a /" value-name:"GLOB"`
+	ExcludedRegexes         []string `long:"exclude-regex" description:"Skip files and directories whose path matches this regex" value-name:"REGEX"`
+	ExcludedFilesystemTypes []string `long:"exclude-fs-type" description:"Skip mounted filesystems of this type" value-name:"TYPE" default:"nfs" default:"nfs4" default:"fuse" default:"overlay" default:"tmpfs"`
+	OneFilesystem           bool     `long:"one-file-system" description:"Do not scan filesystems mounted below the roots"`
+	MaxDepth                int      `long:"max-depth" description:"Only scan this many directories deep below the roots" value-name:"N"`
 }
 
 type CipherInformation map[string][]string
@@ -84,7 +163,74 @@ type Process struct {
 	Cmdline     []string `json:"cmdline"`
 	Env         []string `json:"env"`
 
-	Ports []Port `json:"ports"`
+	PPID      int       `json:"ppid"`
+	UID       int       `json:"uid"`
+	EUID      int       `json:"euid"`
+	GID       int       `json:"gid"`
+	EGID      int       `json:"egid"`
+	StartTime time.Time `json:"start_time"`
+
+	Executable       string `json:"executable"`
+	ExecutableSHA256 string `json:"executable_sha256"`
+
+	Capabilities Capabilities `json:"capabilities"`
+	NoNewPrivs   bool         `json:"no_new_privs"`
+	Seccomp      string       `json:"seccomp"`
+
+	// ContainerID identifies the Docker, Kubernetes or Garden container the
+	// process runs in and is empty for processes on the host.
+	ContainerID string     `json:"container_id,omitempty"`
+	Cgroup      string     `json:"cgroup,omitempty"`
+	Namespaces  Namespaces `json:"namespaces"`
+
+	Ports       []Port       `json:"ports"`
+	UnixSockets []UnixSocket `json:"unix_sockets"`
+
+	// Secrets are the environment variables and arguments that look like
+	// passwords, tokens or keys. Their values are redacted in Env and Cmdline
+	// unless the scan was told to reveal them.
+	Secrets []Secret `json:"secrets"`
+}
+
+const (
+	SecretInEnv     = "env"
+	SecretInCmdline = "cmdline"
+)
+
+// Secret is where a process was given a secret. Name is the environment
+// variable, the flag or, for a bare argument, its position like argv[2].
+type Secret struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+}
+
+// UnixSocket is a listening Unix domain socket. Abstract sockets have a path
+// starting with @ and no file, so no permissions or owner.
+type UnixSocket struct {
+	Path        string      `json:"path"`
+	Type        string      `json:"type"`
+	Permissions os.FileMode `json:"permissions"`
+	User        string      `json:"user"`
+	Group       string      `json:"group"`
+}
+
+// Capabilities holds the names of the Linux capabilities in each of a
+// process's capability sets.
+type Capabilities struct {
+	Effective   []string `json:"effective"`
+	Permitted   []string `json:"permitted"`
+	Inheritable []string `json:"inheritable"`
+	Bounding    []string `json:"bounding"`
+	Ambient     []string `json:"ambient"`
+}
+
+// Namespaces holds the inode numbers of the Linux namespaces a process is in.
+// Processes in the same namespace have the same number.
+type Namespaces struct {
+	PID     uint64 `json:"pid,omitempty"`
+	Network uint64 `json:"net,omitempty"`
+	Mount   uint64 `json:"mnt,omitempty"`
 }
 
 type SSHKey struct {
@@ -92,10 +238,83 @@ type SSHKey struct {
 	Key  string `json:"key"`
 }
 
+// Package is an installed OS package or a Go module built into the executable
+// of a listening process.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Ecosystem is dpkg, rpm or Go.
+	Ecosystem string `json:"ecosystem"`
+
+	// Source is the source package an OS package was built from, if it is
+	// named differently.
+	Source string `json:"source,omitempty"`
+
+	// Executable is the program a Go module was found in.
+	Executable string `json:"executable,omitempty"`
+}
+
+const (
+	DpkgEcosystem = "dpkg"
+	RPMEcosystem  = "rpm"
+	GoEcosystem   = "Go"
+)
+
+// OSRelease is the distribution a machine runs, as /etc/os-release names it,
+// like ubuntu and 22.04.
+type OSRelease struct {
+	ID        string `json:"id"`
+	VersionID string `json:"version_id"`
+}
+
+// Kernel is the running Linux kernel and the settings that harden it.
+type Kernel struct {
+	Release string `json:"release"`
+	Version string `json:"version"`
+
+	// BootParameters is the command line the kernel was booted with.
+	BootParameters string   `json:"boot_parameters"`
+	Modules        []string `json:"modules"`
+
+	// Sysctls are the security-relevant kernel parameters, by the name
+	// sysctl gives them, like net.ipv4.ip_forward.
+	Sysctls map[string]string `json:"sysctls"`
+}
+
 type SystemInfo struct {
 	Processes []Process `json:"processes"`
 	Files     []File    `json:"files"`
 	SSHKeys   []SSHKey  `json:"ssh_keys"`
+	Packages  []Package `json:"packages"`
+
+	// OSRelease is not collected on Windows, nor on machines without
+	// /etc/os-release.
+	OSRelease *OSRelease `json:"os_release,omitempty"`
+
+	// Kernel is not collected on Windows.
+	Kernel *Kernel `json:"kernel,omitempty"`
+
+	FirewallRulesets []FirewallRuleset `json:"firewall_rulesets"`
+
+	// Errors are the parts of the machine that could not be scanned. The
+	// results of the rest of the scan are still there.
+	Errors []CollectionError `json:"errors"`
+}
+
+// Subsystems a CollectionError can be for.
+const (
+	ProcessSubsystem  = "processes"
+	FileSubsystem     = "files"
+	SSHSubsystem      = "ssh"
+	PackageSubsystem  = "packages"
+	KernelSubsystem   = "kernel"
+	FirewallSubsystem = "firewall"
+)
+
+type CollectionError struct {
+	Subsystem string `json:"subsystem"`
+	Message   string `json:"message"`
 }
 
 func (p Process) HasFileWithPort(number int) bool {
@@ -0,0 +1,370 @@
+// Package vulns matches the packages found by scans against an offline feed
+// of vulnerabilities in the OSV format (https://ossf.github.io/osv-schema/).
+package vulns
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf/scantron"
+)
+
+// Vulnerability is an OSV entry, with only the fields needed to match
+// packages.
+type Vulnerability struct {
+	ID       string     `json:"id"`
+	Aliases  []string   `json:"aliases"`
+	Summary  string     `json:"summary"`
+	Severity []Severity `json:"severity"`
+	Affected []Affected `json:"affected"`
+
+	// DatabaseSpecific differs between feeds but often has a severity.
+	DatabaseSpecific map[string]interface{} `json:"database_specific"`
+}
+
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type Affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// The ecosystems of scanned packages, which OSV splits by distribution.
+const (
+	ecosystemDpkg = scantron.DpkgEcosystem
+	ecosystemRPM  = scantron.RPMEcosystem
+	ecosystemGo   = scantron.GoEcosystem
+)
+
+// packageEcosystem is the ecosystem of the packages an OSV ecosystem like
+// "Ubuntu:22.04" is about, or "" if scans do not find them.
+func packageEcosystem(osvEcosystem string) string {
+	switch strings.SplitN(osvEcosystem, ":", 2)[0] {
+	case "Debian", "Ubuntu":
+		return ecosystemDpkg
+	case "Red Hat", "AlmaLinux", "Rocky Linux", "SUSE", "openSUSE":
+		return ecosystemRPM
+	case "Go":
+		return ecosystemGo
+	default:
+		return ""
+	}
+}
+
+// feedEcosystem names the release an OSV ecosystem is for the way
+// releaseEcosystem does, so "Ubuntu:22.04:LTS" is "Ubuntu:22.04" and
+// "Red Hat:enterprise_linux:8::appstream" is "Red Hat:8".
+func feedEcosystem(osvEcosystem string) string {
+	parts := strings.Split(osvEcosystem, ":")
+	switch {
+	case parts[0] == "Ubuntu" && len(parts) == 3 && parts[2] == "LTS":
+		return parts[0] + ":" + parts[1]
+	case parts[0] == "Red Hat" && len(parts) > 2 && parts[1] == "enterprise_linux":
+		return parts[0] + ":" + parts[2]
+	default:
+		return osvEcosystem
+	}
+}
+
+// releaseEcosystem is the OSV ecosystem of the OS packages of a
+// distribution release, or "" if OSV does not have it.
+func releaseEcosystem(release scantron.OSRelease) string {
+	if release.VersionID == "" {
+		return ""
+	}
+	major := strings.SplitN(release.VersionID, ".", 2)[0]
+
+	switch release.ID {
+	case "ubuntu":
+		return "Ubuntu:" + release.VersionID
+	case "debian":
+		return "Debian:" + major
+	case "rhel":
+		return "Red Hat:" + major
+	case "almalinux":
+		return "AlmaLinux:" + major
+	case "rocky":
+		return "Rocky Linux:" + major
+	case "sles":
+		// 15.5 is Linux Enterprise Server 15 SP5
+		version := strings.Replace(release.VersionID, ".", " SP", 1)
+		return "SUSE:Linux Enterprise Server " + version
+	case "opensuse-leap":
+		return "openSUSE:Leap " + release.VersionID
+	default:
+		return ""
+	}
+}
+
+// Feed is a set of vulnerabilities looked up by the packages they affect.
+type Feed struct {
+	byPackage map[string][]Vulnerability
+	size      int
+}
+
+func NewFeed(vulnerabilities []Vulnerability) Feed {
+	feed := Feed{
+		byPackage: map[string][]Vulnerability{},
+		size:      len(vulnerabilities),
+	}
+
+	for _, v := range vulnerabilities {
+		added := map[string]bool{}
+		for _, affected := range v.Affected {
+			ecosystem := feedEcosystem(affected.Package.Ecosystem)
+			if packageEcosystem(ecosystem) == "" {
+				continue
+			}
+
+			key := ecosystem + " " + affected.Package.Name
+			if !added[key] {
+				feed.byPackage[key] = append(feed.byPackage[key], v)
+				added[key] = true
+			}
+		}
+	}
+
+	return feed
+}
+
+// LoadFeed reads a JSON file of one vulnerability or a list of them, or a zip
+// of such files like those osv.dev exports for each ecosystem.
+func LoadFeed(feedPath string) (Feed, error) {
+	data, err := ioutil.ReadFile(feedPath)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	if strings.ToLower(path.Ext(feedPath)) != ".zip" {
+		vulnerabilities, err := parseJSON(data)
+		if err != nil {
+			return Feed{}, fmt.Errorf("%s is malformed: %s", feedPath, err)
+		}
+		return NewFeed(vulnerabilities), nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Feed{}, err
+	}
+
+	vulnerabilities := []Vulnerability{}
+	for _, f := range zr.File {
+		if strings.ToLower(path.Ext(f.Name)) != ".json" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return Feed{}, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return Feed{}, err
+		}
+
+		vs, err := parseJSON(data)
+		if err != nil {
+			return Feed{}, fmt.Errorf("%s in %s is malformed: %s", f.Name, feedPath, err)
+		}
+		vulnerabilities = append(vulnerabilities, vs...)
+	}
+
+	return NewFeed(vulnerabilities), nil
+}
+
+func parseJSON(data []byte) ([]Vulnerability, error) {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		var vs []Vulnerability
+		err := json.Unmarshal(data, &vs)
+		return vs, err
+	}
+
+	var v Vulnerability
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+	return []Vulnerability{v}, nil
+}
+
+// Len is the number of vulnerabilities in the feed.
+func (f Feed) Len() int {
+	return f.size
+}
+
+// Match is a vulnerability that affects a package.
+type Match struct {
+	ID       string
+	Aliases  []string
+	Summary  string
+	Severity string
+
+	// Fixed is the first version without the vulnerability, if there is
+	// one.
+	Fixed string
+}
+
+// Match finds the vulnerabilities affecting a package, under its own name or
+// the name of its source package. OS packages are only matched against the
+// entries for the release of the distribution they are installed on, since
+// each release has its own versions.
+func (f Feed) Match(pkg scantron.Package, release scantron.OSRelease) []Match {
+	matches := []Match{}
+
+	ecosystem := ecosystemGo
+	if pkg.Ecosystem != ecosystemGo {
+		ecosystem = releaseEcosystem(release)
+	}
+	if packageEcosystem(ecosystem) != pkg.Ecosystem {
+		return matches
+	}
+
+	names := []string{pkg.Name}
+	if pkg.Source != "" && pkg.Source != pkg.Name {
+		names = append(names, pkg.Source)
+	}
+
+	seen := map[string]bool{}
+	for _, name := range names {
+		for _, v := range f.byPackage[ecosystem+" "+name] {
+			if seen[v.ID] {
+				continue
+			}
+
+			fixed, ok := v.affects(ecosystem, name, pkg.Version)
+			if !ok {
+				continue
+			}
+			seen[v.ID] = true
+
+			matches = append(matches, Match{
+				ID:       v.ID,
+				Aliases:  v.Aliases,
+				Summary:  v.Summary,
+				Severity: v.severity(),
+				Fixed:    fixed,
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+
+	return matches
+}
+
+// affects is whether a version of a package is affected in a release and,
+// if so, the version it is fixed in.
+func (v Vulnerability) affects(ecosystem, name, version string) (string, bool) {
+	versionEcosystem := packageEcosystem(ecosystem)
+
+	for _, affected := range v.Affected {
+		if affected.Package.Name != name || feedEcosystem(affected.Package.Ecosystem) != ecosystem {
+			continue
+		}
+
+		for _, r := range affected.Ranges {
+			if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+				continue
+			}
+
+			if fixed, ok := r.affects(versionEcosystem, version); ok {
+				return fixed, true
+			}
+		}
+
+		for _, affectedVersion := range affected.Versions {
+			if compareVersions(versionEcosystem, version, affectedVersion) == 0 {
+				return "", true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// affects goes through the events of a range from oldest to newest, as the
+// OSV schema describes, to find whether version is between an introduced
+// event and the fixed or last affected event after it.
+func (r Range) affects(ecosystem, version string) (string, bool) {
+	events := make([]Event, len(r.Events))
+	copy(events, r.Events)
+
+	eventVersion := func(e Event) string {
+		return e.Introduced + e.Fixed + e.LastAffected
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := eventVersion(events[i]), eventVersion(events[j])
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return compareVersions(ecosystem, a, b) < 0
+	})
+
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compareVersions(ecosystem, version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if compareVersions(ecosystem, version, e.Fixed) >= 0 {
+				affected = false
+			} else if affected {
+				return e.Fixed, true
+			}
+		case e.LastAffected != "":
+			if compareVersions(ecosystem, version, e.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+
+	return "", affected
+}
+
+// severity is the rating the feed gives the vulnerability, like HIGH or
+// medium, rather than a CVSS vector.
+func (v Vulnerability) severity() string {
+	if severity, ok := v.DatabaseSpecific["severity"].(string); ok && severity != "" {
+		return severity
+	}
+
+	for _, s := range v.Severity {
+		if !strings.HasPrefix(s.Type, "CVSS") {
+			return s.Score
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,304 @@
+package vulns
+
+import (
+	"strings"
+)
+
+// compareVersions orders versions the way the package manager of an
+// ecosystem does, returning a negative number when a is older than b.
+func compareVersions(ecosystem, a, b string) int {
+	switch ecosystem {
+	case ecosystemDpkg:
+		return compareDpkg(a, b)
+	case ecosystemRPM:
+		return compareRPM(a, b)
+	default:
+		return compareSemver(a, b)
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// compareNumbers compares strings of digits of any length.
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// splitVersion splits [epoch:]version[-release] into its parts.
+func splitVersion(v string) (string, string, string) {
+	epoch := "0"
+	if i := strings.Index(v, ":"); i >= 0 {
+		epoch, v = v[:i], v[i+1:]
+	}
+
+	release := ""
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		v, release = v[:i], v[i+1:]
+	}
+
+	return epoch, v, release
+}
+
+// compareDpkg compares versions like dpkg --compare-versions.
+func compareDpkg(a, b string) int {
+	aEpoch, aUpstream, aRevision := splitVersion(a)
+	bEpoch, bUpstream, bRevision := splitVersion(b)
+
+	if c := compareNumbers(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareDpkgPart(aUpstream, bUpstream); c != 0 {
+		return c
+	}
+	return compareDpkgPart(aRevision, bRevision)
+}
+
+// dpkgOrder sorts ~ before the end of a version, and letters before other
+// characters.
+func dpkgOrder(s string, i int) int {
+	if i >= len(s) || isDigit(s[i]) {
+		return 0
+	}
+
+	c := s[i]
+	switch {
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func compareDpkgPart(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := dpkgOrder(a, i), dpkgOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			if i < len(a) {
+				i++
+			}
+			if j < len(b) {
+				j++
+			}
+		}
+
+		si, sj := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		if c := compareNumbers(a[si:i], b[sj:j]); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// compareRPM compares versions like rpm does, where a missing release
+// matches any release.
+func compareRPM(a, b string) int {
+	aEpoch, aVersion, aRelease := splitVersion(a)
+	bEpoch, bVersion, bRelease := splitVersion(b)
+
+	if c := compareNumbers(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareRPMPart(aVersion, bVersion); c != 0 {
+		return c
+	}
+	if aRelease == "" || bRelease == "" {
+		return 0
+	}
+	return compareRPMPart(aRelease, bRelease)
+}
+
+func compareRPMPart(a, b string) int {
+	isSeparator := func(c byte) bool {
+		return !isDigit(c) && !isAlpha(c) && c != '~' && c != '^'
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && isSeparator(a[i]) {
+			i++
+		}
+		for j < len(b) && isSeparator(b[j]) {
+			j++
+		}
+
+		// ~ sorts before everything, even the end of the version
+		aTilde, bTilde := i < len(a) && a[i] == '~', j < len(b) && b[j] == '~'
+		if aTilde || bTilde {
+			if !aTilde {
+				return 1
+			}
+			if !bTilde {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// ^ sorts after the end of the version but before anything else
+		aCaret, bCaret := i < len(a) && a[i] == '^', j < len(b) && b[j] == '^'
+		if aCaret || bCaret {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if !aCaret {
+				return 1
+			}
+			if !bCaret {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		si, sj := i, j
+		numeric := isDigit(a[i])
+		segment := isAlpha
+		if numeric {
+			segment = isDigit
+		}
+		for i < len(a) && segment(a[i]) {
+			i++
+		}
+		for j < len(b) && segment(b[j]) {
+			j++
+		}
+
+		// Numbers are newer than letters
+		if sj == j {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		if numeric {
+			c = compareNumbers(a[si:i], b[sj:j])
+		} else {
+			c = strings.Compare(a[si:i], b[sj:j])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i >= len(a):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// compareSemver compares semantic versions, with or without the v that Go
+// modules have.
+func compareSemver(a, b string) int {
+	aCore, aPre := splitSemver(a)
+	bCore, bPre := splitSemver(b)
+
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for k := 0; k < len(aParts) || k < len(bParts); k++ {
+		aPart, bPart := "0", "0"
+		if k < len(aParts) {
+			aPart = aParts[k]
+		}
+		if k < len(bParts) {
+			bPart = bParts[k]
+		}
+		if c := compareNumbers(aPart, bPart); c != 0 {
+			return c
+		}
+	}
+
+	// Pre-releases are older than the release
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	aIDs, bIDs := strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for k := 0; k < len(aIDs) && k < len(bIDs); k++ {
+		aNumeric, bNumeric := isNumber(aIDs[k]), isNumber(bIDs[k])
+		var c int
+		switch {
+		case aNumeric && bNumeric:
+			c = compareNumbers(aIDs[k], bIDs[k])
+		case aNumeric:
+			c = -1
+		case bNumeric:
+			c = 1
+		default:
+			c = strings.Compare(aIDs[k], bIDs[k])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return len(aIDs) - len(bIDs)
+}
+
+func splitSemver(v string) (string, string) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+
+	pre := ""
+	if i := strings.Index(v, "-"); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+
+	return v, pre
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for k := 0; k < len(s); k++ {
+		if !isDigit(s[k]) {
+			return false
+		}
+	}
+	return true
+}
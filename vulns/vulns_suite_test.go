@@ -0,0 +1,13 @@
+package vulns_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestVulns(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vulns Suite")
+}
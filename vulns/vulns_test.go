@@ -0,0 +1,234 @@
+package vulns_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/scantron"
+	"github.com/pivotal-cf/scantron/vulns"
+)
+
+func vulnerability(id, osvEcosystem, name string, events ...vulns.Event) vulns.Vulnerability {
+	affected := vulns.Affected{
+		Ranges: []vulns.Range{{Type: "ECOSYSTEM", Events: events}},
+	}
+	affected.Package.Ecosystem = osvEcosystem
+	affected.Package.Name = name
+
+	return vulns.Vulnerability{
+		ID:       id,
+		Affected: []vulns.Affected{affected},
+	}
+}
+
+var _ = Describe("Vulns", func() {
+	var (
+		focal  = scantron.OSRelease{ID: "ubuntu", VersionID: "20.04"}
+		jammy  = scantron.OSRelease{ID: "ubuntu", VersionID: "22.04"}
+		buster = scantron.OSRelease{ID: "debian", VersionID: "10"}
+		rhel8  = scantron.OSRelease{ID: "rhel", VersionID: "8.9"}
+		alma8  = scantron.OSRelease{ID: "almalinux", VersionID: "8.9"}
+		rocky9 = scantron.OSRelease{ID: "rocky", VersionID: "9.3"}
+		none   = scantron.OSRelease{}
+	)
+
+	DescribeTable("comparing versions with the first fixed version",
+		func(osvEcosystem string, release scantron.OSRelease, ecosystem, installed, fixed string, affected bool) {
+			feed := vulns.NewFeed([]vulns.Vulnerability{
+				vulnerability("VULN-1", osvEcosystem, "pkg", vulns.Event{Introduced: "0"}, vulns.Event{Fixed: fixed}),
+			})
+
+			matches := feed.Match(scantron.Package{Name: "pkg", Version: installed, Ecosystem: ecosystem}, release)
+			if affected {
+				Expect(matches).To(HaveLen(1))
+				Expect(matches[0].Fixed).To(Equal(fixed))
+			} else {
+				Expect(matches).To(BeEmpty())
+			}
+		},
+		Entry("dpkg older revision", "Ubuntu:20.04:LTS", focal, scantron.DpkgEcosystem, "1.1.1f-1ubuntu2.16", "1.1.1f-1ubuntu2.17", true),
+		Entry("dpkg same version", "Debian:10", buster, scantron.DpkgEcosystem, "2.28-10+deb10u2", "2.28-10+deb10u2", false),
+		Entry("dpkg numeric parts", "Debian:10", buster, scantron.DpkgEcosystem, "1.10-1", "1.9-1", false),
+		Entry("dpkg tilde before release", "Debian:10", buster, scantron.DpkgEcosystem, "2.0~rc1-1", "2.0-1", true),
+		Entry("dpkg epoch", "Debian:10", buster, scantron.DpkgEcosystem, "1:0.9-1", "2.0-1", false),
+		Entry("rpm older release", "Red Hat:enterprise_linux:8::baseos", rhel8, scantron.RPMEcosystem, "1:1.1.1k-7.el8_6", "1:1.1.1k-9.el8_7", true),
+		Entry("rpm newer version", "AlmaLinux:8", alma8, scantron.RPMEcosystem, "4.4.20-5.el8", "4.4.19-12.el8", false),
+		Entry("rpm letters before numbers", "Rocky Linux:9", rocky9, scantron.RPMEcosystem, "1.0a-1", "1.0.1-1", true),
+		Entry("Go module with v", "Go", none, scantron.GoEcosystem, "v0.6.0", "0.7.0", true),
+		Entry("Go pseudo-version", "Go", focal, scantron.GoEcosystem, "v0.0.0-20220722155237-a158d28d115b", "0.0.0", true),
+		Entry("Go pre-release", "Go", none, scantron.GoEcosystem, "v1.2.0-rc.1", "1.2.0", true),
+		Entry("Go stdlib", "Go", none, scantron.GoEcosystem, "1.20.10", "1.20.9", false),
+	)
+
+	Describe("Match", func() {
+		It("matches packages by their source package", func() {
+			feed := vulns.NewFeed([]vulns.Vulnerability{
+				vulnerability("UBUNTU-CVE-2023-0286", "Ubuntu:20.04:LTS", "openssl", vulns.Event{Introduced: "0"}, vulns.Event{Fixed: "1.1.1f-1ubuntu2.17"}),
+			})
+
+			matches := feed.Match(scantron.Package{
+				Name:      "libssl1.1",
+				Version:   "1.1.1f-1ubuntu2.16",
+				Ecosystem: scantron.DpkgEcosystem,
+				Source:    "openssl",
+			}, focal)
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0].ID).To(Equal("UBUNTU-CVE-2023-0286"))
+		})
+
+		It("only matches the entries for the release the package is installed on", func() {
+			affected := func(osvEcosystem, fixed string) vulns.Affected {
+				a := vulns.Affected{
+					Ranges: []vulns.Range{{Type: "ECOSYSTEM", Events: []vulns.Event{{Introduced: "0"}, {Fixed: fixed}}}},
+				}
+				a.Package.Ecosystem = osvEcosystem
+				a.Package.Name = "openssl"
+				return a
+			}
+			feed := vulns.NewFeed([]vulns.Vulnerability{{
+				ID: "CVE-2023-0286",
+				Affected: []vulns.Affected{
+					affected("Ubuntu:20.04:LTS", "1.1.1f-1ubuntu2.17"),
+					affected("Ubuntu:22.04:LTS", "3.0.2-0ubuntu1.8"),
+					affected("Debian:11", "1.1.1n-0+deb11u4"),
+				},
+			}})
+
+			match := func(version string, release scantron.OSRelease) []vulns.Match {
+				return feed.Match(scantron.Package{Name: "openssl", Version: version, Ecosystem: scantron.DpkgEcosystem}, release)
+			}
+
+			matches := match("1.1.1f-1ubuntu2.16", focal)
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0].Fixed).To(Equal("1.1.1f-1ubuntu2.17"))
+
+			Expect(match("1.1.1f-1ubuntu2.17", focal)).To(BeEmpty())
+
+			matches = match("3.0.2-0ubuntu1.7", jammy)
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0].Fixed).To(Equal("3.0.2-0ubuntu1.8"))
+
+			Expect(match("3.0.2-0ubuntu1.8", jammy)).To(BeEmpty())
+			Expect(match("1.1.1f-1ubuntu2.16", buster)).To(BeEmpty())
+		})
+
+		It("does not match OS packages when the release is unknown", func() {
+			feed := vulns.NewFeed([]vulns.Vulnerability{
+				vulnerability("UBUNTU-CVE-2023-0286", "Ubuntu:20.04:LTS", "openssl", vulns.Event{Introduced: "0"}, vulns.Event{Fixed: "1.1.1f-1ubuntu2.17"}),
+			})
+
+			pkg := scantron.Package{Name: "openssl", Version: "1.1.1f-1ubuntu2.16", Ecosystem: scantron.DpkgEcosystem}
+			Expect(feed.Match(pkg, none)).To(BeEmpty())
+			Expect(feed.Match(pkg, scantron.OSRelease{ID: "alpine", VersionID: "3.19.1"})).To(BeEmpty())
+		})
+
+		It("does not match packages of other ecosystems", func() {
+			feed := vulns.NewFeed([]vulns.Vulnerability{
+				vulnerability("RHSA-2023:1405", "Red Hat:enterprise_linux:8::baseos", "openssl", vulns.Event{Introduced: "0"}, vulns.Event{Fixed: "1:1.1.1k-9.el8_7"}),
+			})
+
+			Expect(feed.Match(scantron.Package{Name: "openssl", Version: "1.1.1f-1", Ecosystem: scantron.DpkgEcosystem}, rhel8)).To(BeEmpty())
+		})
+
+		It("matches versions up to the last affected version", func() {
+			feed := vulns.NewFeed([]vulns.Vulnerability{
+				vulnerability("GO-2024-0001", "Go", "example.com/lib", vulns.Event{Introduced: "1.2.0"}, vulns.Event{LastAffected: "1.4.0"}),
+			})
+
+			match := func(version string) []vulns.Match {
+				return feed.Match(scantron.Package{Name: "example.com/lib", Version: version, Ecosystem: scantron.GoEcosystem}, none)
+			}
+			Expect(match("v1.1.9")).To(BeEmpty())
+			Expect(match("v1.4.0")).To(HaveLen(1))
+			Expect(match("v1.4.1")).To(BeEmpty())
+		})
+
+		It("matches listed versions", func() {
+			v := vulns.Vulnerability{ID: "GHSA-1", Affected: []vulns.Affected{{Versions: []string{"1.0.0"}}}}
+			v.Affected[0].Package.Ecosystem = "Go"
+			v.Affected[0].Package.Name = "example.com/lib"
+			v.DatabaseSpecific = map[string]interface{}{"severity": "HIGH"}
+			feed := vulns.NewFeed([]vulns.Vulnerability{v})
+
+			matches := feed.Match(scantron.Package{Name: "example.com/lib", Version: "v1.0.0", Ecosystem: scantron.GoEcosystem}, none)
+			Expect(matches).To(Equal([]vulns.Match{{ID: "GHSA-1", Severity: "HIGH"}}))
+		})
+	})
+
+	Describe("LoadFeed", func() {
+		var tmpdir string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "vulns")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		entry := `{
+		  "id": "GO-2023-1571",
+		  "affected": [{
+		    "package": {"ecosystem": "Go", "name": "golang.org/x/net"},
+		    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.7.0"}]}]
+		  }]
+		}`
+		net := scantron.Package{Name: "golang.org/x/net", Version: "v0.6.0", Ecosystem: scantron.GoEcosystem}
+
+		It("loads a JSON file of one vulnerability", func() {
+			path := filepath.Join(tmpdir, "GO-2023-1571.json")
+			Expect(ioutil.WriteFile(path, []byte(entry), 0644)).To(Succeed())
+
+			feed, err := vulns.LoadFeed(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feed.Len()).To(Equal(1))
+			Expect(feed.Match(net, scantron.OSRelease{})).To(HaveLen(1))
+		})
+
+		It("loads a JSON file of a list of vulnerabilities", func() {
+			path := filepath.Join(tmpdir, "feed.json")
+			Expect(ioutil.WriteFile(path, []byte("["+entry+"]"), 0644)).To(Succeed())
+
+			feed, err := vulns.LoadFeed(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feed.Len()).To(Equal(1))
+			Expect(feed.Match(net, scantron.OSRelease{})).To(HaveLen(1))
+		})
+
+		It("loads a zip of JSON files", func() {
+			path := filepath.Join(tmpdir, "all.zip")
+			f, err := os.Create(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			zw := zip.NewWriter(f)
+			w, err := zw.Create("GO-2023-1571.json")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = w.Write([]byte(entry))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zw.Close()).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			feed, err := vulns.LoadFeed(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(feed.Len()).To(Equal(1))
+			Expect(feed.Match(net, scantron.OSRelease{})).To(HaveLen(1))
+		})
+
+		It("fails for malformed files", func() {
+			path := filepath.Join(tmpdir, "feed.json")
+			Expect(ioutil.WriteFile(path, []byte("not json"), 0644)).To(Succeed())
+
+			_, err := vulns.LoadFeed(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("feed.json is malformed"))
+		})
+	})
+})